@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+
+	"pybot-simulator/api/client"
+)
+
+// maxIdempotentResponses limita cuántas respuestas se recuerdan.
+const maxIdempotentResponses = 4096
+
+// cachedResponse es una respuesta ya enviada para una clave de idempotencia.
+type cachedResponse struct {
+	method string
+	path   string
+	status int
+	header http.Header
+	body   []byte
+}
+
+// idempotency repite la respuesta original cuando llega otra vez la misma
+// cabecera Idempotency-Key, sin volver a ejecutar el handler. Así un
+// reintento tras un timeout no crea un segundo período ni suma dos veces
+// la misma recolección. Cada respuesta devuelve la clave, que es como el
+// cliente sabe que puede reintentar sin riesgo.
+type idempotency struct {
+	next http.Handler
+
+	mu        sync.Mutex
+	responses map[string]*cachedResponse
+	order     []string
+	inFlight  map[string]chan struct{}
+}
+
+func newIdempotency(next http.Handler) *idempotency {
+	return &idempotency{
+		next:      next,
+		responses: map[string]*cachedResponse{},
+		inFlight:  map[string]chan struct{}{},
+	}
+}
+
+func (h *idempotency) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get(client.IdempotencyHeader)
+	if key == "" || r.Method == http.MethodGet {
+		h.next.ServeHTTP(w, r)
+		return
+	}
+
+	// Si la misma clave está en curso, se espera a que termine
+	for {
+		h.mu.Lock()
+		if cached, ok := h.responses[key]; ok {
+			h.mu.Unlock()
+			if cached.method != r.Method || cached.path != r.URL.Path {
+				writeError(w, http.StatusUnprocessableEntity, "Idempotency-Key reutilizada en otra petición")
+				return
+			}
+			for k, v := range cached.header {
+				w.Header()[k] = v
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(cached.status)
+			w.Write(cached.body)
+			return
+		}
+		wait, busy := h.inFlight[key]
+		if !busy {
+			h.inFlight[key] = make(chan struct{})
+			h.mu.Unlock()
+			break
+		}
+		h.mu.Unlock()
+		<-wait
+	}
+
+	// Devolver la clave le confirma al cliente que se respeta
+	w.Header().Set(client.IdempotencyHeader, key)
+	rec := &recorder{ResponseWriter: w, status: http.StatusOK}
+	h.next.ServeHTTP(rec, r)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	close(h.inFlight[key])
+	delete(h.inFlight, key)
+	// Los errores del servidor no se recuerdan: el cliente puede reintentar
+	if rec.status >= 500 {
+		return
+	}
+	h.responses[key] = &cachedResponse{
+		method: r.Method,
+		path:   r.URL.Path,
+		status: rec.status,
+		header: w.Header().Clone(),
+		body:   rec.body.Bytes(),
+	}
+	h.order = append(h.order, key)
+	if len(h.order) > maxIdempotentResponses {
+		delete(h.responses, h.order[0])
+		h.order = h.order[1:]
+	}
+}
+
+// recorder copia la respuesta mientras se escribe.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(p []byte) (int, error) {
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
@@ -0,0 +1,121 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/client"
+)
+
+// counting cuenta cuántas veces corre y responde con ese número. Con fail,
+// las primeras fail veces responde 500.
+type counting struct {
+	calls atomic.Int32
+	fail  int32
+	delay time.Duration
+}
+
+func (c *counting) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := c.calls.Add(1)
+	time.Sleep(c.delay)
+	if n <= c.fail {
+		http.Error(w, "falla", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, "%d", n)
+}
+
+func serve(h http.Handler, method, path, key string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if key != "" {
+		req.Header.Set(client.IdempotencyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	next := &counting{}
+	h := newIdempotency(next)
+
+	first := serve(h, http.MethodPatch, "/sensors/", "clave")
+	again := serve(h, http.MethodPatch, "/sensors/", "clave")
+	if n := next.calls.Load(); n != 1 {
+		t.Fatalf("el handler corrió %d veces con la misma clave", n)
+	}
+	if again.Code != first.Code || again.Body.String() != first.Body.String() {
+		t.Errorf("la repetición respondió %d %q, la original %d %q", again.Code, again.Body, first.Code, first.Body)
+	}
+	if again.Header().Get("Idempotent-Replayed") != "true" || first.Header().Get("Idempotent-Replayed") != "" {
+		t.Error("Idempotent-Replayed no marca solo la repetición")
+	}
+	// Las dos respuestas confirman la clave al cliente
+	for _, res := range []*httptest.ResponseRecorder{first, again} {
+		if got := res.Header().Get(client.IdempotencyHeader); got != "clave" {
+			t.Errorf("la respuesta devolvió la clave %q", got)
+		}
+	}
+
+	// Otra clave, sin clave o un GET corren el handler
+	serve(h, http.MethodPatch, "/sensors/", "otra")
+	serve(h, http.MethodPatch, "/sensors/", "")
+	serve(h, http.MethodGet, "/sensors/", "clave")
+	if n := next.calls.Load(); n != 4 {
+		t.Errorf("el handler corrió %d veces, se esperaban 4", n)
+	}
+}
+
+func TestIdempotencyRejectsReusedKey(t *testing.T) {
+	h := newIdempotency(&counting{})
+	serve(h, http.MethodPost, "/sensors/weight", "clave")
+	if res := serve(h, http.MethodPost, "/sensors/gps", "clave"); res.Code != http.StatusUnprocessableEntity {
+		t.Errorf("la clave reutilizada en otra ruta respondió %d", res.Code)
+	}
+}
+
+func TestIdempotencyForgetsServerErrors(t *testing.T) {
+	next := &counting{fail: 1}
+	h := newIdempotency(next)
+	if res := serve(h, http.MethodPost, "/sensors/weight", "clave"); res.Code != http.StatusInternalServerError {
+		t.Fatalf("primer intento %d", res.Code)
+	}
+	// Tras un 5xx el reintento con la misma clave se ejecuta
+	if res := serve(h, http.MethodPost, "/sensors/weight", "clave"); res.Code != http.StatusCreated {
+		t.Errorf("reintento %d", res.Code)
+	}
+	if n := next.calls.Load(); n != 2 {
+		t.Errorf("el handler corrió %d veces, se esperaban 2", n)
+	}
+}
+
+func TestIdempotencyWaitsForInFlight(t *testing.T) {
+	next := &counting{delay: 50 * time.Millisecond}
+	h := newIdempotency(next)
+
+	var wg sync.WaitGroup
+	bodies := make([]string, 4)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			bodies[i] = serve(h, http.MethodPost, "/sensors/weight", "clave").Body.String()
+		}()
+	}
+	wg.Wait()
+	if n := next.calls.Load(); n != 1 {
+		t.Errorf("con peticiones simultáneas el handler corrió %d veces", n)
+	}
+	for _, b := range bodies {
+		if b != "1" {
+			t.Errorf("respuestas %q, se esperaba la misma en todas", bodies)
+			break
+		}
+	}
+}
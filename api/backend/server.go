@@ -0,0 +1,352 @@
+package backend
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"pybot-simulator/api/client"
+)
+
+// Server es un sustituto local de la API de pybot. Implementa las mismas
+// rutas que usan los servicios, relativas a la URL base de la API:
+//
+//	GET   /workPeriods/                    último período abierto
+//	POST  /workPeriods/                    crear período
+//	PATCH /workPeriods/?endHour=&id=       cerrar período
+//	PUT   /workPeriods/                    actualizar lectura
+//	POST  /workPeriods/readings            crear lectura
+//	GET   /workPeriods/readingsGlobal?id=  lectura global
+//	POST  /sensors/weight                  registrar peso
+//	POST  /sensors/gps                     registrar GPS
+//	POST  /sensors/weight/bulk             registrar varios pesos
+//	POST  /sensors/gps/bulk                registrar varios GPS
+//	POST  /sensors/waste                   crear recolección
+//	PATCH /sensors/?Id=                    sumar a una recolección
+//	GET   /backup/                         backup
+//
+// Los cuerpos con Content-Encoding: gzip se descomprimen antes de decodificar.
+// Las escrituras con cabecera Idempotency-Key se ejecutan una sola vez; los
+// reintentos con la misma clave reciben la respuesta original.
+//
+// Sirve tanto para correr el juego sin conexión como para pruebas con httptest.
+type Server struct {
+	store   *Store
+	mux     *http.ServeMux
+	handler http.Handler
+	auth    client.Auth
+}
+
+// Option ajusta un Server al crearlo.
+type Option func(s *Server)
+
+// WithAuth exige credenciales en todas las rutas: el token bearer y/o la API
+// key de auth (si los dos están configurados basta con uno).
+func WithAuth(auth client.Auth) Option {
+	return func(s *Server) { s.auth = auth }
+}
+
+// NewServer crea el handler sobre store.
+func NewServer(store *Store, opts ...Option) *Server {
+	s := &Server{store: store, mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("GET /workPeriods/{$}", s.getLastPeriod)
+	s.mux.HandleFunc("POST /workPeriods/{$}", s.createPeriod)
+	s.mux.HandleFunc("PATCH /workPeriods/{$}", s.closePeriod)
+	s.mux.HandleFunc("PUT /workPeriods/{$}", s.putReading)
+	s.mux.HandleFunc("POST /workPeriods/readings", s.putReading)
+	s.mux.HandleFunc("GET /workPeriods/readingsGlobal", s.getReadingsGlobal)
+	s.mux.HandleFunc("POST /sensors/weight", s.addWeight)
+	s.mux.HandleFunc("POST /sensors/gps", s.addGPS)
+	s.mux.HandleFunc("POST /sensors/weight/bulk", s.addWeights)
+	s.mux.HandleFunc("POST /sensors/gps/bulk", s.addGPSBatch)
+	s.mux.HandleFunc("POST /sensors/waste", s.createWasteCollection)
+	s.mux.HandleFunc("PATCH /sensors/{$}", s.incrementWasteCollection)
+	s.mux.HandleFunc("GET /backup/{$}", s.backup)
+
+	s.handler = newIdempotency(s.mux)
+	return s
+}
+
+// Store devuelve el almacenamiento del servidor.
+func (s *Server) Store() *Store {
+	return s.store
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pybot"`)
+		writeError(w, http.StatusUnauthorized, "credenciales inválidas")
+		return
+	}
+	s.handler.ServeHTTP(w, r)
+}
+
+// authorized comprueba las credenciales de r contra las configuradas.
+func (s *Server) authorized(r *http.Request) bool {
+	if s.auth.Token == "" && s.auth.APIKey == "" {
+		return true
+	}
+	equal := func(got, want string) bool {
+		return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+	}
+	if s.auth.Token != "" && equal(r.Header.Get("Authorization"), "Bearer "+s.auth.Token) {
+		return true
+	}
+	header := s.auth.APIKeyHeader
+	if header == "" {
+		header = client.DefaultAPIKeyHeader
+	}
+	return s.auth.APIKey != "" && equal(r.Header.Get(header), s.auth.APIKey)
+}
+
+// Running es un servidor local escuchando en un puerto.
+type Running struct {
+	URL    string
+	server *http.Server
+}
+
+// Start escucha en addr (por ejemplo "127.0.0.1:0" para un puerto libre) y
+// sirve en segundo plano. URL es la URL base que hay que usar como api_url.
+func Start(addr string, store *Store, opts ...Option) (*Running, error) {
+	return StartTLS(addr, store, nil, opts...)
+}
+
+// StartTLS es Start sirviendo https con tlsConfig (que debe traer el
+// certificado del servidor). Con tlsConfig nil sirve http.
+func StartTLS(addr string, store *Store, tlsConfig *tls.Config, opts ...Option) (*Running, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("error escuchando en %s: %w", addr, err)
+	}
+	scheme := "http://"
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+		scheme = "https://"
+	}
+	srv := &http.Server{Handler: NewServer(store, opts...), ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("[Backend] Error sirviendo: %v", err)
+		}
+	}()
+	return &Running{URL: scheme + ln.Addr().String(), server: srv}, nil
+}
+
+// Close detiene el servidor esperando a las peticiones en curso.
+func (r *Running) Close(ctx context.Context) error {
+	return r.server.Shutdown(ctx)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"message": msg})
+}
+
+// writeStoreError traduce errores del store a respuestas HTTP.
+func writeStoreError(w http.ResponseWriter, err error) {
+	if errors.Is(err, ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	body := r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "":
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "gzip inválido: "+err.Error())
+			return false
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		writeError(w, http.StatusUnsupportedMediaType, "Content-Encoding no soportado")
+		return false
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "JSON inválido: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func queryID(w http.ResponseWriter, r *http.Request, key string) (int64, bool) {
+	id, err := strconv.ParseInt(r.URL.Query().Get(key), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("parámetro %s inválido", key))
+		return 0, false
+	}
+	return id, true
+}
+
+func (s *Server) getLastPeriod(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, client.LastPeriodResponse{LastPeriod: s.store.LastOpenPeriod()})
+}
+
+func (s *Server) createPeriod(w http.ResponseWriter, r *http.Request) {
+	var p client.WorkPeriod
+	if !decode(w, r, &p) {
+		return
+	}
+	if p.StartHour == "" {
+		writeError(w, http.StatusBadRequest, "start_hour es obligatorio")
+		return
+	}
+	id, err := s.store.CreatePeriod(p)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var res client.CreatePeriodResponse
+	res.Data.WorkPeriodsID = id
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) closePeriod(w http.ResponseWriter, r *http.Request) {
+	id, ok := queryID(w, r, "id")
+	if !ok {
+		return
+	}
+	endHour := r.URL.Query().Get("endHour")
+	if endHour == "" {
+		writeError(w, http.StatusBadRequest, "endHour es obligatorio")
+		return
+	}
+	if err := s.store.ClosePeriod(id, endHour); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"period_id": id, "end_hour": endHour}})
+}
+
+func (s *Server) putReading(w http.ResponseWriter, r *http.Request) {
+	var reading client.Reading
+	if !decode(w, r, &reading) {
+		return
+	}
+	if err := s.store.PutReading(reading); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": reading})
+}
+
+func (s *Server) getReadingsGlobal(w http.ResponseWriter, r *http.Request) {
+	id, ok := queryID(w, r, "id")
+	if !ok {
+		return
+	}
+	reading, err := s.store.Reading(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, client.ReadingsGlobalResponse{LastReading: reading})
+}
+
+func (s *Server) addWeight(w http.ResponseWriter, r *http.Request) {
+	var record client.WeightRecord
+	if !decode(w, r, &record) {
+		return
+	}
+	id, err := s.store.AddWeight(record)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	record.WeightDataID = id
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": record})
+}
+
+func (s *Server) addGPS(w http.ResponseWriter, r *http.Request) {
+	var record client.GPSRecord
+	if !decode(w, r, &record) {
+		return
+	}
+	if err := s.store.AddGPS(record); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": record})
+}
+
+func (s *Server) addWeights(w http.ResponseWriter, r *http.Request) {
+	var records []client.WeightRecord
+	if !decode(w, r, &records) {
+		return
+	}
+	if err := s.store.AddWeights(records); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": map[string]int{"count": len(records)}})
+}
+
+func (s *Server) addGPSBatch(w http.ResponseWriter, r *http.Request) {
+	var records []client.GPSRecord
+	if !decode(w, r, &records) {
+		return
+	}
+	if err := s.store.AddGPSBatch(records); err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]interface{}{"data": map[string]int{"count": len(records)}})
+}
+
+func (s *Server) createWasteCollection(w http.ResponseWriter, r *http.Request) {
+	var collection client.WasteCollection
+	if !decode(w, r, &collection) {
+		return
+	}
+	id, err := s.store.CreateWasteCollection(collection)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	var res client.CreateWasteCollectionResponse
+	res.Data.WasteCollectionID = id
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) incrementWasteCollection(w http.ResponseWriter, r *http.Request) {
+	id, ok := queryID(w, r, "Id")
+	if !ok {
+		return
+	}
+	collection, err := s.store.IncrementWasteCollection(id)
+	if err != nil {
+		writeStoreError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"data": collection})
+}
+
+func (s *Server) backup(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status": "ok",
+		"counts": s.store.Counts(),
+	})
+}
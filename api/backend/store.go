@@ -0,0 +1,284 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"pybot-simulator/api/client"
+)
+
+// ErrNotFound se devuelve cuando el recurso pedido no existe.
+var ErrNotFound = errors.New("no encontrado")
+
+// snapshot es todo el estado del backend; es lo que se guarda en disco.
+type snapshot struct {
+	NextID           int64                             `json:"next_id"`
+	Periods          map[int64]*client.WorkPeriod      `json:"periods"`
+	Readings         map[int64]*client.Reading         `json:"readings"`
+	Weights          []client.WeightRecord             `json:"weights"`
+	GPS              []client.GPSRecord                `json:"gps"`
+	WasteCollections map[int64]*client.WasteCollection `json:"waste_collections"`
+}
+
+// Store guarda los datos del backend en memoria y, si tiene ruta, los
+// persiste en un archivo JSON tras cada escritura.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data snapshot
+}
+
+// NewMemoryStore crea un almacenamiento solo en memoria.
+func NewMemoryStore() *Store {
+	return &Store{data: snapshot{
+		NextID:           1,
+		Periods:          map[int64]*client.WorkPeriod{},
+		Readings:         map[int64]*client.Reading{},
+		WasteCollections: map[int64]*client.WasteCollection{},
+	}}
+}
+
+// OpenFileStore carga (o crea) un almacenamiento respaldado por path.
+func OpenFileStore(path string) (*Store, error) {
+	s := NewMemoryStore()
+	s.path = path
+
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo %s: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, &s.data); err != nil {
+		return nil, fmt.Errorf("error decodificando %s: %w", path, err)
+	}
+	if s.data.Periods == nil {
+		s.data.Periods = map[int64]*client.WorkPeriod{}
+	}
+	if s.data.Readings == nil {
+		s.data.Readings = map[int64]*client.Reading{}
+	}
+	if s.data.WasteCollections == nil {
+		s.data.WasteCollections = map[int64]*client.WasteCollection{}
+	}
+	return s, nil
+}
+
+// save se llama con mu tomado. Escribe a un temporal y lo renombra para no
+// dejar el archivo a medias.
+func (s *Store) save() error {
+	if s.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func (s *Store) nextID() int64 {
+	id := s.data.NextID
+	s.data.NextID++
+	return id
+}
+
+// CreatePeriod guarda un período nuevo y devuelve su ID.
+func (s *Store) CreatePeriod(p client.WorkPeriod) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p.PeriodID = s.nextID()
+	s.data.Periods[p.PeriodID] = &p
+	return p.PeriodID, s.save()
+}
+
+// Period devuelve un período por ID.
+func (s *Store) Period(id int64) (client.WorkPeriod, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.data.Periods[id]
+	if !ok {
+		return client.WorkPeriod{}, ErrNotFound
+	}
+	return *p, nil
+}
+
+// Periods devuelve todos los períodos ordenados por ID.
+func (s *Store) Periods() []client.WorkPeriod {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]client.WorkPeriod, 0, len(s.data.Periods))
+	for _, p := range s.data.Periods {
+		out = append(out, *p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].PeriodID < out[j].PeriodID })
+	return out
+}
+
+// LastOpenPeriod devuelve el período abierto más reciente y la hora de su
+// último dato, o un LastPeriod vacío si no hay ninguno abierto.
+func (s *Store) LastOpenPeriod() client.LastPeriod {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var last *client.WorkPeriod
+	for _, p := range s.data.Periods {
+		if p.EndHour == "" && (last == nil || p.PeriodID > last.PeriodID) {
+			last = p
+		}
+	}
+	if last == nil {
+		return client.LastPeriod{}
+	}
+
+	lastHour := last.StartHour
+	for _, w := range s.data.Weights {
+		if w.PeriodID == last.PeriodID && w.HourPeriod > lastHour {
+			lastHour = w.HourPeriod
+		}
+	}
+	return client.LastPeriod{PeriodID: last.PeriodID, LastHour: lastHour}
+}
+
+// ClosePeriod guarda la hora de fin de un período.
+func (s *Store) ClosePeriod(id int64, endHour string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.data.Periods[id]
+	if !ok {
+		return ErrNotFound
+	}
+	p.EndHour = endHour
+	return s.save()
+}
+
+// PutReading crea o reemplaza la lectura de un período.
+func (s *Store) PutReading(r client.Reading) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Periods[r.PeriodID]; !ok {
+		return ErrNotFound
+	}
+	s.data.Readings[r.PeriodID] = &r
+	return s.save()
+}
+
+// Reading devuelve la lectura global de un período. Si hay pesos registrados
+// después de la última lectura, el peso es el del último registro.
+func (s *Store) Reading(periodID int64) (client.Reading, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Periods[periodID]; !ok {
+		return client.Reading{}, ErrNotFound
+	}
+	r := client.Reading{PeriodID: periodID}
+	if stored, ok := s.data.Readings[periodID]; ok {
+		r = *stored
+	}
+	for _, w := range s.data.Weights {
+		if w.PeriodID == periodID && w.Weight > r.WeightWaste {
+			r.WeightWaste = w.Weight
+		}
+	}
+	return r, nil
+}
+
+// AddWeight guarda una lectura de peso y devuelve su ID.
+func (s *Store) AddWeight(w client.WeightRecord) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.WeightDataID = s.nextID()
+	s.data.Weights = append(s.data.Weights, w)
+	return w.WeightDataID, s.save()
+}
+
+// AddWeights guarda varias lecturas de peso de una vez.
+func (s *Store) AddWeights(records []client.WeightRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, w := range records {
+		w.WeightDataID = s.nextID()
+		s.data.Weights = append(s.data.Weights, w)
+	}
+	return s.save()
+}
+
+// AddGPSBatch guarda varias lecturas de GPS de una vez.
+func (s *Store) AddGPSBatch(records []client.GPSRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.GPS = append(s.data.GPS, records...)
+	return s.save()
+}
+
+// AddGPS guarda una lectura de GPS.
+func (s *Store) AddGPS(g client.GPSRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.GPS = append(s.data.GPS, g)
+	return s.save()
+}
+
+// CreateWasteCollection guarda un contador de recolección y devuelve su ID.
+func (s *Store) CreateWasteCollection(w client.WasteCollection) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w.WasteCollectionID = s.nextID()
+	s.data.WasteCollections[w.WasteCollectionID] = &w
+	return w.WasteCollectionID, s.save()
+}
+
+// WasteCollections devuelve los contadores de un período ordenados por ID.
+func (s *Store) WasteCollections(periodID int64) ([]client.WasteCollection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Periods[periodID]; !ok {
+		return nil, ErrNotFound
+	}
+	out := []client.WasteCollection{}
+	for _, w := range s.data.WasteCollections {
+		if w.PeriodID == periodID {
+			out = append(out, *w)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].WasteCollectionID < out[j].WasteCollectionID })
+	return out, nil
+}
+
+// IncrementWasteCollection suma uno al contador de una recolección.
+func (s *Store) IncrementWasteCollection(id int64) (client.WasteCollection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w, ok := s.data.WasteCollections[id]
+	if !ok {
+		return client.WasteCollection{}, ErrNotFound
+	}
+	w.Amount++
+	return *w, s.save()
+}
+
+// Counts resume cuántos registros hay de cada tipo.
+func (s *Store) Counts() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return map[string]int{
+		"periods":           len(s.data.Periods),
+		"readings":          len(s.data.Readings),
+		"weights":           len(s.data.Weights),
+		"gps":               len(s.data.GPS),
+		"waste_collections": len(s.data.WasteCollections),
+	}
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// Auth son las credenciales HTTP de la API. Si hay Token se manda como
+// "Authorization: Bearer <Token>"; si hay APIKey, en la cabecera APIKeyHeader.
+type Auth struct {
+	Token        string
+	APIKey       string
+	APIKeyHeader string // por defecto X-API-Key
+}
+
+// DefaultAPIKeyHeader es la cabecera de la API key si no se indica otra.
+const DefaultAPIKeyHeader = "X-API-Key"
+
+// apply agrega las cabeceras de autenticación a req.
+func (a Auth) apply(req *http.Request) {
+	if a.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.Token)
+	}
+	if a.APIKey != "" {
+		header := a.APIKeyHeader
+		if header == "" {
+			header = DefaultAPIKeyHeader
+		}
+		req.Header.Set(header, a.APIKey)
+	}
+}
+
+// WithAuth agrega credenciales a todas las peticiones.
+func WithAuth(auth Auth) Option {
+	return func(c *Client) { c.auth = auth }
+}
+
+// WithTLS usa cfg (CA propia, certificado de cliente, pines) en las
+// conexiones https. Con cfg nil se usa la configuración por defecto.
+func WithTLS(cfg *tls.Config) Option {
+	return func(c *Client) {
+		if cfg == nil {
+			return
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = cfg
+		c.http.Transport = transport
+	}
+}
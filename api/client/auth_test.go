@@ -0,0 +1,93 @@
+package client_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/backend"
+	"pybot-simulator/api/client"
+)
+
+// newAuthServer levanta el sustituto local exigiendo auth.
+func newAuthServer(t *testing.T, auth client.Auth) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(backend.NewServer(backend.NewMemoryStore(), backend.WithAuth(auth)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// lastPeriod hace una llamada cualquiera a la API con auth.
+func lastPeriod(url string, auth client.Auth, opts ...client.Option) error {
+	opts = append(opts, client.WithRetry(client.NoRetry), client.WithAuth(auth))
+	_, err := client.New(url, 5*time.Second, opts...).GetLastPeriod(context.Background())
+	return err
+}
+
+func TestAuth(t *testing.T) {
+	cases := []struct {
+		name   string
+		server client.Auth
+		client client.Auth
+		ok     bool
+	}{
+		{"sin auth", client.Auth{}, client.Auth{}, true},
+		{"bearer", client.Auth{Token: "secreto"}, client.Auth{Token: "secreto"}, true},
+		{"bearer incorrecto", client.Auth{Token: "secreto"}, client.Auth{Token: "otro"}, false},
+		{"bearer faltante", client.Auth{Token: "secreto"}, client.Auth{}, false},
+		{"API key", client.Auth{APIKey: "clave"}, client.Auth{APIKey: "clave"}, true},
+		{"API key incorrecta", client.Auth{APIKey: "clave"}, client.Auth{APIKey: "otra"}, false},
+		{"API key como bearer", client.Auth{APIKey: "clave"}, client.Auth{Token: "clave"}, false},
+		{"API key en otra cabecera", client.Auth{APIKey: "clave", APIKeyHeader: "X-Pybot-Key"},
+			client.Auth{APIKey: "clave", APIKeyHeader: "X-Pybot-Key"}, true},
+		{"API key en la cabecera por defecto", client.Auth{APIKey: "clave", APIKeyHeader: "X-Pybot-Key"},
+			client.Auth{APIKey: "clave"}, false},
+		{"basta el bearer", client.Auth{Token: "secreto", APIKey: "clave"}, client.Auth{Token: "secreto"}, true},
+		{"basta la API key", client.Auth{Token: "secreto", APIKey: "clave"}, client.Auth{Token: "otro", APIKey: "clave"}, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := newAuthServer(t, tc.server)
+			err := lastPeriod(srv.URL, tc.client)
+			if tc.ok && err != nil {
+				t.Errorf("se rechazó: %v", err)
+			}
+			if !tc.ok && client.StatusCode(err) != http.StatusUnauthorized {
+				t.Errorf("error %v, se esperaba 401", err)
+			}
+		})
+	}
+}
+
+func TestWithTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(backend.NewServer(backend.NewMemoryStore(), backend.WithAuth(client.Auth{Token: "secreto"})))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // los handshakes rechazados son esperados
+	srv.StartTLS()
+	defer srv.Close()
+	auth := client.Auth{Token: "secreto"}
+
+	// El certificado de prueba no es de una CA conocida
+	if err := lastPeriod(srv.URL, auth); err == nil {
+		t.Error("se aceptó el servidor sin su CA")
+	}
+	if err := lastPeriod(srv.URL, auth, client.WithTLS(nil)); err == nil {
+		t.Error("WithTLS(nil) aceptó el servidor sin su CA")
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	cfg := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if err := lastPeriod(srv.URL, auth, client.WithTLS(cfg)); err != nil {
+		t.Errorf("con la CA del servidor: %v", err)
+	}
+	// TLS y auth se verifican por separado
+	if err := lastPeriod(srv.URL, client.Auth{}, client.WithTLS(cfg)); client.StatusCode(err) != http.StatusUnauthorized {
+		t.Errorf("sin token por https: %v, se esperaba 401", err)
+	}
+}
@@ -0,0 +1,274 @@
+package client
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Client es el cliente REST tipado de la API del backend de pybot.
+// Todas las peticiones pasan por do, que arma la URL, codifica el cuerpo
+// y convierte las respuestas de error en *APIError.
+type Client struct {
+	baseURL string
+	http    *http.Client
+	retry   RetryPolicy
+	auth    Auth
+	health  healthTracker
+	// replays se enciende cuando el servidor devuelve la Idempotency-Key de
+	// una petición: desde ahí se sabe que no aplica dos veces la misma clave
+	replays atomic.Bool
+}
+
+// Option ajusta un Client al crearlo.
+type Option func(c *Client)
+
+// WithRetry cambia la política de reintentos (por defecto DefaultRetryPolicy).
+func WithRetry(policy RetryPolicy) Option {
+	return func(c *Client) { c.retry = policy }
+}
+
+// New crea un cliente para apiBaseURL (por ejemplo https://pybot.aleosh.online/sensors).
+func New(apiBaseURL string, timeout time.Duration, opts ...Option) *Client {
+	c := &Client{
+		baseURL: strings.TrimRight(apiBaseURL, "/"),
+		http:    &http.Client{Timeout: timeout},
+		retry:   DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// RetryPolicy devuelve la política de reintentos del cliente.
+func (c *Client) RetryPolicy() RetryPolicy {
+	return c.retry
+}
+
+// ReplaysIdempotencyKeys indica si el servidor ya confirmó que respeta
+// Idempotency-Key, devolviendo la cabecera en alguna respuesta. Hasta
+// entonces una clave no alcanza para repetir sin riesgo un POST o un PATCH
+// que falló sin saber si se aplicó.
+func (c *Client) ReplaysIdempotencyKeys() bool {
+	return c.replays.Load()
+}
+
+// BaseURL devuelve la URL base configurada.
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// APIError es una respuesta HTTP con status >= 400.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	// Body es el cuerpo de error decodificado como JSON, o nil si no era JSON.
+	Body map[string]interface{}
+	// RawBody es el cuerpo tal cual llegó (recortado).
+	RawBody string
+	// RetryAfter es la espera pedida por el servidor (cabecera Retry-After), si la hubo.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message()
+	if msg == "" {
+		return fmt.Sprintf("[FetchAPI] %s %s: status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("[FetchAPI] %s %s: status %d: %s", e.Method, e.Path, e.StatusCode, msg)
+}
+
+// Message extrae el mensaje de error del cuerpo, si lo hay.
+func (e *APIError) Message() string {
+	for _, key := range []string{"message", "error", "detail"} {
+		if v, ok := e.Body[key].(string); ok && v != "" {
+			return v
+		}
+	}
+	return strings.TrimSpace(e.RawBody)
+}
+
+// StatusCode devuelve el status de err si es un *APIError, o 0.
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsTemporary indica si err es un fallo pasajero: error de red o un status
+// 408, 429 o 5xx. Son los errores tras los que vale la pena reintentar.
+func IsTemporary(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return true
+	}
+	switch code := StatusCode(err); {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests:
+		return true
+	case code >= 500:
+		return true
+	}
+	return false
+}
+
+// IsAmbiguous indica si err deja la duda de si el servidor aplicó la
+// petición: la conexión se cortó o el tiempo venció después de mandarla, o el
+// servidor falló a la mitad (5xx). No lo es si la petición no llegó a salir
+// (error al conectar) ni si el servidor la rechazó sin procesarla (408, 429
+// o 503).
+func IsAmbiguous(err error) bool {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		var opErr *net.OpError
+		return !errors.As(err, &opErr) || opErr.Op != "dial"
+	}
+	switch code := StatusCode(err); {
+	case code == http.StatusRequestTimeout, code == http.StatusTooManyRequests, code == http.StatusServiceUnavailable:
+		return false
+	case code >= 500:
+		return true
+	}
+	return false
+}
+
+// maxErrorBody limita cuánto del cuerpo de error se guarda.
+const maxErrorBody = 4 << 10
+
+// IsBulkUnsupported indica si err significa que el servidor no tiene la ruta
+// de envío por lotes (404, 405, 415 o 501), y hay que mandar uno por uno.
+func IsBulkUnsupported(err error) bool {
+	switch StatusCode(err) {
+	case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusUnsupportedMediaType, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+// do ejecuta una petición. in se codifica como JSON si no es nil; la
+// respuesta se decodifica en out si no es nil. Los fallos pasajeros se
+// reintentan según la política del cliente, pero solo si repetir la petición
+// es seguro: métodos idempotentes o peticiones con clave de idempotencia. Una
+// petición con clave que falló de forma ambigua se repite solo si el
+// servidor ya confirmó que respeta las claves.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, in, out interface{}) error {
+	return c.doWith(ctx, method, path, query, in, out, false)
+}
+
+// doWith es do con la opción de comprimir el cuerpo con gzip.
+func (c *Client) doWith(ctx context.Context, method, path string, query url.Values, in, out interface{}, compress bool) error {
+	fullURL := c.baseURL + path
+	if len(query) > 0 {
+		fullURL += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("[FetchAPI] Error codificando JSON: %w", err)
+		}
+		payload = data
+	}
+	if compress && payload != nil {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		zw.Write(payload)
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("[FetchAPI] Error comprimiendo cuerpo: %w", err)
+		}
+		payload = buf.Bytes()
+	}
+
+	key := IdempotencyKey(ctx)
+	retryable := (key != "" || isIdempotentMethod(method)) && !isSingleAttempt(ctx)
+
+	for attempt := 1; ; attempt++ {
+		err := c.doOnce(ctx, method, path, fullURL, key, payload, compress, out)
+		if err == nil || !retryable || !IsTemporary(err) || attempt >= c.retry.MaxAttempts {
+			return err
+		}
+		if !isIdempotentMethod(method) && IsAmbiguous(err) && !c.ReplaysIdempotencyKeys() {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(c.retry.Wait(attempt, err)):
+		}
+	}
+}
+
+// doOnce hace un solo intento de la petición.
+func (c *Client) doOnce(ctx context.Context, method, path, fullURL, key string, payload []byte, compressed bool, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, fullURL, body)
+	if err != nil {
+		return fmt.Errorf("[FetchAPI] Error creando request: %w", err)
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+		if compressed {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+	}
+	req.Header.Set("Accept", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyHeader, key)
+	}
+	c.auth.apply(req)
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		err = fmt.Errorf("[FetchAPI] %s %s: %w", method, path, err)
+		c.health.record(start, 0, err)
+		return err
+	}
+	defer resp.Body.Close()
+	if key != "" && resp.Header.Get(IdempotencyHeader) == key {
+		c.replays.Store(true)
+	}
+
+	if resp.StatusCode >= 400 {
+		raw, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		apiErr := &APIError{Method: method, Path: path, StatusCode: resp.StatusCode, RawBody: string(raw)}
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+			apiErr.RetryAfter = time.Duration(secs) * time.Second
+		}
+		var decoded map[string]interface{}
+		if json.Unmarshal(raw, &decoded) == nil {
+			apiErr.Body = decoded
+		}
+		c.health.record(start, resp.StatusCode, apiErr)
+		return apiErr
+	}
+	c.health.record(start, resp.StatusCode, nil)
+
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("[FetchAPI] Error decodificando JSON de %s %s: %w", method, path, err)
+	}
+	return nil
+}
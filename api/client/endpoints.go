@@ -0,0 +1,111 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Rutas de la API relativas a la URL base.
+const (
+	workPeriodsPath = "/workPeriods"
+	sensorsPath     = "/sensors"
+)
+
+// GetLastPeriod obtiene el último período registrado (GET /workPeriods/).
+func (c *Client) GetLastPeriod(ctx context.Context) (LastPeriod, error) {
+	var res LastPeriodResponse
+	err := c.do(ctx, http.MethodGet, workPeriodsPath+"/", nil, nil, &res)
+	return res.LastPeriod, err
+}
+
+// CreatePeriod crea un período (POST /workPeriods/) y devuelve su ID.
+func (c *Client) CreatePeriod(ctx context.Context, period WorkPeriod) (int64, error) {
+	var res CreatePeriodResponse
+	if err := c.do(ctx, http.MethodPost, workPeriodsPath+"/", nil, period, &res); err != nil {
+		return 0, err
+	}
+	if res.Data.WorkPeriodsID == 0 {
+		return 0, fmt.Errorf("[FetchAPI] la API devolvió un ID de período 0")
+	}
+	return res.Data.WorkPeriodsID, nil
+}
+
+// ClosePeriod registra la hora de fin de un período (PATCH /workPeriods/?endHour=&id=).
+func (c *Client) ClosePeriod(ctx context.Context, periodID int64, endHour string) error {
+	q := url.Values{}
+	q.Set("endHour", endHour)
+	q.Set("id", strconv.FormatInt(periodID, 10))
+	return c.do(ctx, http.MethodPatch, workPeriodsPath+"/", q, nil, nil)
+}
+
+// CreateReading crea la lectura de un período (POST /workPeriods/readings).
+func (c *Client) CreateReading(ctx context.Context, reading Reading) error {
+	return c.do(ctx, http.MethodPost, workPeriodsPath+"/readings", nil, reading, nil)
+}
+
+// UpdateReading actualiza la última lectura de un período (PUT /workPeriods/).
+func (c *Client) UpdateReading(ctx context.Context, reading Reading) error {
+	return c.do(ctx, http.MethodPut, workPeriodsPath+"/", nil, reading, nil)
+}
+
+// GetReadingsGlobal obtiene la distancia y el peso acumulados de un período
+// (GET /workPeriods/readingsGlobal?id=).
+func (c *Client) GetReadingsGlobal(ctx context.Context, periodID int64) (Reading, error) {
+	q := url.Values{}
+	q.Set("id", strconv.FormatInt(periodID, 10))
+	var res ReadingsGlobalResponse
+	err := c.do(ctx, http.MethodGet, workPeriodsPath+"/readingsGlobal", q, nil, &res)
+	return res.LastReading, err
+}
+
+// RegisterWeight registra una lectura de peso (POST /sensors/weight).
+func (c *Client) RegisterWeight(ctx context.Context, record WeightRecord) error {
+	return c.do(ctx, http.MethodPost, sensorsPath+"/weight", nil, record, nil)
+}
+
+// RegisterGPS registra una lectura de GPS (POST /sensors/gps).
+func (c *Client) RegisterGPS(ctx context.Context, record GPSRecord) error {
+	return c.do(ctx, http.MethodPost, sensorsPath+"/gps", nil, record, nil)
+}
+
+// RegisterWeightBatch registra varias lecturas de peso en una sola petición
+// comprimida con gzip (POST /sensors/weight/bulk). Si el servidor no tiene la
+// ruta, IsBulkUnsupported(err) es true.
+func (c *Client) RegisterWeightBatch(ctx context.Context, records []WeightRecord) error {
+	return c.doWith(ctx, http.MethodPost, sensorsPath+"/weight/bulk", nil, records, nil, true)
+}
+
+// RegisterGPSBatch registra varias lecturas de GPS en una sola petición
+// comprimida con gzip (POST /sensors/gps/bulk).
+func (c *Client) RegisterGPSBatch(ctx context.Context, records []GPSRecord) error {
+	return c.doWith(ctx, http.MethodPost, sensorsPath+"/gps/bulk", nil, records, nil, true)
+}
+
+// CreateWasteCollection crea el contador de un tipo de residuo (POST /sensors/waste)
+// y devuelve su ID.
+func (c *Client) CreateWasteCollection(ctx context.Context, collection WasteCollection) (int64, error) {
+	var res CreateWasteCollectionResponse
+	if err := c.do(ctx, http.MethodPost, sensorsPath+"/waste", nil, collection, &res); err != nil {
+		return 0, err
+	}
+	return res.Data.WasteCollectionID, nil
+}
+
+// IncrementWasteCollection suma uno al contador de una recolección
+// (PATCH /sensors/?Id=).
+func (c *Client) IncrementWasteCollection(ctx context.Context, wasteCollectionID int64) error {
+	q := url.Values{}
+	q.Set("Id", strconv.FormatInt(wasteCollectionID, 10))
+	return c.do(ctx, http.MethodPatch, sensorsPath+"/", q, nil, nil)
+}
+
+// Backup pide al backend que haga su backup (GET /backup/) y devuelve la
+// respuesta tal cual.
+func (c *Client) Backup(ctx context.Context) (map[string]interface{}, error) {
+	var res map[string]interface{}
+	err := c.do(ctx, http.MethodGet, "/backup/", nil, nil, &res)
+	return res, err
+}
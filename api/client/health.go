@@ -0,0 +1,39 @@
+package client
+
+import (
+	"sync"
+	"time"
+)
+
+// Health es lo último que se supo de la conexión con el backend, según la
+// última petición terminada.
+type Health struct {
+	Checked   time.Time // cero si todavía no hubo ninguna petición
+	Reachable bool      // el servidor respondió, aunque fuera con un error HTTP
+	Status    int       // status HTTP de la última respuesta
+	Err       string    // error de la última petición, si hubo
+	Latency   time.Duration
+}
+
+// healthTracker guarda la Health de un Client.
+type healthTracker struct {
+	mu   sync.Mutex
+	last Health
+}
+
+func (t *healthTracker) record(start time.Time, status int, err error) {
+	h := Health{Checked: time.Now(), Reachable: status != 0, Status: status, Latency: time.Since(start)}
+	if err != nil {
+		h.Err = err.Error()
+	}
+	t.mu.Lock()
+	t.last = h
+	t.mu.Unlock()
+}
+
+// Health devuelve el estado de la conexión según la última petición.
+func (c *Client) Health() Health {
+	c.health.mu.Lock()
+	defer c.health.mu.Unlock()
+	return c.health.last
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	mathrand "math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy define los reintentos: backoff exponencial con jitter completo,
+// es decir, una espera aleatoria entre 0 y min(MaxDelay, BaseDelay*2^(intento-1)).
+type RetryPolicy struct {
+	MaxAttempts int // intentos totales, incluido el primero
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy son 4 intentos con esperas de hasta 250ms, 500ms y 1s.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   250 * time.Millisecond,
+	MaxDelay:    5 * time.Second,
+}
+
+// NoRetry hace un solo intento.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Backoff devuelve la espera antes del reintento que sigue al intento attempt (1, 2, ...).
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || (p.MaxDelay > 0 && ceiling > p.MaxDelay) {
+		ceiling = p.MaxDelay
+	}
+	return time.Duration(mathrand.Int63n(int64(ceiling) + 1))
+}
+
+// Wait es la espera antes del reintento que sigue al intento attempt que
+// falló con err: el backoff, o lo que pidió el servidor con Retry-After si
+// es más.
+func (p RetryPolicy) Wait(attempt int, err error) time.Duration {
+	wait := p.Backoff(attempt)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.RetryAfter > wait {
+		wait = apiErr.RetryAfter
+	}
+	return wait
+}
+
+func isIdempotentMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	return false
+}
+
+// IdempotencyHeader es la cabecera con la que se envía la clave de idempotencia.
+// Un servidor que la respeta responde lo mismo a dos peticiones con la misma
+// clave sin aplicar la operación dos veces, y la devuelve en la respuesta
+// para que el cliente lo sepa (ver Client.ReplaysIdempotencyKeys).
+const IdempotencyHeader = "Idempotency-Key"
+
+type idempotencyKeyCtx struct{}
+
+// WithIdempotencyKey asocia una clave de idempotencia a las peticiones hechas con ctx.
+// Con clave, también los POST y PATCH se reintentan, salvo tras un fallo
+// ambiguo (ver IsAmbiguous) contra un servidor que no confirmó que respeta
+// las claves.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtx{}, key)
+}
+
+type singleAttemptCtx struct{}
+
+// SingleAttempt hace que las peticiones con ctx se intenten una sola vez,
+// para quien lleva sus propios reintentos (por ejemplo, para no esperar el
+// backoff con un lock tomado).
+func SingleAttempt(ctx context.Context) context.Context {
+	return context.WithValue(ctx, singleAttemptCtx{}, true)
+}
+
+func isSingleAttempt(ctx context.Context) bool {
+	single, _ := ctx.Value(singleAttemptCtx{}).(bool)
+	return single
+}
+
+// IdempotencyKey devuelve la clave asociada a ctx, o "".
+func IdempotencyKey(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyCtx{}).(string)
+	return key
+}
+
+// NewIdempotencyKey genera una clave aleatoria de 128 bits.
+func NewIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
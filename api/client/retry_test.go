@@ -0,0 +1,171 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/client"
+)
+
+func TestBackoffStaysUnderCeiling(t *testing.T) {
+	p := client.RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	ceilings := []time.Duration{100, 200, 300, 300, 300}
+	for i, ceiling := range ceilings {
+		ceiling *= time.Millisecond
+		for range 200 {
+			if d := p.Backoff(i + 1); d < 0 || d > ceiling {
+				t.Fatalf("Backoff(%d) = %s, fuera de [0, %s]", i+1, d, ceiling)
+			}
+		}
+	}
+	if d := client.NoRetry.Backoff(1); d != 0 {
+		t.Errorf("sin BaseDelay Backoff = %s", d)
+	}
+}
+
+func TestWaitHonoursRetryAfter(t *testing.T) {
+	p := client.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	busy := fmt.Errorf("envolviendo: %w", &client.APIError{StatusCode: http.StatusServiceUnavailable, RetryAfter: 2 * time.Second})
+	if d := p.Wait(1, busy); d != 2*time.Second {
+		t.Errorf("Wait con Retry-After 2s = %s", d)
+	}
+	if d := p.Wait(1, errors.New("otro error")); d > time.Millisecond {
+		t.Errorf("Wait sin Retry-After = %s, se esperaba el backoff", d)
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	status := func(code int) error { return &client.APIError{StatusCode: code} }
+	dial := &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}
+	cut := &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "read", Err: errors.New("connection reset")}}
+	timeout := &url.Error{Op: "Post", URL: "http://x", Err: context.DeadlineExceeded}
+
+	cases := []struct {
+		name                 string
+		err                  error
+		temporary, ambiguous bool
+	}{
+		{"400", status(400), false, false},
+		{"404", status(404), false, false},
+		{"408", status(408), true, false},
+		{"429", status(429), true, false},
+		{"500", status(500), true, true},
+		{"502", status(502), true, true},
+		{"503", status(503), true, false},
+		{"504", status(504), true, true},
+		{"sin conexión", dial, true, false},
+		{"conexión cortada", cut, true, true},
+		{"tiempo vencido", timeout, true, true},
+		{"otro", errors.New("otro"), false, false},
+	}
+	for _, tc := range cases {
+		if got := client.IsTemporary(tc.err); got != tc.temporary {
+			t.Errorf("%s: IsTemporary = %v", tc.name, got)
+		}
+		if got := client.IsAmbiguous(tc.err); got != tc.ambiguous {
+			t.Errorf("%s: IsAmbiguous = %v", tc.name, got)
+		}
+	}
+}
+
+// flakyServer falla con status y Retry-After: 0 las peticiones que llegan
+// después de las primeras skip, hasta fails de ellas. Con echo devuelve la
+// Idempotency-Key, como un servidor que la respeta.
+func flakyServer(t *testing.T, skip, fails int32, status int, echo bool) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if echo {
+			w.Header().Set(client.IdempotencyHeader, r.Header.Get(client.IdempotencyHeader))
+		}
+		if n := calls.Add(1); n > skip && n <= skip+fails {
+			w.Header().Set("Retry-After", "0")
+			http.Error(w, `{"message":"falla"}`, status)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestRetries(t *testing.T) {
+	keyed := client.WithIdempotencyKey(context.Background(), "clave")
+	cases := []struct {
+		name   string
+		ctx    context.Context
+		status int
+		echo   bool
+		calls  int32
+		ok     bool
+	}{
+		// 503 dice que no se procesó: con clave se reintenta
+		{"503 con clave", keyed, http.StatusServiceUnavailable, false, 2, true},
+		{"503 sin clave", context.Background(), http.StatusServiceUnavailable, false, 1, false},
+		{"503 un solo intento", client.SingleAttempt(keyed), http.StatusServiceUnavailable, false, 1, false},
+		// Un 500 pudo haberse aplicado: solo se repite si el servidor respeta la clave
+		{"500 sin confirmar", keyed, http.StatusInternalServerError, false, 1, false},
+		{"500 confirmado", keyed, http.StatusInternalServerError, true, 2, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Una primera petición que sale bien, y la siguiente falla una vez
+			srv, calls := flakyServer(t, 1, 1, tc.status, tc.echo)
+			c := client.New(srv.URL, 5*time.Second, client.WithRetry(client.RetryPolicy{MaxAttempts: 3}))
+			if err := c.IncrementWasteCollection(client.WithIdempotencyKey(context.Background(), "antes"), 1); err != nil {
+				t.Fatal(err)
+			}
+			calls.Store(1)
+
+			err := c.IncrementWasteCollection(tc.ctx, 1)
+			if (err == nil) != tc.ok {
+				t.Errorf("error %v", err)
+			}
+			if n := calls.Load() - 1; n != tc.calls {
+				t.Errorf("%d intentos, se esperaban %d", n, tc.calls)
+			}
+		})
+	}
+}
+
+func TestReplaysIdempotencyKeys(t *testing.T) {
+	keyed := client.WithIdempotencyKey(context.Background(), "clave")
+	for _, echo := range []bool{false, true} {
+		srv, _ := flakyServer(t, 0, 0, 0, echo)
+		c := client.New(srv.URL, 5*time.Second)
+		if c.ReplaysIdempotencyKeys() {
+			t.Fatal("confirmado antes de la primera respuesta")
+		}
+		if err := c.IncrementWasteCollection(keyed, 1); err != nil {
+			t.Fatal(err)
+		}
+		if got := c.ReplaysIdempotencyKeys(); got != echo {
+			t.Errorf("con echo=%v ReplaysIdempotencyKeys = %v", echo, got)
+		}
+	}
+}
+
+func TestRetryAfterIsParsed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, `{"message":"ocupado"}`, http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	err := client.New(srv.URL, 5*time.Second, client.WithRetry(client.NoRetry)).IncrementWasteCollection(context.Background(), 1)
+	var apiErr *client.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error %v, se esperaba *APIError", err)
+	}
+	if apiErr.RetryAfter != 7*time.Second || apiErr.Message() != "ocupado" {
+		t.Errorf("APIError %+v", apiErr)
+	}
+}
@@ -0,0 +1,81 @@
+package client
+
+// WorkPeriod es un período de trabajo del prototipo.
+type WorkPeriod struct {
+	PeriodID    int64  `json:"period_id"`
+	StartHour   string `json:"start_hour"`
+	EndHour     string `json:"end_hour"`
+	DayWork     string `json:"day_work"`
+	PrototypeID string `json:"prototype_id"`
+}
+
+// LastPeriod es el último período conocido por el backend.
+// PeriodID es 0 cuando todavía no existe ninguno.
+type LastPeriod struct {
+	PeriodID int64  `json:"period_id"`
+	LastHour string `json:"last_hour"`
+}
+
+// LastPeriodResponse es la respuesta de GET /workPeriods/.
+type LastPeriodResponse struct {
+	LastPeriod LastPeriod `json:"last_period"`
+}
+
+// CreatePeriodResponse es la respuesta de POST /workPeriods/.
+type CreatePeriodResponse struct {
+	Data struct {
+		WorkPeriodsID int64 `json:"work_periods_id"`
+	} `json:"data"`
+}
+
+// Reading es la lectura acumulada (distancia y peso) de un período.
+type Reading struct {
+	PeriodID         int64   `json:"period_id"`
+	DistanceTraveled float64 `json:"distance_traveled"`
+	WeightWaste      float64 `json:"weight_waste"`
+}
+
+// ReadingsGlobalResponse es la respuesta de GET /workPeriods/readingsGlobal.
+type ReadingsGlobalResponse struct {
+	LastReading Reading `json:"last_reading"`
+}
+
+// WeightRecord es una lectura del sensor de peso.
+type WeightRecord struct {
+	WeightDataID int64   `json:"weight_data_id"`
+	PeriodID     int64   `json:"period_id"`
+	HourPeriod   string  `json:"Hour_period"`
+	Weight       float64 `json:"Weight"`
+}
+
+// GPSRecord es una lectura del GPS.
+type GPSRecord struct {
+	PeriodID  int64   `json:"period_id"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+	Altitude  float64 `json:"altitude"`
+	Speed     float64 `json:"speed"`
+	DateGPS   string  `json:"date_gps"`
+	HourUTC   string  `json:"hour_UTC"`
+}
+
+// Tipos de residuo conocidos por el backend.
+const (
+	WastePET  int64 = 1
+	WasteCans int64 = 2
+)
+
+// WasteCollection es el contador de recolección de un tipo de residuo en un período.
+type WasteCollection struct {
+	WasteCollectionID int64 `json:"waste_collection_id"`
+	PeriodID          int64 `json:"period_id"`
+	Amount            int   `json:"amount"`
+	WasteID           int64 `json:"waste_id"`
+}
+
+// CreateWasteCollectionResponse es la respuesta de POST /sensors/waste.
+type CreateWasteCollectionResponse struct {
+	Data struct {
+		WasteCollectionID int64 `json:"waste_collection_id"`
+	} `json:"data"`
+}
@@ -0,0 +1,296 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry es una operación de la API pendiente de enviar.
+type Entry struct {
+	Seq     uint64          `json:"seq"`
+	Op      string          `json:"op"`
+	LocalID int64           `json:"local_id,omitempty"` // ID provisional si la operación crea un recurso
+	Key     string          `json:"key,omitempty"`      // clave de idempotencia, la misma en cada reenvío
+	Payload json.RawMessage `json:"payload"`
+	At      time.Time       `json:"at"`
+}
+
+// line es un renglón del archivo: una operación nueva, la confirmación de
+// una, o la foto que deja la compactación.
+type line struct {
+	Type     string `json:"type"` // "op", "ack" o "snapshot"
+	Entry           // solo en "op"
+	ServerID int64  `json:"server_id,omitempty"`
+
+	// Solo en "snapshot": lo que sobrevive a vaciar el archivo
+	NextLocalID int64           `json:"next_local_id,omitempty"`
+	IDs         map[int64]int64 `json:"ids,omitempty"`
+}
+
+// Journal es un diario persistente (JSON por renglón) de operaciones de la API
+// que no se pudieron enviar. Las entradas se reenvían en orden y los IDs
+// creados sin conexión (negativos) se traducen a los IDs reales del servidor.
+type Journal struct {
+	mu          sync.Mutex
+	path        string
+	file        *os.File
+	pending     []Entry
+	nextSeq     uint64
+	nextLocalID int64
+	ids         map[int64]int64 // ID local -> ID del servidor
+}
+
+// Open carga el diario de path (creándolo si no existe).
+func Open(path string) (*Journal, error) {
+	j := &Journal{
+		path:        path,
+		nextSeq:     1,
+		nextLocalID: -1,
+		ids:         map[int64]int64{},
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("error creando directorio del diario: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("error abriendo diario %s: %w", path, err)
+	}
+	j.file = f
+	return j, nil
+}
+
+func (j *Journal) load() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("error leyendo diario %s: %w", j.path, err)
+	}
+	defer f.Close()
+
+	bySeq := map[uint64]int{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for scanner.Scan() {
+		var l line
+		if err := json.Unmarshal(scanner.Bytes(), &l); err != nil {
+			// Un renglón a medias (corte de luz al escribir) se ignora
+			continue
+		}
+		switch l.Type {
+		case "op":
+			bySeq[l.Seq] = len(j.pending)
+			j.pending = append(j.pending, l.Entry)
+			if l.Seq >= j.nextSeq {
+				j.nextSeq = l.Seq + 1
+			}
+			if l.LocalID != 0 && l.LocalID <= j.nextLocalID {
+				j.nextLocalID = l.LocalID - 1
+			}
+		case "ack":
+			idx, ok := bySeq[l.Seq]
+			if !ok {
+				continue
+			}
+			if e := j.pending[idx]; e.LocalID != 0 && l.ServerID != 0 {
+				j.ids[e.LocalID] = l.ServerID
+			}
+			j.pending[idx].Seq = 0 // marcada como confirmada
+		case "snapshot":
+			for local, server := range l.IDs {
+				j.ids[local] = server
+			}
+			if l.NextLocalID < j.nextLocalID {
+				j.nextLocalID = l.NextLocalID
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error leyendo diario %s: %w", j.path, err)
+	}
+
+	remaining := j.pending[:0]
+	for _, e := range j.pending {
+		if e.Seq != 0 {
+			remaining = append(remaining, e)
+		}
+	}
+	j.pending = remaining
+	return nil
+}
+
+func (j *Journal) write(l line) error {
+	raw, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	raw = append(raw, '\n')
+	if _, err := j.file.Write(raw); err != nil {
+		return fmt.Errorf("error escribiendo diario: %w", err)
+	}
+	return j.file.Sync()
+}
+
+// NewLocalID reserva un ID provisional (negativo) para un recurso creado sin conexión.
+func (j *Journal) NewLocalID() int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	id := j.nextLocalID
+	j.nextLocalID--
+	return id
+}
+
+// Append guarda una operación al final del diario.
+func (j *Journal) Append(op string, localID int64, key string, payload interface{}) (Entry, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Entry{}, fmt.Errorf("error codificando operación %s: %w", op, err)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	e := Entry{Seq: j.nextSeq, Op: op, LocalID: localID, Key: key, Payload: raw, At: time.Now().UTC()}
+	if err := j.write(line{Type: "op", Entry: e}); err != nil {
+		return Entry{}, err
+	}
+	j.nextSeq++
+	j.pending = append(j.pending, e)
+	return e, nil
+}
+
+// Pending devuelve una copia de las operaciones sin confirmar, en orden.
+func (j *Journal) Pending() []Entry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Entry(nil), j.pending...)
+}
+
+// HasKey indica si hay una operación pendiente con esa clave de idempotencia.
+func (j *Journal) HasKey(key string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range j.pending {
+		if e.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Len devuelve cuántas operaciones quedan pendientes.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.pending)
+}
+
+// Ack confirma una operación. Si creó un recurso, serverID es su ID real y
+// desde ese momento Resolve traduce el ID local a serverID, también en las
+// próximas sesiones. Cuando ya no queda nada pendiente el archivo se compacta.
+func (j *Journal) Ack(seq uint64, serverID int64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	idx := -1
+	for i, e := range j.pending {
+		if e.Seq == seq {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		return fmt.Errorf("operación %d no está pendiente", seq)
+	}
+	if err := j.write(line{Type: "ack", Entry: Entry{Seq: seq}, ServerID: serverID}); err != nil {
+		return err
+	}
+	if local := j.pending[idx].LocalID; local != 0 && serverID != 0 {
+		j.ids[local] = serverID
+	}
+	j.pending = append(j.pending[:idx], j.pending[idx+1:]...)
+
+	if len(j.pending) == 0 {
+		return j.compact()
+	}
+	return nil
+}
+
+// compact vacía el archivo; se llama con mu tomado y sin pendientes. Deja
+// un único renglón "snapshot" con las traducciones de IDs locales y el
+// próximo ID local: un ID ya repartido no se vuelve a usar, y un recurso
+// creado sin conexión se sigue pudiendo traducir si el programa se corta
+// antes de guardar su ID del servidor en otro lado. El archivo nuevo se
+// escribe aparte y se renombra, así un corte no lo deja a medias.
+func (j *Journal) compact() error {
+	raw, err := json.Marshal(line{Type: "snapshot", NextLocalID: j.nextLocalID, IDs: j.ids})
+	if err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := writeSynced(tmp, append(raw, '\n')); err != nil {
+		return fmt.Errorf("error compactando diario: %w", err)
+	}
+
+	// En Windows no se puede reemplazar un archivo abierto
+	j.file.Close()
+	renameErr := os.Rename(tmp, j.path)
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("error reabriendo diario %s: %w", j.path, err)
+	}
+	j.file = f
+	if renameErr != nil {
+		return fmt.Errorf("error compactando diario: %w", renameErr)
+	}
+	return nil
+}
+
+// writeSynced escribe data en path y espera a que llegue al disco.
+func writeSynced(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Resolve traduce un ID local a su ID del servidor. Los IDs que no son
+// locales, o que todavía no tienen traducción, se devuelven tal cual.
+func (j *Journal) Resolve(id int64) int64 {
+	if id >= 0 {
+		return id
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if server, ok := j.ids[id]; ok {
+		return server
+	}
+	return id
+}
+
+// Close cierra el archivo del diario.
+func (j *Journal) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
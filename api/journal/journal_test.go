@@ -0,0 +1,141 @@
+package journal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTest(t *testing.T, path string) *Journal {
+	t.Helper()
+	j, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	return j
+}
+
+func seqs(entries []Entry) []uint64 {
+	out := make([]uint64, len(entries))
+	for i, e := range entries {
+		out[i] = e.Seq
+	}
+	return out
+}
+
+func TestReopenKeepsPendingInOrder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTest(t, path)
+	for _, op := range []string{"a", "b", "c"} {
+		if _, err := j.Append(op, 0, "clave-"+op, map[string]string{"op": op}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := j.Ack(2, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Ack(2, 0); err == nil {
+		t.Error("Ack de una operación ya confirmada no falló")
+	}
+	j.Close()
+
+	j = openTest(t, path)
+	pending := j.Pending()
+	if got := seqs(pending); len(got) != 2 || got[0] != 1 || got[1] != 3 {
+		t.Fatalf("pendientes %v, se esperaban [1 3]", got)
+	}
+	if pending[1].Op != "c" || pending[1].Key != "clave-c" || string(pending[1].Payload) != `{"op":"c"}` {
+		t.Errorf("entrada recargada %+v", pending[1])
+	}
+	if !j.HasKey("clave-a") || j.HasKey("clave-b") {
+		t.Error("HasKey no coincide con las pendientes")
+	}
+	// La secuencia sigue después de la última del archivo
+	e, err := j.Append("d", 0, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Seq != 4 {
+		t.Errorf("nueva entrada con seq %d, se esperaba 4", e.Seq)
+	}
+}
+
+func TestLoadSkipsTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTest(t, path)
+	if _, err := j.Append("a", 0, "", 1); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	// Un corte a mitad de escritura deja un renglón incompleto
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"type":"op","seq":2,"op":"b","payl`)
+	f.Close()
+
+	if j = openTest(t, path); j.Len() != 1 {
+		t.Errorf("quedaron %d pendientes, se esperaba 1", j.Len())
+	}
+}
+
+func TestCompactionKeepsLocalIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.jsonl")
+	j := openTest(t, path)
+
+	local := j.NewLocalID()
+	if local != -1 {
+		t.Fatalf("primer ID local %d", local)
+	}
+	e, err := j.Append("create", local, "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Append("use", 0, "", map[string]int64{"id": local}); err != nil {
+		t.Fatal(err)
+	}
+	if got := j.Resolve(local); got != local {
+		t.Errorf("Resolve antes de confirmar = %d", got)
+	}
+	if err := j.Ack(e.Seq, 42); err != nil {
+		t.Fatal(err)
+	}
+	if got := j.Resolve(local); got != 42 {
+		t.Errorf("Resolve después de confirmar = %d, se esperaba 42", got)
+	}
+
+	// La última confirmación compacta el archivo
+	if err := j.Ack(e.Seq+1, 0); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(raw); n == 0 || n > 200 {
+		t.Errorf("el archivo compactado tiene %d bytes: %s", n, raw)
+	}
+
+	// Después de compactar se sigue escribiendo en el archivo nuevo
+	if _, err := j.Append("later", 0, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	j = openTest(t, path)
+	if got := j.Resolve(local); got != 42 {
+		t.Errorf("Resolve después de reabrir = %d, se esperaba 42", got)
+	}
+	if next := j.NewLocalID(); next != -2 {
+		t.Errorf("después de reabrir el próximo ID local es %d, se esperaba -2", next)
+	}
+	if pending := j.Pending(); len(pending) != 1 || pending[0].Op != "later" {
+		t.Errorf("pendientes después de reabrir: %+v", pending)
+	}
+	if got := j.Resolve(7); got != 7 {
+		t.Errorf("Resolve de un ID del servidor = %d", got)
+	}
+}
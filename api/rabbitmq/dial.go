@@ -0,0 +1,26 @@
+package rabbitmq
+
+import (
+	"fmt"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"pybot-simulator/config"
+)
+
+// dial abre la conexión al broker de la configuración actual. Con amqps://
+// usa el TLS de la configuración (CA propia, certificado de cliente, pines).
+func dial() (*amqp.Connection, error) {
+	settings := config.Current()
+	if !strings.HasPrefix(settings.RabbitMQURL, "amqps://") {
+		return amqp.Dial(settings.RabbitMQURL)
+	}
+	tlsConfig, err := settings.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error configurando TLS del broker: %w", err)
+	}
+	if tlsConfig == nil {
+		return amqp.Dial(settings.RabbitMQURL)
+	}
+	return amqp.DialTLS(settings.RabbitMQURL, tlsConfig)
+}
@@ -0,0 +1,151 @@
+package rabbitmq
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"pybot-simulator/config"
+)
+
+// tlsBroker es un broker falso: acepta conexiones TLS, anota si el
+// handshake salió bien y corta antes del protocolo AMQP.
+type tlsBroker struct {
+	addr       string
+	cert       *x509.Certificate
+	caFile     string
+	handshakes chan error
+}
+
+func newTLSBroker(t *testing.T) *tlsBroker {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "broker de prueba"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "broker-ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	b := &tlsBroker{addr: ln.Addr().String(), cert: cert, caFile: caFile, handshakes: make(chan error, 8)}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.SetDeadline(time.Now().Add(5 * time.Second))
+			b.handshakes <- conn.(*tls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+	return b
+}
+
+// dialWith carga la configuración con args y llama a dial.
+func dialWith(t *testing.T, args ...string) error {
+	t.Helper()
+	t.Chdir(t.TempDir()) // sin pybot.json ni .env del repo
+	if _, err := config.Load(args); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := dial()
+	if err == nil {
+		conn.Close()
+	}
+	return err
+}
+
+// handshake es el resultado del handshake del lado del broker.
+func (b *tlsBroker) handshake(t *testing.T) error {
+	t.Helper()
+	select {
+	case err := <-b.handshakes:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("el broker no recibió la conexión")
+		return nil
+	}
+}
+
+func TestDialTLS(t *testing.T) {
+	b := newTLSBroker(t)
+	url := "amqps://guest:guest@" + b.addr + "/"
+	other := config.SPKIPin(&x509.Certificate{RawSubjectPublicKeyInfo: []byte("otra clave")})
+
+	t.Run("CA propia", func(t *testing.T) {
+		err := dialWith(t, "-rabbitmq-url", url, "-tls-ca-file", b.caFile)
+		if err := b.handshake(t); err != nil {
+			t.Errorf("handshake con la CA propia: %v", err)
+		}
+		// El broker falso corta antes del protocolo AMQP
+		if err == nil {
+			t.Error("dial no falló contra un broker sin AMQP")
+		}
+	})
+
+	t.Run("pin correcto", func(t *testing.T) {
+		dialWith(t, "-rabbitmq-url", url, "-tls-ca-file", b.caFile, "-tls-pins", config.SPKIPin(b.cert))
+		if err := b.handshake(t); err != nil {
+			t.Errorf("handshake con el pin correcto: %v", err)
+		}
+	})
+
+	t.Run("pin incorrecto", func(t *testing.T) {
+		err := dialWith(t, "-rabbitmq-url", url, "-tls-ca-file", b.caFile, "-tls-pins", other)
+		if err == nil || !strings.Contains(err.Error(), "tls_pins") {
+			t.Errorf("error %v, se esperaba el rechazo por tls_pins", err)
+		}
+		if err := b.handshake(t); err == nil {
+			t.Error("el handshake salió bien con un pin incorrecto")
+		}
+	})
+
+	t.Run("sin CA", func(t *testing.T) {
+		err := dialWith(t, "-rabbitmq-url", url)
+		var unknown x509.UnknownAuthorityError
+		if !errors.As(err, &unknown) {
+			t.Errorf("error %v, se esperaba un certificado desconocido", err)
+		}
+		b.handshake(t)
+	})
+}
@@ -0,0 +1,234 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Tipos de comandos remotos aceptados por el simulador.
+const (
+	CommandSpawn        = "spawn"
+	CommandRecharge     = "recharge"
+	CommandGoTo         = "goto"
+	CommandPause        = "pause"
+	CommandResume       = "resume"
+	CommandStrategy     = "strategy"
+	CommandRotatePeriod = "rotate_period"
+)
+
+// Command es el mensaje JSON que llega por la cola de comandos.
+type Command struct {
+	ID       string  `json:"id"`
+	Type     string  `json:"type"`
+	Count    int     `json:"count,omitempty"`
+	X        float64 `json:"x,omitempty"`
+	Y        float64 `json:"y,omitempty"`
+	Strategy string  `json:"strategy,omitempty"`
+}
+
+// CommandReply es la respuesta que se publica al aplicar (o rechazar) un comando.
+type CommandReply struct {
+	CommandID   string `json:"command_id"`
+	Type        string `json:"type"`
+	PrototypeID string `json:"prototype_id"`
+	Status      string `json:"status"` // "ok" o "error"
+	Error       string `json:"error,omitempty"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// IncomingCommand es un comando recibido pendiente de respuesta.
+// Quien lo aplica debe llamar a Respond exactamente una vez.
+type IncomingCommand struct {
+	Command
+	delivery amqp.Delivery
+	consumer *RabbitMQConsumer
+}
+
+// CommandRoutingKey devuelve la routing key de comandos de un prototipo.
+func CommandRoutingKey(prototypeID string) string {
+	return "commands." + prototypeID
+}
+
+// ReplyRoutingKey devuelve la routing key por defecto para las respuestas.
+func ReplyRoutingKey(prototypeID string) string {
+	return "commands." + prototypeID + ".reply"
+}
+
+// RabbitMQConsumer recibe comandos en una cola ligada a la routing key del prototipo.
+type RabbitMQConsumer struct {
+	connection  *amqp.Connection
+	channel     *amqp.Channel
+	prototypeID string
+	commands    chan *IncomingCommand
+}
+
+// NewRabbitMQConsumer se conecta, declara la cola del prototipo y empieza a consumir.
+// Igual que el publisher, si no hay broker devuelve una instancia sin conexión
+// cuyo canal de comandos nunca entrega nada.
+func NewRabbitMQConsumer(prototypeID string) (*RabbitMQConsumer, error) {
+	c := &RabbitMQConsumer{
+		prototypeID: prototypeID,
+		commands:    make(chan *IncomingCommand, 32),
+	}
+
+	conn, err := dial()
+	if err != nil {
+		log.Printf("[RabbitMQ] Advertencia: No se pudo conectar a RabbitMQ. Los comandos remotos estarán deshabilitados. Error: %v", err)
+		return c, nil
+	}
+
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("error al crear canal: %w", err)
+	}
+
+	if err := ch.ExchangeDeclare(exchangeName, exchangeType, true, false, false, false, nil); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("error al declarar exchange: %w", err)
+	}
+
+	queueName := "pybot.commands." + prototypeID
+	q, err := ch.QueueDeclare(
+		queueName,
+		true,  // durable: los comandos esperan si el simulador está apagado
+		false, // auto-delete
+		false, // exclusive
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("error al declarar cola %s: %w", queueName, err)
+	}
+
+	routingKey := CommandRoutingKey(prototypeID)
+	if err := ch.QueueBind(q.Name, routingKey, exchangeName, false, nil); err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("error al ligar cola a %s: %w", routingKey, err)
+	}
+
+	deliveries, err := ch.Consume(
+		q.Name,
+		"",    // consumer tag
+		false, // auto-ack: se confirma al aplicar el comando
+		false, // exclusive
+		false, // no-local
+		false, // no-wait
+		nil,
+	)
+	if err != nil {
+		ch.Close()
+		conn.Close()
+		return nil, fmt.Errorf("error al consumir cola %s: %w", q.Name, err)
+	}
+
+	c.connection = conn
+	c.channel = ch
+	go c.loop(deliveries)
+
+	fmt.Printf("[RabbitMQ] Escuchando comandos en %s\n", routingKey)
+	return c, nil
+}
+
+// Commands devuelve el canal por el que llegan los comandos decodificados.
+func (c *RabbitMQConsumer) Commands() <-chan *IncomingCommand {
+	return c.commands
+}
+
+func (c *RabbitMQConsumer) loop(deliveries <-chan amqp.Delivery) {
+	for d := range deliveries {
+		var cmd Command
+		if err := json.Unmarshal(d.Body, &cmd); err != nil || cmd.Type == "" {
+			if err == nil {
+				err = fmt.Errorf("comando sin 'type'")
+			}
+			log.Printf("[RabbitMQ] Comando inválido descartado: %v", err)
+			in := &IncomingCommand{Command: cmd, delivery: d, consumer: c}
+			in.Respond(fmt.Errorf("comando inválido: %w", err))
+			continue
+		}
+		c.commands <- &IncomingCommand{Command: cmd, delivery: d, consumer: c}
+	}
+}
+
+// Respond publica la respuesta del comando y confirma el mensaje al broker.
+// Si err es nil la respuesta es "ok".
+func (in *IncomingCommand) Respond(err error) {
+	reply := CommandReply{
+		CommandID:   in.ID,
+		Type:        in.Type,
+		PrototypeID: in.consumer.prototypeID,
+		Status:      "ok",
+		Timestamp:   time.Now().UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		reply.Status = "error"
+		reply.Error = err.Error()
+	}
+
+	if pubErr := in.consumer.publishReply(in.delivery, reply); pubErr != nil {
+		log.Printf("[RabbitMQ] Error publicando respuesta del comando %s: %v", in.ID, pubErr)
+	}
+	if in.delivery.Acknowledger != nil {
+		if ackErr := in.delivery.Ack(false); ackErr != nil {
+			log.Printf("[RabbitMQ] Error confirmando comando %s: %v", in.ID, ackErr)
+		}
+	}
+}
+
+// publishReply responde al ReplyTo del mensaje si viene, o a la routing key por defecto.
+func (c *RabbitMQConsumer) publishReply(d amqp.Delivery, reply CommandReply) error {
+	if c.channel == nil {
+		return nil
+	}
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return fmt.Errorf("error codificando JSON: %w", err)
+	}
+
+	exchange, routingKey := exchangeName, ReplyRoutingKey(c.prototypeID)
+	if d.ReplyTo != "" {
+		// ReplyTo apunta a una cola, se publica por el exchange por defecto
+		exchange, routingKey = "", d.ReplyTo
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err = c.channel.PublishWithContext(ctx, exchange, routingKey, false, false, amqp.Publishing{
+		ContentType:   "application/json",
+		CorrelationId: d.CorrelationId,
+		Body:          body,
+	})
+	countPublish(routingKey, func(s *PublishStats) {
+		if err != nil {
+			s.Failed++
+		} else {
+			s.Sent++
+		}
+	})
+	return err
+}
+
+// Connected indica si el consumidor de comandos tiene la conexión abierta.
+func (c *RabbitMQConsumer) Connected() bool {
+	return c.connection != nil && !c.connection.IsClosed()
+}
+
+// Close cierra el canal y la conexión.
+func (c *RabbitMQConsumer) Close() {
+	if c.channel != nil {
+		c.channel.Close()
+	}
+	if c.connection != nil && !c.connection.IsClosed() {
+		c.connection.Close()
+	}
+}
@@ -0,0 +1,77 @@
+package rabbitmq
+
+import "sync"
+
+// Routing keys de los sensores.
+const (
+	RoutingKeyCamera = "cam"
+	RoutingKeyGPS    = "neo"
+	RoutingKeyWeight = "hx"
+)
+
+// PublishStats son los contadores de publicación de una routing key, sumando
+// todos los publishers del proceso.
+type PublishStats struct {
+	Sent     uint64 // publicados sin error
+	Failed   uint64 // el broker o la red devolvieron error
+	Skipped  uint64 // descartados porque no había conexión
+	InFlight int    // publicándose en este momento
+}
+
+// ConnectionState resume las conexiones al broker del proceso.
+type ConnectionState struct {
+	Publishers int // publishers creados
+	Connected  int // publishers con la conexión abierta
+}
+
+var stats = struct {
+	mu         sync.Mutex
+	byKey      map[string]*PublishStats
+	publishers []*RabbitMQPublisher
+}{byKey: make(map[string]*PublishStats)}
+
+func keyStats(routingKey string) *PublishStats {
+	s, ok := stats.byKey[routingKey]
+	if !ok {
+		s = &PublishStats{}
+		stats.byKey[routingKey] = s
+	}
+	return s
+}
+
+// countPublish aplica update a los contadores de routingKey.
+func countPublish(routingKey string, update func(s *PublishStats)) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	update(keyStats(routingKey))
+}
+
+func registerPublisher(p *RabbitMQPublisher) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.publishers = append(stats.publishers, p)
+}
+
+// Stats devuelve los contadores de publicación por routing key.
+func Stats() map[string]PublishStats {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	out := make(map[string]PublishStats, len(stats.byKey))
+	for k, s := range stats.byKey {
+		out[k] = *s
+	}
+	return out
+}
+
+// Connection devuelve cuántos publishers tienen la conexión abierta.
+func Connection() ConnectionState {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	state := ConnectionState{Publishers: len(stats.publishers)}
+	for _, p := range stats.publishers {
+		if p.Connected() {
+			state.Connected++
+		}
+	}
+	return state
+}
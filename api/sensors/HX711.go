@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"log"
 	"math/rand"
-	"os"
 	"pybot-simulator/api/rabbitmq"
+	"pybot-simulator/config"
 	"pybot-simulator/api/services"
 	"time"
 )
@@ -64,7 +64,7 @@ func NewHX711Reader(serviceRegister *services.RegisterPeriods, h *WasteHandler)
 	hx := NewMockHX711(20, 21, offset)
 
 	return &HX711Reader{
-		prototypeID:     os.Getenv("ID_PROTOTYPE"),
+		prototypeID:     config.Current().PrototypeID,
 		mqtt:            mqtt,
 		hx:              hx,
 		serviceRegister: serviceRegister,
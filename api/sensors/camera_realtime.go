@@ -1,12 +1,14 @@
 package sensors
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"math/rand"
 	"path/filepath"
 	"pybot-simulator/api/rabbitmq"
+	"pybot-simulator/config"
 	"time"
 )
 
@@ -52,7 +54,9 @@ func NewRealTimeCamera() (*RealTimeCamera, error) {
 }
 
 // PublishRandomImage selects a random image, reads it, and publishes it to RabbitMQ.
-func (c *RealTimeCamera) PublishRandomImage() {
+// The detections are a fixed placeholder; they do not come from the items in
+// the simulated camera's field of view.
+func (c *RealTimeCamera) PublishRandomImage(ctx context.Context) {
 	if len(c.imagePaths) == 0 {
 		return // No images to send
 	}
@@ -68,15 +72,15 @@ func (c *RealTimeCamera) PublishRandomImage() {
 	}
 
 	// Publish to RabbitMQ
-	sent, err := c.publisher.Send(
+	sent, err := c.publisher.SendContext(ctx,
 		map[string]interface{}{
-                        "prototype_id": "a99fd25c7e4a4e2cb5b7a1d1",
+                        "prototype_id": config.Current().PrototypeID,
                         "detections": map[string]interface{}{
 								"cls": 1,
 								"conf": 0.4,
 							},
                         "image": imageData,
-                    }, "cam")
+                    }, rabbitmq.RoutingKeyCamera)
 	if err != nil {
 		log.Printf("Error sending image to RabbitMQ: %v", err)
 	} else if sent {
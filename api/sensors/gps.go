@@ -5,8 +5,8 @@ import (
 	"log"
 	"math"
 	"math/rand"
-	"os"
 	"pybot-simulator/api/rabbitmq"
+	"pybot-simulator/config"
 	"pybot-simulator/api/services"
 	"strconv"
 	"time"
@@ -80,7 +80,7 @@ func NewGPSReader(serviceRegister *services.RegisterPeriods) (*GPSReader, error)
 	return &GPSReader{
 		register:    serviceRegister,
 		mqtt:        mqtt,
-		prototypeID: os.Getenv("ID_PROTOTYPE"),
+		prototypeID: config.Current().PrototypeID,
 		// device se inicializará en Start()
 	}, nil
 }
@@ -1,11 +1,14 @@
 package sensors
 
 import (
+	"context"
 	"log"
 	"math/rand"
 	"pybot-simulator/api/rabbitmq"
 	"pybot-simulator/api/services"
+	"pybot-simulator/config"
 	"pybot-simulator/utils"
+	"sync"
 	"time"
 )
 
@@ -27,10 +30,20 @@ type GPSSensor struct {
 	registerPeriods *services.RegisterPeriods
 	originX         float64
 	originY         float64
+
+	mu      sync.Mutex
+	lastFix GPSFix
+}
+
+// GPSFix is the last position the sensor reported, back in world pixels.
+type GPSFix struct {
+	Position utils.Vector2D
+	At       time.Time // zero until the first reading
 }
 
-// NewGPSSensor creates a new GPS sensor.
-func NewGPSSensor(rp *services.RegisterPeriods, screenWidth, screenHeight int) (*GPSSensor, error) {
+// NewGPSSensor creates a new GPS sensor. The GPS origin is the center of the
+// world.
+func NewGPSSensor(rp *services.RegisterPeriods, worldWidth, worldHeight int) (*GPSSensor, error) {
 	publisher, err := rabbitmq.NewRabbitMQPublisher()
 	if err != nil {
 		// The publisher already logs a warning, but we can add context.
@@ -40,8 +53,8 @@ func NewGPSSensor(rp *services.RegisterPeriods, screenWidth, screenHeight int) (
 	return &GPSSensor{
 		publisher:       publisher,
 		registerPeriods: rp,
-		originX:         float64(screenWidth / 2),
-		originY:         float64(screenHeight / 2),
+		originX:         float64(worldWidth / 2),
+		originY:         float64(worldHeight / 2),
 	}, nil
 }
 
@@ -62,8 +75,12 @@ func (s *GPSSensor) GenerateGPSData(position, velocity utils.Vector2D) map[strin
 	// Get current time for the payload
 	now := time.Now().UTC()
 
+	s.mu.Lock()
+	s.lastFix = GPSFix{Position: s.ToWorld(lat, lon), At: now}
+	s.mu.Unlock()
+
 	data := map[string]interface{}{
-		"prototype_id":  "a99fd25c7e4a4e2cb5b7a1d1",
+		"prototype_id":  config.Current().PrototypeID,
 		"lat": lat,
 		"lon": lon,
 		"alt": alt,
@@ -74,8 +91,23 @@ func (s *GPSSensor) GenerateGPSData(position, velocity utils.Vector2D) map[strin
 	return data
 }
 
+// ToWorld maps GPS coordinates back to world pixels.
+func (s *GPSSensor) ToWorld(lat, lon float64) utils.Vector2D {
+	return utils.Vector2D{
+		X: s.originX + (lon-originLon)/coordScale,
+		Y: s.originY + (lat-originLat)/coordScale,
+	}
+}
+
+// LastFix returns the last position the sensor generated.
+func (s *GPSSensor) LastFix() GPSFix {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastFix
+}
+
 // SendGPSData sends the generated data to the API and RabbitMQ.
-func (s *GPSSensor) SendGPSData(data map[string]interface{}) {
+func (s *GPSSensor) SendGPSData(ctx context.Context, data map[string]interface{}) {
 	// Send to API
 	if err := s.registerPeriods.RegisterGPS(data); err != nil {
 		log.Printf("Warning: Failed to send GPS data to API: %v", err)
@@ -84,9 +116,16 @@ func (s *GPSSensor) SendGPSData(data map[string]interface{}) {
 	}
 
 	// Send to RabbitMQ
-	if sent, err := s.publisher.Send(data, "neo"); err != nil {
+	if sent, err := s.publisher.SendContext(ctx, data, rabbitmq.RoutingKeyGPS); err != nil {
 		log.Printf("Warning: Failed to send GPS data to RabbitMQ: %v", err)
 	} else if sent {
 		log.Println("Successfully sent GPS data to RabbitMQ.")
 	}
 }
+
+// Close closes the RabbitMQ connection.
+func (s *GPSSensor) Close() {
+	if s.publisher != nil {
+		s.publisher.Close()
+	}
+}
@@ -110,12 +110,12 @@ func (h *WasteHandler) ProcessWeight(weight float64) {
 			// Se concreta un residuo
 			if h.isPet {
 				log.Printf("[Handler] +1 PET (Δweight=%.2fg)\n", delta)
-				h.service.UpdateWasteCollection(h.idPET) // Ignoramos error (como en Python)
+				h.service.UpdateWasteCollection(h.idPET, "") // Ignoramos error (como en Python)
 				h.isPet = false
 			}
 			if h.isCan {
 				log.Printf("[Handler] +1 Can (Δweight=%.2fg)\n", delta)
-				h.service.UpdateWasteCollection(h.idCANS) // Ignoramos error
+				h.service.UpdateWasteCollection(h.idCANS, "") // Ignoramos error
 				h.isCan = false
 			}
 			// Tras concreción, borramos detect_time
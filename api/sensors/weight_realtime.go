@@ -1,9 +1,11 @@
 package sensors
 
 import (
+	"context"
 	"log"
 	"pybot-simulator/api/rabbitmq"
 	"pybot-simulator/api/services"
+	"pybot-simulator/config"
 )
 
 // WeightSensor handles sending weight and waste data.
@@ -26,52 +28,56 @@ func NewWeightSensor(rp *services.RegisterPeriods) (*WeightSensor, error) {
 }
 
 // RegisterWeight sends the total weight to the API and RabbitMQ.
-func (s *WeightSensor) RegisterWeight(totalWeight float64) {
+// It blocks on network I/O; callers run it on a background worker.
+func (s *WeightSensor) RegisterWeight(ctx context.Context, totalWeight float64) {
 	// Send total weight to /weight endpoint
-	go func() {
-		if err := s.registerPeriods.RegisterWeigh(totalWeight); err != nil {
-			log.Printf("Warning: Failed to send weight data to API: %v", err)
-		} else {
-			log.Printf("Successfully sent total weight %fg to API.", totalWeight)
-		}
-	}()
+	if err := s.registerPeriods.RegisterWeigh(totalWeight); err != nil {
+		log.Printf("Warning: Failed to send weight data to API: %v", err)
+	} else {
+		log.Printf("Successfully sent total weight %fg to API.", totalWeight)
+	}
 
 	// Send total weight to RabbitMQ
-	go func() {
-		payload := map[string]interface{}{
-			"prototype_id":  "a99fd25c7e4a4e2cb5b7a1d1",
-			"weight_g": totalWeight,
-		}
-		if sent, err := s.publisher.Send(payload, "hx"); err != nil {
-			log.Printf("Warning: Failed to send weight data to RabbitMQ: %v", err)
-		} else if sent {
-			log.Printf("Successfully sent total weight %fg to RabbitMQ.", totalWeight)
-		}
-	}()
+	payload := map[string]interface{}{
+		"prototype_id": config.Current().PrototypeID,
+		"weight_g":     totalWeight,
+	}
+	if sent, err := s.publisher.SendContext(ctx, payload, rabbitmq.RoutingKeyWeight); err != nil {
+		log.Printf("Warning: Failed to send weight data to RabbitMQ: %v", err)
+	} else if sent {
+		log.Printf("Successfully sent total weight %fg to RabbitMQ.", totalWeight)
+	}
 }
 
 // UpdateWasteCount sends a PATCH request to update the count for a given waste type.
-func (s *WeightSensor) UpdateWasteCount(wasteID int64) {
-	go func() {
-		var collectionID int64
-		if wasteID == 1 { // PET
-			collectionID = s.registerPeriods.GetIdWasteCollectionPET()
-		} else if wasteID == 2 { // CAN
-			collectionID = s.registerPeriods.GetIdWasteCollectionCANS()
-		} else {
-			log.Printf("Warning: Unknown wasteID %d for waste count update.", wasteID)
-			return
-		}
+// eventKey identifies the collection event so it is never counted twice.
+// Like RegisterWeight, it blocks and is meant to run on a background worker.
+func (s *WeightSensor) UpdateWasteCount(wasteID int64, eventKey string) {
+	var collectionID int64
+	if wasteID == 1 { // PET
+		collectionID = s.registerPeriods.GetIdWasteCollectionPET()
+	} else if wasteID == 2 { // CAN
+		collectionID = s.registerPeriods.GetIdWasteCollectionCANS()
+	} else {
+		log.Printf("Warning: Unknown wasteID %d for waste count update.", wasteID)
+		return
+	}
+
+	if collectionID == 0 {
+		log.Printf("Warning: No collection ID found for wasteID %d. Cannot update count.", wasteID)
+		return
+	}
 
-		if collectionID == 0 {
-			log.Printf("Warning: No collection ID found for wasteID %d. Cannot update count.", wasteID)
-			return
-		}
+	if err := s.registerPeriods.UpdateWasteCollection(collectionID, eventKey); err != nil {
+		log.Printf("Warning: Failed to update waste collection for wasteID %d (collectionID: %d): %v", wasteID, collectionID, err)
+	} else {
+		log.Printf("Successfully sent PATCH to update waste collection for wasteID %d.", wasteID)
+	}
+}
 
-		if err := s.registerPeriods.UpdateWasteCollection(collectionID); err != nil {
-			log.Printf("Warning: Failed to update waste collection for wasteID %d (collectionID: %d): %v", wasteID, collectionID, err)
-		} else {
-			log.Printf("Successfully sent PATCH to update waste collection for wasteID %d.", wasteID)
-		}
-	}()
-}
\ No newline at end of file
+// Close closes the RabbitMQ connection.
+func (s *WeightSensor) Close() {
+	if s.publisher != nil {
+		s.publisher.Close()
+	}
+}
@@ -0,0 +1,27 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"pybot-simulator/api/client"
+	"pybot-simulator/config"
+)
+
+// newAPIClient crea el cliente de la API con la autenticación y el TLS de
+// settings. opts se aplican después (por ejemplo la política de reintentos).
+func newAPIClient(settings *config.Settings, timeout time.Duration, opts ...client.Option) (*client.Client, error) {
+	tlsConfig, err := settings.TLSConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error configurando TLS de la API: %w", err)
+	}
+	base := []client.Option{
+		client.WithTLS(tlsConfig),
+		client.WithAuth(client.Auth{
+			Token:        settings.APIToken,
+			APIKey:       settings.APIKey,
+			APIKeyHeader: settings.APIKeyHeader,
+		}),
+	}
+	return client.New(settings.APIBaseURL, timeout, append(base, opts...)...), nil
+}
@@ -1,93 +1,241 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net"
-	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
 	"time"
+
+	"pybot-simulator/api/client"
+	"pybot-simulator/config"
 )
 
-// Backup es el struct que contiene la URL base y el cliente HTTP.
-// Es el equivalente a tu clase `Backup`.
-type Backup struct {
-	baseURL string
-	client  *http.Client
+// Disparadores de un backup, para el registro del último resultado.
+const (
+	BackupOnStartup  = "startup"
+	BackupOnSchedule = "schedule"
+	BackupOnRotation = "rotation"
+)
+
+// BackupResult es el resultado de una corrida del backup.
+type BackupResult struct {
+	At         time.Time
+	Trigger    string
+	Reachable  bool   // el host del backend respondió al sondeo
+	OK         bool   // el backend confirmó el backup
+	Err        string // error del backend, si hubo
+	Duration   time.Duration
+	ExportPath string // archivo local escrito, si hay exportación
+	ExportErr  string // error de la exportación local, si hubo
 }
 
-// NewBackup es el constructor, equivalente a tu `__init__`.
-func NewBackup() *Backup {
-	return &Backup{
-		baseURL: "https://pybot.aleosh.online/sensors/backup",
-		client: &http.Client{
-			Timeout: 15 * time.Second, // Timeout para las peticiones HTTP
-		},
+// Status resume el resultado en pocas palabras. Un backup del servidor que
+// salió bien con la exportación local fallida no es "ok".
+func (r BackupResult) Status() string {
+	switch {
+	case r.OK && r.ExportErr != "":
+		return "ok, exportación falló"
+	case r.OK:
+		return "ok"
+	case !r.Reachable:
+		return "sin conexión"
+	default:
+		return "error"
 	}
 }
 
-// checkInternetConnection (privado) verifica la conectividad a un host y puerto.
-// Usamos una función privada (minúscula) ya que solo la llama Start.
-// Esta función es el equivalente a tu `check_internet_conection`
-func (b *Backup) checkInternetConnection() bool {
-	// Usamos los mismos valores por defecto de tu método
-	host := "8.8.8.8"
-	port := 53
-	timeout := 3 * time.Second
+// BackupArchive es la exportación local de los períodos de la sesión.
+type BackupArchive struct {
+	ExportedAt  time.Time              `json:"exported_at"`
+	PrototypeID string                 `json:"prototype_id"`
+	Periods     []PeriodRecord         `json:"periods"`
+	Server      map[string]interface{} `json:"server,omitempty"` // respuesta de /backup/, si la hubo
+}
+
+// Backup pide al backend que haga su backup y, si hay directorio de
+// exportación, guarda un archivo JSON con los períodos y lecturas de la
+// sesión, conservando solo las últimas keep exportaciones. Recuerda el
+// resultado de la última corrida.
+type Backup struct {
+	api       *client.Client
+	probeAddr string // host:puerto del backend
+	exportDir string
+	keep      int // exportaciones que se conservan; 0 = todas
+	periods   *RegisterPeriods
+
+	runMu sync.Mutex // una corrida a la vez
+	mu    sync.Mutex // protege last; Last no espera a una corrida en curso
+	last  BackupResult
+}
+
+// probeTimeout es la espera máxima del sondeo de conexión.
+const probeTimeout = 3 * time.Second
+
+// NewBackup crea el servicio para la API de settings. periods da los períodos
+// de la sesión para la exportación local (puede ser nil).
+func NewBackup(settings *config.Settings, periods *RegisterPeriods) (*Backup, error) {
+	api, err := newAPIClient(settings, 15*time.Second, client.WithRetry(client.NoRetry))
+	if err != nil {
+		return nil, err
+	}
+	return &Backup{
+		api:       api,
+		probeAddr: probeAddress(settings.APIBaseURL),
+		exportDir: settings.BackupExportDir,
+		keep:      settings.BackupKeep,
+		periods:   periods,
+	}, nil
+}
 
-	address := fmt.Sprintf("%s:%d", host, port)
+// probeAddress saca host:puerto de la URL de la API.
+func probeAddress(apiBaseURL string) string {
+	u, err := url.Parse(apiBaseURL)
+	if err != nil {
+		return ""
+	}
+	port := u.Port()
+	if port == "" {
+		port = "80"
+		if u.Scheme == "https" {
+			port = "443"
+		}
+	}
+	return net.JoinHostPort(u.Hostname(), port)
+}
 
-	// net.DialTimeout es el equivalente directo y más idiomático en Go
-	// para socket.socket(...).connect() con un timeout.
-	conn, err := net.DialTimeout("tcp", address, timeout)
+// checkConnection verifica que el host del backend acepte conexiones.
+func (b *Backup) checkConnection(ctx context.Context) error {
+	if b.probeAddr == "" {
+		return fmt.Errorf("URL de la API inválida")
+	}
+	dialer := net.Dialer{Timeout: probeTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", b.probeAddr)
 	if err != nil {
-		// Si hay un error, no hay conexión
-		return false
+		return err
 	}
-	// Cerramos la conexión inmediatamente, solo queríamos saber si se podía establecer.
 	conn.Close()
-	return true
+	return nil
 }
 
-// Start es el método principal, equivalente a tu `start`.
-func (b *Backup) Start() {
-	connection := b.checkInternetConnection()
+// Run hace una corrida del backup y devuelve su resultado.
+func (b *Backup) Run(ctx context.Context, trigger string) BackupResult {
+	b.runMu.Lock()
+	defer b.runMu.Unlock()
 
-	if connection {
-		fmt.Println("Se hace el backup")
+	res := BackupResult{At: time.Now(), Trigger: trigger}
+	var server map[string]interface{}
+	if err := b.checkConnection(ctx); err != nil {
+		res.Err = err.Error()
+		log.Printf("[Backup] Backend %s no disponible: %v", b.probeAddr, err)
+	} else {
+		res.Reachable = true
+		server, err = b.api.Backup(ctx)
+		if err != nil {
+			res.Err = err.Error()
+			log.Printf("[Backup] Error al hacer el backup: %v", err)
+		} else {
+			res.OK = true
+		}
+	}
 
-		// --- Este es el bloque que tenías comentado en Python ---
-		// Hacemos un POST a la URL base, sin cuerpo (nil)
-		resp, err := b.client.Get(b.baseURL+"/")
+	if b.exportDir != "" {
+		path, err := b.export(ctx, res.Reachable, server)
 		if err != nil {
-			fmt.Printf("[FetchAPI] Error al hacer el backup: %v\n", err)
-			// Si la petición falla (ej. error de red), no podemos leer resp,
-			// así que salimos.
-			return
+			res.ExportErr = err.Error()
+			log.Printf("[Backup] Error exportando: %v", err)
+		} else if err := b.prune(); err != nil {
+			log.Printf("[Backup] Error borrando exportaciones viejas: %v", err)
 		}
-		defer resp.Body.Close()
+		res.ExportPath = path
+	}
 
-		// Equivalente a resp.raise_for_status()
-		isError := resp.StatusCode >= 400
-		if isError {
-			fmt.Printf("[FetchAPI] Error al hacer el backup, status: %d\n", resp.StatusCode)
+	res.Duration = time.Since(res.At)
+	b.mu.Lock()
+	b.last = res
+	b.mu.Unlock()
+	log.Printf("[Backup] %s (%s) en %s", res.Status(), trigger, res.Duration.Round(time.Millisecond))
+	return res
+}
+
+// export escribe el archivo JSON de la sesión. Si el backend responde, las
+// lecturas de los períodos abiertos se piden al servidor.
+func (b *Backup) export(ctx context.Context, reachable bool, server map[string]interface{}) (string, error) {
+	archive := BackupArchive{ExportedAt: time.Now().UTC(), Server: server}
+	if b.periods != nil {
+		archive.PrototypeID = b.periods.prototypeID
+		archive.Periods = b.periods.SessionPeriods()
+	}
+	for i, p := range archive.Periods {
+		if p.Reading != nil || p.PeriodID <= 0 || !reachable {
+			continue
+		}
+		if reading, err := b.api.GetReadingsGlobal(ctx, p.PeriodID); err == nil {
+			archive.Periods[i].Reading = &reading
 		}
+	}
 
-		// Intentamos decodificar el JSON, tal como lo hacías en Python
-		// (incluso en el bloque de error).
-		var result interface{}
-		if decodeErr := json.NewDecoder(resp.Body).Decode(&result); decodeErr != nil {
-			// Si no es un error de status, pero falla el JSON, lo reportamos.
-			if !isError {
-				fmt.Printf("[FetchAPI] Error decodificando respuesta: %v\n", decodeErr)
-			}
-		} else {
-			// Imprimimos la respuesta si se decodificó bien.
-			fmt.Println(result)
+	raw, err := json.MarshalIndent(archive, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(b.exportDir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(b.exportDir, "backup-"+archive.ExportedAt.Format("20060102T150405.000Z")+".json")
+	if err := os.WriteFile(path, raw, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// exportPattern son los archivos que escribe export. El nombre lleva la
+// fecha, así que el orden alfabético es el cronológico.
+const exportPattern = "backup-*.json"
+
+// prune borra las exportaciones más viejas y deja las últimas keep.
+func (b *Backup) prune() error {
+	if b.keep <= 0 {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(b.exportDir, exportPattern))
+	if err != nil || len(paths) <= b.keep {
+		return err
+	}
+	sort.Strings(paths)
+	var errs []error
+	for _, path := range paths[:len(paths)-b.keep] {
+		if err := os.Remove(path); err != nil {
+			errs = append(errs, err)
 		}
-		// --- Fin del bloque ---
+	}
+	return errors.Join(errs...)
+}
 
-	} else {
-		// Mantuve tu typo "Baackup"
-		fmt.Println("Baackup no disponible")
+// Last devuelve el resultado de la última corrida; false si no hubo ninguna.
+func (b *Backup) Last() (BackupResult, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last, !b.last.At.IsZero()
+}
+
+// RunEvery corre el backup cada interval hasta que ctx se cancele.
+func (b *Backup) RunEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.Run(ctx, BackupOnSchedule)
+		}
 	}
-}
\ No newline at end of file
+}
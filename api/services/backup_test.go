@@ -0,0 +1,79 @@
+package services
+
+import (
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/backend"
+	"pybot-simulator/api/client"
+)
+
+// newTestBackup arma un Backup contra el sustituto local que exporta a dir.
+func newTestBackup(t *testing.T, dir string, keep int) *Backup {
+	t.Helper()
+	srv := httptest.NewServer(backend.NewServer(backend.NewMemoryStore()))
+	t.Cleanup(srv.Close)
+	return &Backup{
+		api:       client.New(srv.URL, 5*time.Second, client.WithRetry(client.NoRetry)),
+		probeAddr: srv.Listener.Addr().String(),
+		exportDir: dir,
+		keep:      keep,
+	}
+}
+
+func TestBackupReportsExportFailure(t *testing.T) {
+	// El directorio de exportación es un archivo: el backup del servidor sale
+	// bien pero la exportación no
+	dir := filepath.Join(t.TempDir(), "no-es-un-directorio")
+	if err := os.WriteFile(dir, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := newTestBackup(t, dir, 0).Run(t.Context(), BackupOnSchedule)
+	if !res.OK || res.Err != "" {
+		t.Fatalf("backup del servidor: %+v", res)
+	}
+	if res.ExportErr == "" || res.ExportPath != "" {
+		t.Errorf("exportación: %+v", res)
+	}
+	if res.Status() == "ok" {
+		t.Errorf("Status() = %q con la exportación fallida", res.Status())
+	}
+}
+
+func TestBackupKeepsLastExports(t *testing.T) {
+	dir := t.TempDir()
+	b := newTestBackup(t, dir, 3)
+	var paths []string
+	for range 5 {
+		res := b.Run(t.Context(), BackupOnSchedule)
+		if res.Status() != "ok" {
+			t.Fatalf("corrida: %+v", res)
+		}
+		paths = append(paths, res.ExportPath)
+		time.Sleep(2 * time.Millisecond) // el nombre tiene milisegundos
+	}
+
+	left, err := filepath.Glob(filepath.Join(dir, exportPattern))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(left) != 3 {
+		t.Fatalf("quedaron %d exportaciones, se esperaban 3: %v", len(left), left)
+	}
+	for i, path := range paths {
+		_, err := os.Stat(path)
+		if kept := i >= 2; kept != (err == nil) {
+			t.Errorf("exportación %d: conservada=%v, error %v", i, kept, err)
+		}
+	}
+
+	// Con keep = 0 no se borra nada
+	b.keep = 0
+	b.Run(t.Context(), BackupOnSchedule)
+	if left, _ = filepath.Glob(filepath.Join(dir, exportPattern)); len(left) != 4 {
+		t.Errorf("con keep 0 quedaron %d exportaciones, se esperaban 4", len(left))
+	}
+}
@@ -0,0 +1,460 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pybot-simulator/api/client"
+	"pybot-simulator/api/journal"
+)
+
+// Operaciones que se pueden guardar en el diario.
+const (
+	opCreatePeriod   = "create_period"
+	opClosePeriod    = "close_period"
+	opCreateReading  = "create_reading"
+	opUpdateReading  = "update_reading"
+	opWeight         = "weight"
+	opGPS            = "gps"
+	opWeightBatch    = "weight_batch"
+	opGPSBatch       = "gps_batch"
+	opCreateWaste    = "create_waste"
+	opIncrementWaste = "increment_waste"
+)
+
+// closePeriodOp es el payload de opClosePeriod.
+type closePeriodOp struct {
+	PeriodID int64  `json:"period_id"`
+	EndHour  string `json:"end_hour"`
+}
+
+// incrementWasteOp es el payload de opIncrementWaste.
+type incrementWasteOp struct {
+	WasteCollectionID int64 `json:"waste_collection_id"`
+}
+
+// repeatedWhenAmbiguous son las operaciones que se vuelven a mandar aunque un
+// fallo deje la duda de si el servidor las aplicó y el servidor no haya
+// confirmado que respeta Idempotency-Key: fijar la hora de fin o una lectura
+// da lo mismo dos veces, y sin el período o la recolección no se puede
+// registrar nada más (una creación repetida deja, a lo sumo, un recurso
+// vacío). Las demás suman registros o contadores y quedarían duplicadas.
+var repeatedWhenAmbiguous = map[string]bool{
+	opCreatePeriod:  true,
+	opClosePeriod:   true,
+	opCreateReading: true,
+	opUpdateReading: true,
+	opCreateWaste:   true,
+}
+
+// mayRepeat indica si op se puede volver a mandar, por reintento o desde el
+// diario, después de fallar con el error pasajero err.
+func (r *RegisterPeriods) mayRepeat(op string, err error) bool {
+	return repeatedWhenAmbiguous[op] || !client.IsAmbiguous(err) || r.api.ReplaysIdempotencyKeys()
+}
+
+// send ejecuta una operación contra la API. Si no hay conexión, o si ya hay
+// operaciones esperando en el diario (para no alterar el orden), la guarda en
+// el diario y devuelve queued=true. Si la operación crea un recurso y quedó
+// en el diario, id es un ID local provisional (negativo).
+//
+// key es la clave de idempotencia de la operación; si viene vacía se genera
+// una. La misma clave viaja en los reintentos y en los reenvíos del diario, y
+// una clave que ya se envió (o ya espera en el diario) no se vuelve a mandar.
+//
+// Una operación que suma (un conteo, un registro de telemetría) no se repite
+// tras un fallo ambiguo si el servidor no confirmó que respeta las claves
+// (ver mayRepeat): se devuelve el error y no va al diario.
+//
+// Los reintentos los lleva send con la política del cliente. Desde el primer
+// fallo la operación ocupa su lugar en el diario y apiMu se suelta durante
+// cada espera: las escrituras que llegan mientras tanto no la esperan, pero
+// van al diario detrás de ella, y Flush no pasa de ella hasta que termine.
+// Sin diario no hay dónde ponerlas, así que apiMu queda tomado en la espera.
+func (r *RegisterPeriods) send(ctx context.Context, op string, creates bool, key string, payload interface{}) (id int64, queued bool, err error) {
+	generated := key == ""
+	if generated {
+		key = client.NewIdempotencyKey()
+	}
+	opCtx := client.SingleAttempt(client.WithIdempotencyKey(ctx, key))
+
+	r.apiMu.Lock()
+	defer r.apiMu.Unlock()
+	if !generated && (r.sent.seen(key) || (r.journal != nil && r.journal.HasKey(key))) {
+		log.Printf("[Offline] %s con clave %s ya enviado, se ignora el duplicado", op, key)
+		return 0, false, nil
+	}
+	if r.journal != nil && r.journal.Len() > 0 {
+		e, err := r.enqueue(op, creates, key, payload)
+		return e.LocalID, err == nil, err
+	}
+
+	policy := r.api.RetryPolicy()
+	var entry journal.Entry // su lugar en el diario desde el primer fallo
+	ambiguous := false
+	for attempt := 1; ; attempt++ {
+		id, err = r.execute(opCtx, op, payload)
+		if err == nil || !client.IsTemporary(err) {
+			break
+		}
+		if !r.mayRepeat(op, err) {
+			log.Printf("[Offline] %s no se repite: pudo haberse aplicado y el servidor no confirma %s: %v",
+				op, client.IdempotencyHeader, err)
+			ambiguous = true
+			break
+		}
+		if r.journal != nil && entry.Seq == 0 {
+			e, qErr := r.enqueue(op, creates, key, payload)
+			if qErr != nil {
+				return 0, false, qErr
+			}
+			entry = e
+			r.retrying = entry.Seq
+			defer func() { r.retrying = 0 }()
+		}
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			break
+		}
+
+		wait := time.NewTimer(policy.Wait(attempt, err))
+		if r.journal != nil {
+			r.apiMu.Unlock()
+		}
+		select {
+		case <-ctx.Done():
+		case <-wait.C:
+		}
+		wait.Stop()
+		if r.journal != nil {
+			r.apiMu.Lock()
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if entry.Seq == 0 {
+		if err == nil {
+			r.sent.add(key)
+		}
+		return id, false, err
+	}
+	switch {
+	case err == nil:
+		r.sent.add(key)
+		return id, false, r.journal.Ack(entry.Seq, id)
+	case client.IsTemporary(err) && !ambiguous:
+		log.Printf("[Offline] %s sin conexión, queda en el diario: %v", op, err)
+		return entry.LocalID, true, nil
+	default:
+		// Rechazo definitivo, o no se puede repetir: sale del diario
+		if ackErr := r.journal.Ack(entry.Seq, 0); ackErr != nil {
+			log.Printf("[Offline] %v", ackErr)
+		}
+		return 0, false, err
+	}
+}
+
+// enqueue guarda la operación al final del diario, con un ID local si crea
+// un recurso. Se llama con apiMu tomado.
+func (r *RegisterPeriods) enqueue(op string, creates bool, key string, payload interface{}) (journal.Entry, error) {
+	var localID int64
+	if creates {
+		localID = r.journal.NewLocalID()
+	}
+	e, err := r.journal.Append(op, localID, key, payload)
+	if err != nil {
+		return journal.Entry{}, fmt.Errorf("error guardando %s en el diario: %w", op, err)
+	}
+	return e, nil
+}
+
+// sentKeys recuerda las últimas claves de idempotencia enviadas con éxito,
+// para descartar localmente eventos repetidos aunque el servidor no soporte
+// la cabecera Idempotency-Key.
+type sentKeys struct {
+	keys  map[string]struct{}
+	order []string
+}
+
+const maxSentKeys = 4096
+
+func (s *sentKeys) seen(key string) bool {
+	_, ok := s.keys[key]
+	return ok
+}
+
+func (s *sentKeys) add(key string) {
+	if s.keys == nil {
+		s.keys = make(map[string]struct{})
+	}
+	if _, ok := s.keys[key]; ok {
+		return
+	}
+	s.keys[key] = struct{}{}
+	s.order = append(s.order, key)
+	if len(s.order) > maxSentKeys {
+		delete(s.keys, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// execute hace la llamada real. Los IDs locales del payload se traducen a
+// los del servidor antes de enviar.
+func (r *RegisterPeriods) execute(ctx context.Context, op string, payload interface{}) (int64, error) {
+	resolve := func(id int64) (int64, error) {
+		if r.journal == nil {
+			return id, nil
+		}
+		resolved := r.journal.Resolve(id)
+		if resolved < 0 {
+			return 0, fmt.Errorf("ID local %d sin traducir", id)
+		}
+		return resolved, nil
+	}
+
+	var err error
+	switch p := payload.(type) {
+	case client.WorkPeriod:
+		return r.api.CreatePeriod(ctx, p)
+	case closePeriodOp:
+		if p.PeriodID, err = resolve(p.PeriodID); err != nil {
+			return 0, err
+		}
+		return 0, r.api.ClosePeriod(ctx, p.PeriodID, p.EndHour)
+	case client.Reading:
+		if p.PeriodID, err = resolve(p.PeriodID); err != nil {
+			return 0, err
+		}
+		if op == opCreateReading {
+			return 0, r.api.CreateReading(ctx, p)
+		}
+		return 0, r.api.UpdateReading(ctx, p)
+	case client.WeightRecord:
+		if p.PeriodID, err = resolve(p.PeriodID); err != nil {
+			return 0, err
+		}
+		return 0, r.api.RegisterWeight(ctx, p)
+	case client.GPSRecord:
+		if p.PeriodID, err = resolve(p.PeriodID); err != nil {
+			return 0, err
+		}
+		return 0, r.api.RegisterGPS(ctx, p)
+	case []client.WeightRecord:
+		records := make([]client.WeightRecord, len(p))
+		for i, rec := range p {
+			if rec.PeriodID, err = resolve(rec.PeriodID); err != nil {
+				return 0, err
+			}
+			records[i] = rec
+		}
+		err = r.api.RegisterWeightBatch(ctx, records)
+		if !client.IsBulkUnsupported(err) {
+			return 0, err
+		}
+		// El lote no se guardó: va registro por registro
+		key := client.IdempotencyKey(ctx)
+		for i, rec := range records {
+			if err := r.api.RegisterWeight(client.WithIdempotencyKey(ctx, recordKey(key, i)), rec); err != nil {
+				return 0, err
+			}
+		}
+		return 0, nil
+	case []client.GPSRecord:
+		records := make([]client.GPSRecord, len(p))
+		for i, rec := range p {
+			if rec.PeriodID, err = resolve(rec.PeriodID); err != nil {
+				return 0, err
+			}
+			records[i] = rec
+		}
+		err = r.api.RegisterGPSBatch(ctx, records)
+		if !client.IsBulkUnsupported(err) {
+			return 0, err
+		}
+		key := client.IdempotencyKey(ctx)
+		for i, rec := range records {
+			if err := r.api.RegisterGPS(client.WithIdempotencyKey(ctx, recordKey(key, i)), rec); err != nil {
+				return 0, err
+			}
+		}
+		return 0, nil
+	case client.WasteCollection:
+		if p.PeriodID, err = resolve(p.PeriodID); err != nil {
+			return 0, err
+		}
+		return r.api.CreateWasteCollection(ctx, p)
+	case incrementWasteOp:
+		if p.WasteCollectionID, err = resolve(p.WasteCollectionID); err != nil {
+			return 0, err
+		}
+		return 0, r.api.IncrementWasteCollection(ctx, p.WasteCollectionID)
+	}
+	return 0, fmt.Errorf("operación desconocida %s (%T)", op, payload)
+}
+
+// decodeEntry reconstruye el payload tipado de una entrada del diario.
+func decodeEntry(e journal.Entry) (interface{}, error) {
+	var err error
+	switch e.Op {
+	case opCreatePeriod:
+		var p client.WorkPeriod
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opClosePeriod:
+		var p closePeriodOp
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opCreateReading, opUpdateReading:
+		var p client.Reading
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opWeight:
+		var p client.WeightRecord
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opGPS:
+		var p client.GPSRecord
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opWeightBatch:
+		var p []client.WeightRecord
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opGPSBatch:
+		var p []client.GPSRecord
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opCreateWaste:
+		var p client.WasteCollection
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	case opIncrementWaste:
+		var p incrementWasteOp
+		err = json.Unmarshal(e.Payload, &p)
+		return p, err
+	}
+	return nil, fmt.Errorf("operación desconocida en el diario: %s", e.Op)
+}
+
+// Flush reenvía en orden las operaciones del diario. Se detiene en el primer
+// fallo pasajero (sigue sin conexión); las operaciones que el servidor
+// rechaza definitivamente (4xx), o que no se pueden repetir tras un fallo
+// ambiguo (ver mayRepeat), se descartan para no bloquear el resto.
+func (r *RegisterPeriods) Flush(ctx context.Context) error {
+	if r.journal == nil {
+		return nil
+	}
+	r.apiMu.Lock()
+	defer r.apiMu.Unlock()
+
+	for _, e := range r.journal.Pending() {
+		if e.Seq == r.retrying {
+			// La está reintentando send; lo que sigue va después de ella
+			return fmt.Errorf("el diario espera a %s #%d, que se está reintentando", e.Op, e.Seq)
+		}
+		payload, err := decodeEntry(e)
+		if err == nil {
+			var serverID int64
+			// Un intento por entrada: el próximo Flush reintenta, y así
+			// apiMu no queda tomado durante un backoff
+			opCtx := client.SingleAttempt(ctx)
+			if e.Key != "" {
+				opCtx = client.WithIdempotencyKey(opCtx, e.Key)
+			}
+			serverID, err = r.execute(opCtx, e.Op, payload)
+			if err == nil {
+				if e.Key != "" {
+					r.sent.add(e.Key)
+				}
+				// El diario guarda la traducción al confirmar: si el programa
+				// se corta antes de remap, NewRegisterPeriods la aplica al
+				// arrancar
+				if err := r.journal.Ack(e.Seq, serverID); err != nil {
+					return err
+				}
+				if e.LocalID != 0 {
+					r.remap(e.LocalID, serverID)
+				}
+				continue
+			}
+			if client.IsTemporary(err) && r.mayRepeat(e.Op, err) {
+				return fmt.Errorf("el diario sigue pendiente (%d operaciones): %w", r.journal.Len(), err)
+			}
+		}
+		log.Printf("[Offline] Se descarta %s #%d: %v", e.Op, e.Seq, err)
+		if err := r.journal.Ack(e.Seq, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// remap reemplaza un ID local por el del servidor en el estado en memoria.
+func (r *RegisterPeriods) remap(localID, serverID int64) {
+	if err := r.periods.remap(localID, serverID); err != nil {
+		log.Printf("[Offline] Error guardando el ID del período: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range []*int64{&r.wIDPET, &r.wIDCANS} {
+		if *id == localID {
+			*id = serverID
+		}
+	}
+	for i := range r.history {
+		if r.history[i].PeriodID == localID {
+			r.history[i].PeriodID = serverID
+		}
+	}
+	log.Printf("[Offline] ID local %d -> %d", localID, serverID)
+}
+
+// RunSync reintenta el diario cada interval hasta que ctx se cancele.
+func (r *RegisterPeriods) RunSync(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if r.PendingOps() == 0 {
+				continue
+			}
+			if err := r.Flush(ctx); err != nil {
+				log.Printf("[Offline] %v", err)
+			} else {
+				log.Println("[Offline] Diario enviado por completo.")
+			}
+		}
+	}
+}
+
+// PendingOps devuelve cuántas operaciones esperan en el diario.
+func (r *RegisterPeriods) PendingOps() int {
+	if r.journal == nil {
+		return 0
+	}
+	return r.journal.Len()
+}
+
+// closeTimeout limita el último envío de telemetría en Close; lo que no
+// salga a tiempo queda en el diario.
+const closeTimeout = 10 * time.Second
+
+// Close envía la telemetría encolada y cierra el diario.
+func (r *RegisterPeriods) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), closeTimeout)
+	defer cancel()
+	if err := r.stopTelemetry(ctx); err != nil {
+		log.Printf("[Telemetry] %v", err)
+	}
+	if r.journal == nil {
+		return nil
+	}
+	return r.journal.Close()
+}
@@ -0,0 +1,364 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/backend"
+	"pybot-simulator/api/client"
+	"pybot-simulator/api/journal"
+	"pybot-simulator/config"
+)
+
+func TestSendKeepsOrderWhileBackingOff(t *testing.T) {
+	// La primera creación de período responde 503 con Retry-After: 1
+	var failed atomic.Bool
+	store := backend.NewMemoryStore()
+	rec := &writeRecorder{next: backend.NewServer(store)}
+	r := newJournaledWith(t, http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost && req.URL.Path == "/workPeriods/" && failed.CompareAndSwap(false, true) {
+			w.Header().Set("Retry-After", "1")
+			http.Error(w, `{"message":"ocupado"}`, http.StatusServiceUnavailable)
+			return
+		}
+		rec.ServeHTTP(w, req)
+	}))
+	r.api = client.New(r.api.BaseURL(), 5*time.Second, client.WithRetry(client.RetryPolicy{MaxAttempts: 2}))
+
+	type result struct {
+		id     int64
+		queued bool
+		err    error
+	}
+	created := make(chan result, 1)
+	go func() {
+		id, queued, err := r.send(context.Background(), opCreatePeriod, true, "", client.WorkPeriod{StartHour: "2025-01-02T08:00:00Z"})
+		created <- result{id, queued, err}
+	}()
+	for !failed.Load() {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // que la creación llegue a su espera
+
+	// Mientras la creación espera su reintento, otra escritura no la espera
+	// pero tampoco se le adelanta: queda en el diario detrás de ella
+	start := time.Now()
+	_, queued, err := r.send(context.Background(), opWeight, false, "", client.WeightRecord{PeriodID: 1, Weight: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if waited := time.Since(start); waited > 500*time.Millisecond {
+		t.Errorf("la escritura esperó %s al backoff de otra", waited)
+	}
+	if !queued {
+		t.Error("la escritura salió antes que la creación que se estaba reintentando")
+	}
+	if err := r.Flush(context.Background()); err == nil {
+		t.Error("Flush pasó de largo la creación que se estaba reintentando")
+	}
+
+	select {
+	case res := <-created:
+		if res.err != nil || res.queued || res.id <= 0 {
+			t.Errorf("la creación terminó con %+v, se esperaba el ID del servidor", res)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("la creación no terminó")
+	}
+
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if got, want := rec.takeCalls(), []string{"POST /workPeriods/", "POST /sensors/weight"}; !slices.Equal(got, want) {
+		t.Errorf("escrituras %v, se esperaba %v", got, want)
+	}
+	if n := r.PendingOps(); n != 0 {
+		t.Errorf("quedaron %d operaciones en el diario", n)
+	}
+}
+
+// writeRecorder anota las escrituras que llegan al sustituto, en orden, y
+// hace fallar las rutas de fail con el código indicado.
+type writeRecorder struct {
+	next http.Handler
+
+	mu      sync.Mutex
+	calls   []string
+	weights []client.WeightRecord
+	fail    map[string]int
+}
+
+func (w *writeRecorder) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+	if r.Method == http.MethodGet {
+		w.next.ServeHTTP(rw, r)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	w.mu.Lock()
+	w.calls = append(w.calls, route)
+	code := w.fail[route]
+	if code == 0 && route == "POST /sensors/weight" {
+		var rec client.WeightRecord
+		json.Unmarshal(body, &rec)
+		w.weights = append(w.weights, rec)
+	}
+	w.mu.Unlock()
+
+	if code != 0 {
+		http.Error(rw, `{"message":"falla de prueba"}`, code)
+		return
+	}
+	w.next.ServeHTTP(rw, r)
+}
+
+func (w *writeRecorder) setFail(route string, code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.fail == nil {
+		w.fail = map[string]int{}
+	}
+	w.fail[route] = code
+}
+
+func (w *writeRecorder) takeCalls() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	calls := w.calls
+	w.calls = nil
+	return calls
+}
+
+// newJournaled arma un RegisterPeriods con diario contra el sustituto,
+// envuelto en un writeRecorder.
+func newJournaled(t *testing.T) (*RegisterPeriods, *writeRecorder, *backend.Store) {
+	t.Helper()
+	store := backend.NewMemoryStore()
+	rec := &writeRecorder{next: backend.NewServer(store)}
+	return newJournaledWith(t, rec), rec, store
+}
+
+// newJournaledWith arma un RegisterPeriods con diario contra handler.
+func newJournaledWith(t *testing.T, handler http.Handler) *RegisterPeriods {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	r := newTestPeriods(t, srv.URL, filepath.Join(dir, "period.json"))
+	j, err := journal.Open(filepath.Join(dir, "journal.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { j.Close() })
+	r.journal = j
+	return r
+}
+
+// queueOfflinePeriod deja en el diario lo que hace una sesión sin conexión:
+// crear un período (con ID local), mandar peso y GPS y cerrarlo.
+func queueOfflinePeriod(t *testing.T, r *RegisterPeriods) int64 {
+	t.Helper()
+	local := r.journal.NewLocalID()
+	ops := []struct {
+		op      string
+		localID int64
+		payload interface{}
+	}{
+		{opCreatePeriod, local, client.WorkPeriod{StartHour: "2025-01-02T08:00:00Z", PrototypeID: "test"}},
+		{opWeight, 0, client.WeightRecord{PeriodID: local, Weight: 0.5}},
+		{opGPS, 0, client.GPSRecord{PeriodID: local, Latitude: 1}},
+		{opClosePeriod, 0, closePeriodOp{PeriodID: local, EndHour: "2025-01-02T09:00:00Z"}},
+	}
+	for i, o := range ops {
+		if _, err := r.journal.Append(o.op, o.localID, fmt.Sprintf("offline-%d", i), o.payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.periods.transition(PeriodOpen, func(next *PeriodRecord) { next.PeriodID = local }); err != nil {
+		t.Fatal(err)
+	}
+	return local
+}
+
+func TestFlushReplaysInOrderAndRemaps(t *testing.T) {
+	r, rec, store := newJournaled(t)
+	queueOfflinePeriod(t, r)
+
+	if err := r.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	want := []string{"POST /workPeriods/", "POST /sensors/weight", "POST /sensors/gps", "PATCH /workPeriods/"}
+	if got := rec.takeCalls(); !slices.Equal(got, want) {
+		t.Errorf("orden de reenvío %v, se esperaba %v", got, want)
+	}
+
+	periods := store.Periods()
+	if len(periods) != 1 || periods[0].EndHour == "" {
+		t.Fatalf("períodos en el servidor: %+v", periods)
+	}
+	server := periods[0].PeriodID
+	if len(rec.weights) != 1 || rec.weights[0].PeriodID != server {
+		t.Errorf("el peso llegó con %+v, se esperaba el período %d", rec.weights, server)
+	}
+	if got := r.PeriodStatus().PeriodID; got != server {
+		t.Errorf("el estado del período quedó con el ID %d, se esperaba %d", got, server)
+	}
+	if n := r.PendingOps(); n != 0 {
+		t.Errorf("quedaron %d operaciones en el diario", n)
+	}
+}
+
+func TestFlushStopsOnTemporaryError(t *testing.T) {
+	r, rec, store := newJournaled(t)
+	queueOfflinePeriod(t, r)
+
+	// El GPS falla de forma pasajera: el período y el peso salen, el resto espera
+	rec.setFail("POST /sensors/gps", http.StatusServiceUnavailable)
+	if err := r.Flush(t.Context()); err == nil {
+		t.Fatal("Flush no informó el fallo pasajero")
+	}
+	if n := r.PendingOps(); n != 2 {
+		t.Fatalf("quedaron %d operaciones, se esperaban 2 (GPS y cierre)", n)
+	}
+	if got := rec.takeCalls(); len(got) != 3 {
+		t.Errorf("se intentaron %v; el cierre no debía salir antes que el GPS", got)
+	}
+
+	// Cuando vuelve, se retoma desde el GPS con el ID ya traducido
+	rec.setFail("POST /sensors/gps", 0)
+	if err := r.Flush(t.Context()); err != nil {
+		t.Fatalf("segundo Flush: %v", err)
+	}
+	if got, want := rec.takeCalls(), []string{"POST /sensors/gps", "PATCH /workPeriods/"}; !slices.Equal(got, want) {
+		t.Errorf("segundo Flush mandó %v, se esperaba %v", got, want)
+	}
+	if c := store.Counts(); c["periods"] != 1 || c["weights"] != 1 || c["gps"] != 1 {
+		t.Errorf("el servidor tiene %v", c)
+	}
+}
+
+func TestFlushDiscardsRejected(t *testing.T) {
+	r, rec, store := newJournaled(t)
+	queueOfflinePeriod(t, r)
+
+	// El servidor rechaza el peso para siempre: se descarta y el resto sigue
+	rec.setFail("POST /sensors/weight", http.StatusUnprocessableEntity)
+	if err := r.Flush(t.Context()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if n := r.PendingOps(); n != 0 {
+		t.Errorf("quedaron %d operaciones en el diario", n)
+	}
+	if c := store.Counts(); c["weights"] != 0 || c["gps"] != 1 {
+		t.Errorf("el servidor tiene %v", c)
+	}
+	if p := store.Periods(); len(p) != 1 || p[0].EndHour == "" {
+		t.Errorf("el período no se cerró: %+v", p)
+	}
+}
+
+func TestRestartRemapsConfirmedPeriod(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "period.json")
+	journalPath := filepath.Join(dir, "journal.jsonl")
+
+	// Sesión anterior: el período se creó sin conexión y su creación se
+	// confirmó, pero el programa se cortó antes de guardar el ID del servidor
+	periods, err := OpenPeriodMachine(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := journal.Open(journalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	local := j.NewLocalID()
+	e, err := j.Append(opCreatePeriod, local, "crear", client.WorkPeriod{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := periods.transition(PeriodOpen, func(next *PeriodRecord) { next.PeriodID = local }); err != nil {
+		t.Fatal(err)
+	}
+	if err := j.Ack(e.Seq, 42); err != nil {
+		t.Fatal(err)
+	}
+	j.Close()
+
+	r, err := NewRegisterPeriods(&config.Settings{
+		APIBaseURL:       "http://127.0.0.1:1",
+		JournalPath:      journalPath,
+		PeriodStatePath:  statePath,
+		RetryMaxAttempts: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if got := r.PeriodStatus().PeriodID; got != 42 {
+		t.Errorf("al arrancar el período tiene el ID %d, se esperaba 42", got)
+	}
+	// Un ID local nuevo no choca con el que ya se usó
+	if next := r.journal.NewLocalID(); next == local {
+		t.Errorf("el ID local %d se repartió dos veces", next)
+	}
+}
+
+// ignoreKeys es un servidor que no conoce Idempotency-Key, como la API real:
+// la cabecera no le llega al sustituto, que entonces no repite respuestas.
+func ignoreKeys(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(client.IdempotencyHeader)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func TestIncrementNotRepeatedWithoutIdempotency(t *testing.T) {
+	store := backend.NewMemoryStore()
+	r := newJournaledWith(t, &lostResponse{next: ignoreKeys(backend.NewServer(store)), route: "PATCH /sensors/"})
+	r.api = client.New(r.api.BaseURL(), 5*time.Second, client.WithRetry(client.RetryPolicy{MaxAttempts: 3}))
+	if err := r.CreateNewPeriod(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateWasteCollection(client.WastePET); err != nil {
+		t.Fatal(err)
+	}
+	id := r.GetIdWasteCollectionPET()
+
+	// La suma se aplicó pero la respuesta se perdió: no se reintenta ni queda
+	// en el diario para reenviarse
+	if err := r.UpdateWasteCollection(id, "recoleccion-1"); err == nil {
+		t.Error("la suma con la respuesta perdida no informó el error")
+	}
+	if n := r.PendingOps(); n != 0 {
+		t.Errorf("la suma ambigua quedó en el diario (%d operaciones)", n)
+	}
+	if err := r.Flush(t.Context()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.UpdateWasteCollection(id, "recoleccion-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	collections, err := store.WasteCollections(r.GetActualPeriodID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collections) != 1 || collections[0].Amount != 3 {
+		t.Errorf("recolecciones %+v, se esperaba una con 3 (1 inicial + 2 sumas)", collections)
+	}
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"pybot-simulator/api/client"
+)
+
+// PeriodState es el estado del período de trabajo del prototipo.
+//
+//	none ──> open ──> closing ──> closed ──> open ...
+//
+// closing puede repetirse: si el cierre falla a la mitad se queda en closing
+// y el siguiente intento retoma desde el paso que faltaba.
+type PeriodState string
+
+const (
+	PeriodNone    PeriodState = "none"
+	PeriodOpen    PeriodState = "open"
+	PeriodClosing PeriodState = "closing"
+	PeriodClosed  PeriodState = "closed"
+)
+
+// periodTransitions son las transiciones válidas desde cada estado.
+var periodTransitions = map[PeriodState][]PeriodState{
+	PeriodNone:    {PeriodOpen},
+	PeriodOpen:    {PeriodClosing},
+	PeriodClosing: {PeriodClosing, PeriodClosed},
+	PeriodClosed:  {PeriodOpen},
+}
+
+// ErrInvalidTransition se devuelve al pedir una transición no permitida.
+var ErrInvalidTransition = errors.New("transición de período inválida")
+
+// PeriodRecord es el estado persistido del período actual.
+type PeriodRecord struct {
+	State     PeriodState `json:"state"`
+	PeriodID  int64       `json:"period_id,omitempty"` // negativo si se creó sin conexión
+	StartHour string      `json:"start_hour,omitempty"`
+	// LastHour es la hora del último dato de un período heredado del backend
+	// (de una sesión anterior); se usa como hora de fin al cerrarlo.
+	LastHour string `json:"last_hour,omitempty"`
+
+	// Avance del cierre, para retomarlo si falla a la mitad
+	EndHour     string          `json:"end_hour,omitempty"`
+	Reading     *client.Reading `json:"reading,omitempty"`
+	CloseSent   bool            `json:"close_sent,omitempty"`
+	ReadingSent bool            `json:"reading_sent,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PeriodMachine guarda el estado del período en un archivo JSON y solo lo
+// cambia con transiciones válidas, así sobrevive a reinicios del simulador.
+type PeriodMachine struct {
+	mu   sync.Mutex
+	path string
+	rec  PeriodRecord
+}
+
+// OpenPeriodMachine carga el estado de path. Si el archivo no existe se
+// empieza en PeriodNone; path vacío deja el estado solo en memoria.
+func OpenPeriodMachine(path string) (*PeriodMachine, error) {
+	m := &PeriodMachine{path: path, rec: PeriodRecord{State: PeriodNone}}
+	if path == "" {
+		return m, nil
+	}
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo estado del período %s: %w", path, err)
+	}
+	if err := json.Unmarshal(raw, &m.rec); err != nil {
+		return nil, fmt.Errorf("error decodificando estado del período %s: %w", path, err)
+	}
+	if _, ok := periodTransitions[m.rec.State]; !ok {
+		return nil, fmt.Errorf("estado de período desconocido en %s: %q", path, m.rec.State)
+	}
+	return m, nil
+}
+
+// Record devuelve una copia del estado actual.
+func (m *PeriodMachine) Record() PeriodRecord {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.rec
+}
+
+// State devuelve el estado actual.
+func (m *PeriodMachine) State() PeriodState {
+	return m.Record().State
+}
+
+// CanTransition indica si se puede pasar de from a to.
+func CanTransition(from, to PeriodState) bool {
+	for _, s := range periodTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// transition valida el paso al estado to, aplica update sobre una copia del
+// registro y la persiste. Si algo falla el estado en memoria no cambia.
+func (m *PeriodMachine) transition(to PeriodState, update func(rec *PeriodRecord)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !CanTransition(m.rec.State, to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, m.rec.State, to)
+	}
+	next := m.rec
+	if update != nil {
+		update(&next)
+	}
+	next.State = to
+	next.UpdatedAt = time.Now().UTC()
+	if err := m.save(next); err != nil {
+		return err
+	}
+	m.rec = next
+	return nil
+}
+
+// remap traduce el ID local del período al del servidor.
+func (m *PeriodMachine) remap(localID, serverID int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rec.PeriodID != localID {
+		return nil
+	}
+	next := m.rec
+	next.PeriodID = serverID
+	if next.Reading != nil {
+		reading := *next.Reading
+		reading.PeriodID = serverID
+		next.Reading = &reading
+	}
+	if err := m.save(next); err != nil {
+		return err
+	}
+	m.rec = next
+	return nil
+}
+
+// save se llama con mu tomado. Escribe a un temporal y lo renombra para no
+// dejar el archivo a medias.
+func (m *PeriodMachine) save(rec PeriodRecord) error {
+	if m.path == "" {
+		return nil
+	}
+	raw, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.path), 0o755); err != nil {
+		return fmt.Errorf("error creando directorio del estado del período: %w", err)
+	}
+	tmp := m.path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("error guardando estado del período: %w", err)
+	}
+	return os.Rename(tmp, m.path)
+}
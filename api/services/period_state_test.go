@@ -0,0 +1,284 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/backend"
+	"pybot-simulator/api/client"
+)
+
+var allPeriodStates = []PeriodState{PeriodNone, PeriodOpen, PeriodClosing, PeriodClosed}
+
+func TestCanTransition(t *testing.T) {
+	valid := map[[2]PeriodState]bool{
+		{PeriodNone, PeriodOpen}:       true,
+		{PeriodOpen, PeriodClosing}:    true,
+		{PeriodClosing, PeriodClosing}: true,
+		{PeriodClosing, PeriodClosed}:  true,
+		{PeriodClosed, PeriodOpen}:     true,
+	}
+	for _, from := range allPeriodStates {
+		for _, to := range allPeriodStates {
+			if got, want := CanTransition(from, to), valid[[2]PeriodState{from, to}]; got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, se esperaba %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestPeriodMachineTransitions(t *testing.T) {
+	m, err := OpenPeriodMachine(filepath.Join(t.TempDir(), "period.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Recorrido completo, con un segundo período al final
+	steps := []PeriodState{PeriodOpen, PeriodClosing, PeriodClosing, PeriodClosed, PeriodOpen}
+	for _, to := range steps {
+		if err := m.transition(to, nil); err != nil {
+			t.Fatalf("%s: %v", to, err)
+		}
+		if got := m.State(); got != to {
+			t.Fatalf("estado %s, se esperaba %s", got, to)
+		}
+	}
+
+	// Las transiciones inválidas se rechazan sin tocar el estado
+	for _, to := range []PeriodState{PeriodNone, PeriodOpen, PeriodClosed} {
+		before := m.Record()
+		err := m.transition(to, func(next *PeriodRecord) { next.PeriodID = 99 })
+		if !errors.Is(err, ErrInvalidTransition) {
+			t.Errorf("open -> %s: error %v, se esperaba ErrInvalidTransition", to, err)
+		}
+		if after := m.Record(); after != before {
+			t.Errorf("open -> %s cambió el registro: %+v", to, after)
+		}
+	}
+}
+
+func TestOpenPeriodMachineReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "period.json")
+
+	m, err := OpenPeriodMachine(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.State() != PeriodNone {
+		t.Fatalf("sin archivo el estado es %s, se esperaba none", m.State())
+	}
+	err = m.transition(PeriodOpen, func(next *PeriodRecord) {
+		next.PeriodID = 7
+		next.StartHour = "2025-01-02T08:00:00Z"
+	})
+	if err == nil {
+		err = m.transition(PeriodClosing, func(next *PeriodRecord) {
+			next.EndHour = "2025-01-02T12:00:00Z"
+			next.Reading = &client.Reading{PeriodID: 7, DistanceTraveled: 12.5}
+			next.CloseSent = true
+		})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := OpenPeriodMachine(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, got := m.Record(), reloaded.Record()
+	if got.State != want.State || got.PeriodID != want.PeriodID || got.EndHour != want.EndHour ||
+		!got.CloseSent || got.ReadingSent || got.Reading == nil || *got.Reading != *want.Reading {
+		t.Errorf("estado recargado %+v, se esperaba %+v", got, want)
+	}
+
+	// Un estado desconocido en el archivo es un error
+	if err := os.WriteFile(path, []byte(`{"state":"lost"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OpenPeriodMachine(path); err == nil {
+		t.Error("se aceptó un estado desconocido")
+	}
+}
+
+// flakyBackend es el sustituto local de la API con fallos inyectados: las
+// próximas fails[ruta] peticiones a una ruta ("PATCH /workPeriods/")
+// responden 500 sin llegar al sustituto.
+type flakyBackend struct {
+	next http.Handler
+
+	mu    sync.Mutex
+	fails map[string]int
+	calls map[string]int // peticiones que sí llegaron al sustituto
+}
+
+func newFlakyBackend(store *backend.Store) *flakyBackend {
+	return &flakyBackend{next: backend.NewServer(store), fails: map[string]int{}, calls: map[string]int{}}
+}
+
+func (f *flakyBackend) failNext(route string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fails[route]++
+}
+
+func (f *flakyBackend) served(route string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[route]
+}
+
+func (f *flakyBackend) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	route := r.Method + " " + r.URL.Path
+	f.mu.Lock()
+	fail := f.fails[route] > 0
+	if fail {
+		f.fails[route]--
+	} else {
+		f.calls[route]++
+	}
+	f.mu.Unlock()
+	if fail {
+		http.Error(w, `{"message":"fallo inyectado"}`, http.StatusInternalServerError)
+		return
+	}
+	f.next.ServeHTTP(w, r)
+}
+
+// newTestPeriods arma un RegisterPeriods sin diario ni lotes contra url,
+// con el estado en statePath. Sin diario los fallos llegan al llamador.
+func newTestPeriods(t *testing.T, url, statePath string) *RegisterPeriods {
+	t.Helper()
+	periods, err := OpenPeriodMachine(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &RegisterPeriods{
+		api:         client.New(url, 5*time.Second, client.WithRetry(client.NoRetry)),
+		periods:     periods,
+		prototypeID: "test",
+	}
+}
+
+func TestFinishPeriodResumesAfterFailure(t *testing.T) {
+	const (
+		readingsGlobal = "GET /workPeriods/readingsGlobal"
+		closePeriod    = "PATCH /workPeriods/"
+		updateReading  = "PUT /workPeriods/"
+	)
+	cases := []struct {
+		name        string
+		failing     string
+		wantReading bool // la lectura ya estaba guardada al fallar
+		wantClose   bool // el cierre ya se había enviado al fallar
+	}{
+		{"GetReadingsGlobal", readingsGlobal, false, false},
+		{"ClosePeriod", closePeriod, true, false},
+		{"UpdateReading", updateReading, true, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := backend.NewMemoryStore()
+			api := newFlakyBackend(store)
+			srv := httptest.NewServer(api)
+			defer srv.Close()
+			statePath := filepath.Join(t.TempDir(), "period.json")
+
+			r := newTestPeriods(t, srv.URL, statePath)
+			if err := r.CreateNewPeriod(time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			id := r.GetActualPeriodID()
+			if err := store.PutReading(client.Reading{PeriodID: id, DistanceTraveled: 42, WeightWaste: 3.5}); err != nil {
+				t.Fatal(err)
+			}
+
+			api.failNext(tc.failing)
+			if err := r.CloseCurrentPeriod(t.Context(), time.Now()); err == nil {
+				t.Fatal("el cierre no falló")
+			}
+			rec := r.PeriodStatus()
+			if rec.State != PeriodClosing || (rec.Reading != nil) != tc.wantReading || rec.CloseSent != tc.wantClose || rec.ReadingSent {
+				t.Fatalf("tras el fallo: %+v", rec)
+			}
+			endHour := rec.EndHour
+
+			// Se retoma en otra sesión, desde el estado guardado
+			resumed := newTestPeriods(t, srv.URL, statePath)
+			if err := resumed.CloseCurrentPeriod(t.Context(), time.Now()); err != nil {
+				t.Fatalf("al retomar: %v", err)
+			}
+			rec = resumed.PeriodStatus()
+			if rec.State != PeriodClosed || !rec.CloseSent || !rec.ReadingSent || rec.EndHour != endHour {
+				t.Fatalf("tras retomar: %+v", rec)
+			}
+
+			// Cada paso llegó al servidor una sola vez
+			for _, route := range []string{readingsGlobal, closePeriod, updateReading} {
+				if n := api.served(route); n != 1 {
+					t.Errorf("%s llegó %d veces al servidor", route, n)
+				}
+			}
+			period, err := store.Period(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if period.EndHour != endHour {
+				t.Errorf("hora de fin %q, se esperaba %q", period.EndHour, endHour)
+			}
+			reading, err := store.Reading(id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reading.DistanceTraveled != 42 || reading.WeightWaste != 3.5 {
+				t.Errorf("lectura final %+v", reading)
+			}
+		})
+	}
+}
+
+func TestFinishPeriodRejectsWithoutOpenPeriod(t *testing.T) {
+	srv := httptest.NewServer(backend.NewServer(backend.NewMemoryStore()))
+	defer srv.Close()
+	r := newTestPeriods(t, srv.URL, "")
+
+	if err := r.CloseCurrentPeriod(t.Context(), time.Now()); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("cerrar sin período: %v, se esperaba ErrInvalidTransition", err)
+	}
+	if err := r.CreateNewPeriod(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.CreateNewPeriod(time.Now()); !errors.Is(err, ErrInvalidTransition) {
+		t.Errorf("abrir con un período abierto: %v, se esperaba ErrInvalidTransition", err)
+	}
+}
+
+func TestPeriodHoursComeFromCaller(t *testing.T) {
+	store := backend.NewMemoryStore()
+	srv := httptest.NewServer(backend.NewServer(store))
+	defer srv.Close()
+	r := newTestPeriods(t, srv.URL, "")
+
+	// Un turno simulado de una hora, aunque en el reloj pasen milisegundos
+	start := time.Date(2030, 1, 7, 8, 0, 0, 0, time.UTC)
+	if err := r.CreateNewPeriod(start); err != nil {
+		t.Fatal(err)
+	}
+	id := r.GetActualPeriodID()
+	if err := r.CloseCurrentPeriod(t.Context(), start.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	period, err := store.Period(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if period.StartHour != "2030-01-07T08:00:00Z" || period.EndHour != "2030-01-07T09:00:00Z" || period.DayWork != "Mon" {
+		t.Errorf("período guardado %+v", period)
+	}
+}
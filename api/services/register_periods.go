@@ -1,178 +1,182 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"math"
-	"os"
-	"strconv"
+	"sync"
 	"time"
 
-	"github.com/joho/godotenv"
+	"pybot-simulator/api/client"
+	"pybot-simulator/api/journal"
+	"pybot-simulator/config"
 )
 
 // RegisterPeriods es el equivalente a tu clase.
 // Mantiene el estado y los servicios.
+// Las llamadas que escriben pasan por send: si no hay conexión se guardan en
+// el diario y se reenvían después (ver offline.go). El período actual vive en
+// una máquina de estados persistida (ver period_state.go).
 type RegisterPeriods struct {
-	serviceWorkPeriods *WorkPeriodService
-	serviceSensors     *SensorRegisterService
-	prototypeID        string
-	actualPeriodID     int64
-	lastPeriodID       int64
-	lastHourPeriod     string
-	wIDCANS            int64
-	wIDPET             int64
+	api     *client.Client
+	journal *journal.Journal
+	periods *PeriodMachine
+	// telemetry agrupa GPS y peso en lotes; nil si está desactivado
+	telemetry *telemetryBatcher
+	apiMu     sync.Mutex // serializa las escrituras para respetar el orden del diario
+	sent      sentKeys   // protegido por apiMu
+	// retrying es la entrada del diario que send está reintentando, o 0;
+	// protegido por apiMu
+	retrying uint64
+
+	mu          sync.Mutex // protege los IDs de abajo
+	prototypeID string
+	history     []PeriodRecord // períodos cerrados en esta sesión
+	wIDCANS     int64
+	wIDPET      int64
 }
 
 // NewRegisterPeriods es el constructor, equivalente a tu __init__.
-func NewRegisterPeriods() (*RegisterPeriods, error) {
-	// Cargar .env
-	err := godotenv.Load()
+func NewRegisterPeriods(settings *config.Settings) (*RegisterPeriods, error) {
+	api, err := newAPIClient(settings, 10*time.Second, client.WithRetry(client.RetryPolicy{
+		MaxAttempts: settings.RetryMaxAttempts,
+		BaseDelay:   settings.RetryBaseDelay,
+		MaxDelay:    settings.RetryMaxDelay,
+	}))
 	if err != nil {
-		log.Println("Advertencia: No se pudo cargar el archivo .env", err)
-		// Podrías decidir si es un error fatal o no
+		return nil, err
 	}
-
-	return &RegisterPeriods{
-		serviceWorkPeriods: NewWorkPeriodService(),
-		serviceSensors:     NewSensorRegisterService(),
-		prototypeID:        os.Getenv("ID_PROTOTYPE"),
-	}, nil
-}
-
-func getFloat(data map[string]interface{}, key string, defaultVal float64) float64 {
-	val, ok := data[key]
-	if !ok {
-		return defaultVal
-	}
-	// JSON decodifica números como float64
-	floatVal, ok := val.(float64)
-	if !ok {
-		return defaultVal
-	}
-	return floatVal
-}
-
-func getString(data map[string]interface{}, key string, defaultVal string) string {
-	val, ok := data[key]
-	if !ok {
-		return defaultVal
-	}
-	strVal, ok := val.(string)
-	if !ok {
-		return defaultVal
+	r := &RegisterPeriods{
+		api:         api,
+		prototypeID: settings.PrototypeID,
 	}
-	return strVal
-}
 
-func (r *RegisterPeriods) StatusPeriod() (bool, error) {
-	res, err := r.serviceWorkPeriods.GetLastHourPeriod()
+	periods, err := OpenPeriodMachine(settings.PeriodStatePath)
 	if err != nil {
-		return false, fmt.Errorf("error en GetLastHourPeriod: %w", err)
+		return nil, err
 	}
-
-	// Replicamos la lógica de `res.get('last_period').get('period_id')`
-	// Esto es mucho más verboso en Go por la seguridad de tipos.
-	resMap, ok := res.(map[string]interface{})
-	if !ok {
-		return false, fmt.Errorf("respuesta no es un mapa")
+	r.periods = periods
+	if rec := periods.Record(); rec.State != PeriodNone {
+		log.Printf("Estado del período recuperado: %s (ID %d)", rec.State, rec.PeriodID)
 	}
 
-	lastPeriodVal, ok := resMap["last_period"]
-	if !ok {
-		return false, fmt.Errorf("respuesta no contiene 'last_period'")
+	if settings.TelemetryBatch {
+		r.telemetry = newTelemetryBatcher(settings.TelemetryBatchSize, settings.TelemetryBatchWindow)
+		go r.runTelemetry()
 	}
 
-	lastPeriod, ok := lastPeriodVal.(map[string]interface{})
-	if !ok {
-		return false, fmt.Errorf("'last_period' no es un mapa")
+	j, err := journal.Open(settings.JournalPath)
+	if err != nil {
+		// Sin diario se sigue funcionando, pero los datos sin conexión se pierden
+		log.Printf("Advertencia: No se pudo abrir el diario offline: %v", err)
+	} else {
+		r.journal = j
+		if n := j.Len(); n > 0 {
+			log.Printf("[Offline] %d operaciones pendientes de una sesión anterior", n)
+		}
+		// Si la sesión anterior se cortó entre confirmar la creación del
+		// período y guardar su ID del servidor, el diario todavía lo sabe
+		if local := periods.Record().PeriodID; local < 0 {
+			if server := j.Resolve(local); server > 0 {
+				r.remap(local, server)
+			}
+		}
+	}
+	return r, nil
+}
+
+// StatusPeriod indica si hay que crear el primer período (true) o si quedó
+// uno pendiente (false). Manda el estado local: si hay un período abierto o a
+// medio cerrar no se consulta al backend. Si no, se pregunta por el último
+// período y, si hay uno abierto que no conocíamos, se adopta como abierto.
+func (r *RegisterPeriods) StatusPeriod() (bool, error) {
+	rec := r.periods.Record()
+	if rec.State == PeriodOpen || rec.State == PeriodClosing {
+		return false, nil
 	}
 
-	// JSON decodifica números como float64 por defecto en interface{}
-	periodIDFloat, ok := lastPeriod["period_id"].(float64)
-	if !ok {
-		return false, fmt.Errorf("'period_id' no encontrado o no es número")
+	last, err := r.api.GetLastPeriod(context.Background())
+	if err != nil {
+		return false, fmt.Errorf("error en GetLastPeriod: %w", err)
 	}
 
-	periodID := int64(periodIDFloat)
-	if periodID == 0 {
+	// El cierre del último período local puede seguir en el diario
+	if last.PeriodID == 0 || (rec.State == PeriodClosed && last.PeriodID == rec.PeriodID) {
 		return true, nil
 	}
 
-	// Guardamos el estado si el período no es 0
-	r.lastPeriodID = periodID
-	r.lastHourPeriod = getString(lastPeriod, "last_hour", "")
-
+	err = r.periods.transition(PeriodOpen, func(next *PeriodRecord) {
+		*next = PeriodRecord{PeriodID: last.PeriodID, LastHour: last.LastHour}
+	})
+	if err != nil {
+		return false, err
+	}
 	return false, nil
 }
 
-// CreateNewPeriod crea un nuevo período de trabajo.
-func (r *RegisterPeriods) CreateNewPeriod() error {
-	startHour := time.Now().UTC()
-	dayWork := startHour.Format("Mon") // %a = 'Mon', 'Tue', etc.
-
-	dBody := map[string]interface{}{
-		"period_id":    0,
-		"start_hour":   startHour.Format(time.RFC3339), // Formato ISO
-		"end_hour":     "",
-		"day_work":     dayWork,
-		"prototype_id": r.prototypeID,
-	}
-
-	res, err := r.serviceWorkPeriods.CreateNewPeriod(dBody)
+// CreateNewPeriod crea un nuevo período de trabajo que empieza en start. Solo
+// es válido si no hay un período abierto ni a medio cerrar. La hora la pone
+// quien llama: el simulador pasa su reloj simulado, así un turno simulado de
+// una hora queda registrado como una hora aunque corra a otra velocidad.
+func (r *RegisterPeriods) CreateNewPeriod(start time.Time) error {
+	if state := r.periods.State(); !CanTransition(state, PeriodOpen) {
+		return fmt.Errorf("%w: no se puede abrir un período en estado %s", ErrInvalidTransition, state)
+	}
+	startHour := start.UTC()
+
+	id, queued, err := r.send(context.Background(), opCreatePeriod, true, "", client.WorkPeriod{
+		StartHour:   startHour.Format(time.RFC3339), // Formato ISO
+		DayWork:     startHour.Format("Mon"),        // %a = 'Mon', 'Tue', etc.
+		PrototypeID: r.prototypeID,
+	})
 	if err != nil {
-		return fmt.Errorf("error en CreateNewPeriod: %w", err)
+		return fmt.Errorf("error en CreatePeriod: %w", err)
 	}
 
-	// Replicamos `res.get('data').get('work_periods_id')`
-	resMap, ok := res.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("respuesta de CreateNewPeriod no es un mapa")
-	}
-	data, ok := resMap["data"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("respuesta no contiene 'data'")
+	err = r.periods.transition(PeriodOpen, func(next *PeriodRecord) {
+		*next = PeriodRecord{PeriodID: id, StartHour: startHour.Format(time.RFC3339)}
+	})
+	if err != nil {
+		return err
 	}
-
-	idFloat := getFloat(data, "work_periods_id", 0)
-	id := int64(idFloat)
-
-	if id != 0 {
-		r.actualPeriodID = id
-		fmt.Printf("p_id en cnp: %d", r.actualPeriodID)
+	if queued {
+		log.Printf("p_id provisional (sin conexión): %d", id)
 	} else {
-		fmt.Println("Ocurrio un error al crear el periodo (ID fue 0)")
-		return fmt.Errorf("API devolvió un ID de 0")
+		log.Printf("p_id en cnp: %d", id)
 	}
 	return nil
 }
 
-// RegisterWeigh registra una lectura de peso.
+// RegisterWeigh registra una lectura de peso. Con los lotes activados solo
+// la encola (ver telemetry.go).
 func (r *RegisterPeriods) RegisterWeigh(weight float64) error {
-	hourPeriod := time.Now().UTC().Format(time.RFC3339)
-	// Go no tiene round(f, 4). Se hace así:
-	roundedWeight := math.Round(weight*10000) / 10000
-
-	dBody := map[string]interface{}{
-		"weight_data_id": 0,
-		"period_id":      r.actualPeriodID,
-		"Hour_period":    hourPeriod,
-		"Weight":         roundedWeight,
+	record := client.WeightRecord{
+		PeriodID:   r.GetActualPeriodID(),
+		HourPeriod: time.Now().UTC().Format(time.RFC3339),
+		// Go no tiene round(f, 4). Se hace así:
+		Weight: math.Round(weight*10000) / 10000,
 	}
-
-	_, err := r.serviceSensors.RegisterWeightData(dBody)
+	if r.telemetry != nil {
+		r.telemetry.add(&record, nil)
+		return nil
+	}
+	_, _, err := r.send(context.Background(), opWeight, false, "", record)
 	if err != nil {
-		return fmt.Errorf("error en RegisterWeightData: %w", err)
+		return fmt.Errorf("error en RegisterWeight: %w", err)
 	}
 	return nil
 }
 
 // RegisterGPS registra datos de GPS.
-// Nota: data es map[string]interface{} para simular el dict de Python.
+// Nota: data es el mismo map que se publica por RabbitMQ.
 func (r *RegisterPeriods) RegisterGPS(data map[string]interface{}) error {
-	// Replicamos la lógica `or` de Python usando nuestros helpers
-	dateStr := getString(data, "date", "2015-07-13")
-	utcStr := getString(data, "UTC", "")
+	// Replicamos la lógica `or` de Python
+	dateStr, _ := data["date"].(string)
+	utcStr, _ := data["UTC"].(string)
+	if dateStr == "" {
+		dateStr = "2015-07-13"
+	}
 
 	var hourUTC string
 	// Esta lógica replica tu `or '2025-07-12T...'`
@@ -181,79 +185,51 @@ func (r *RegisterPeriods) RegisterGPS(data map[string]interface{}) error {
 	} else {
 		hourUTC = fmt.Sprintf("%sT%s+00:00", dateStr, utcStr)
 	}
-	fmt.Printf("p_id en rgps: %d", r.actualPeriodID)
-	dBody := map[string]interface{}{
-		//"gps_data_id": 0,
-		"period_id":   r.actualPeriodID,
-		"latitude":    getFloat(data, "lat", 0.0),
-		"longitude":   getFloat(data, "lon", 0.0),
-		"altitude":    getFloat(data, "alt", 0.0),
-		"speed":       getFloat(data, "spd", 0.0),
-		"date_gps":    dateStr,
-		"hour_UTC":    hourUTC,
-	}
 
-	_, err := r.serviceSensors.RegisterGPSData(dBody)
+	lat, _ := data["lat"].(float64)
+	lon, _ := data["lon"].(float64)
+	alt, _ := data["alt"].(float64)
+	spd, _ := data["spd"].(float64)
+
+	record := client.GPSRecord{
+		PeriodID:  r.GetActualPeriodID(),
+		Latitude:  lat,
+		Longitude: lon,
+		Altitude:  alt,
+		Speed:     spd,
+		DateGPS:   dateStr,
+		HourUTC:   hourUTC,
+	}
+	if r.telemetry != nil {
+		r.telemetry.add(nil, &record)
+		return nil
+	}
+	_, _, err := r.send(context.Background(), opGPS, false, "", record)
 	if err != nil {
-		return fmt.Errorf("error en RegisterGPSData: %w", err)
+		return fmt.Errorf("error en RegisterGPS: %w", err)
 	}
 	return nil
 }
 
 // CreateVoidReading crea una lectura vacía para el período actual.
 func (r *RegisterPeriods) CreateVoidReading() error {
-	dBody := map[string]interface{}{
-		"period_id":         r.actualPeriodID,
-		"distance_traveled": 0.0,
-		"weight_waste":      0.0,
-	}
-	_, err := r.serviceWorkPeriods.CreateNewReading(dBody)
+	_, _, err := r.send(context.Background(), opCreateReading, false, "", client.Reading{PeriodID: r.GetActualPeriodID()})
 	if err != nil {
-		return fmt.Errorf("error en CreateNewReading: %w", err)
+		return fmt.Errorf("error en CreateReading: %w", err)
 	}
 	return nil
 }
 
-// CompleteLastPeriod completa el período anterior y crea uno nuevo.
-func (r *RegisterPeriods) CompleteLastPeriod() error {
-	id := strconv.FormatInt(r.lastPeriodID, 10) // Convierte int64 a string
-
-	res, err := r.serviceWorkPeriods.GetDistanceAndWeight(id)
-	if err != nil {
-		return fmt.Errorf("error en GetDistanceAndWeight: %w", err)
-	}
-	fmt.Println(res) // Imprime la respuesta
-
-	resMap, ok := res.(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("respuesta de GetDistanceAndWeight no es un mapa")
+// CompleteLastPeriod cierra el período en curso (o termina de cerrar uno que
+// quedó a medias) y crea uno nuevo que empieza en at. Si el período vino de
+// una sesión anterior se cierra con la hora de su último dato; si no, en at.
+func (r *RegisterPeriods) CompleteLastPeriod(at time.Time) error {
+	if err := r.CloseCurrentPeriod(context.Background(), at); err != nil {
+		return err
 	}
 
-	res1, err := r.serviceWorkPeriods.UpdateLastPeriod(r.lastHourPeriod, id)
-	if err != nil {
-		return fmt.Errorf("error en UpdateLastPeriod: %w", err)
-	}
-	fmt.Println(res1) // Imprime la respuesta
-
-	lastReading, ok := resMap["last_reading"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("respuesta no contiene 'last_reading'")
-	}
-
-	dBody := map[string]interface{}{
-		"period_id":         r.lastPeriodID,
-		"distance_traveled": getFloat(lastReading, "distance_traveled", 0.0),
-		"weight_waste":      getFloat(lastReading, "weight_waste", 0.0),
-	}
-
-	res3, err := r.serviceWorkPeriods.UpdateLastReadig(dBody)
-	if err != nil {
-		return fmt.Errorf("error en UpdateLastReadig: %w", err)
-	}
-	fmt.Println(res3) // Imprime la respuesta
-
 	// Encadenamos la creación del nuevo período
-	if err := r.CreateNewPeriod(); err != nil {
+	if err := r.CreateNewPeriod(at); err != nil {
 		return fmt.Errorf("error en CreateNewPeriod (parte de CompleteLastPeriod): %w", err)
 	}
 	if err := r.CreateVoidReading(); err != nil {
@@ -262,29 +238,102 @@ func (r *RegisterPeriods) CompleteLastPeriod() error {
 	return nil
 }
 
+// ClosePeriod cierra el período actual al terminar la sesión: guarda la
+// última lectura de distancia y peso y registra endHour como hora de fin.
+func (r *RegisterPeriods) ClosePeriod(ctx context.Context, endHour time.Time, distance, weight float64) error {
+	rec := r.periods.Record()
+	if rec.State != PeriodOpen && rec.State != PeriodClosing {
+		return fmt.Errorf("no hay un período activo que cerrar (estado %s)", rec.State)
+	}
+	return r.finishPeriod(ctx, endHour.UTC().Format(time.RFC3339), &client.Reading{
+		PeriodID:         rec.PeriodID,
+		DistanceTraveled: math.Round(distance*100) / 100,
+		WeightWaste:      math.Round(weight*10000) / 10000,
+	})
+}
+
+// finishPeriod lleva el período de open a closed pasando por closing. Cada
+// paso queda guardado en el estado, así que si algo falla el período se queda
+// en closing y la siguiente llamada retoma desde el paso pendiente. reading es
+// la lectura final; si es nil se usa la lectura global del backend. Si el
+// período ya estaba en closing se respetan la hora y la lectura guardadas.
+func (r *RegisterPeriods) finishPeriod(ctx context.Context, endHour string, reading *client.Reading) error {
+	// Las lecturas encoladas del período tienen que llegar antes del cierre
+	if err := r.FlushTelemetry(ctx); err != nil {
+		log.Printf("[Telemetry] %v", err)
+	}
+
+	rec := r.periods.Record()
+	switch rec.State {
+	case PeriodOpen:
+		err := r.periods.transition(PeriodClosing, func(next *PeriodRecord) {
+			next.EndHour = endHour
+			next.Reading = reading
+		})
+		if err != nil {
+			return err
+		}
+	case PeriodClosing:
+		log.Printf("Retomando el cierre del período %d", rec.PeriodID)
+	default:
+		return fmt.Errorf("%w: no hay un período abierto que cerrar (estado %s)", ErrInvalidTransition, rec.State)
+	}
+
+	rec = r.periods.Record()
+	if rec.Reading == nil {
+		fetched, err := r.api.GetReadingsGlobal(ctx, rec.PeriodID)
+		if err != nil {
+			return fmt.Errorf("error en GetReadingsGlobal: %w", err)
+		}
+		fetched.PeriodID = rec.PeriodID
+		if err := r.periods.transition(PeriodClosing, func(next *PeriodRecord) { next.Reading = &fetched }); err != nil {
+			return err
+		}
+	}
+
+	rec = r.periods.Record()
+	if !rec.CloseSent {
+		closeOp := closePeriodOp{PeriodID: rec.PeriodID, EndHour: rec.EndHour}
+		if _, _, err := r.send(ctx, opClosePeriod, false, "", closeOp); err != nil {
+			return fmt.Errorf("error en ClosePeriod: %w", err)
+		}
+		if err := r.periods.transition(PeriodClosing, func(next *PeriodRecord) { next.CloseSent = true }); err != nil {
+			return err
+		}
+	}
+
+	rec = r.periods.Record()
+	if !rec.ReadingSent {
+		if _, _, err := r.send(ctx, opUpdateReading, false, "", *rec.Reading); err != nil {
+			return fmt.Errorf("error en UpdateReading: %w", err)
+		}
+		if err := r.periods.transition(PeriodClosing, func(next *PeriodRecord) { next.ReadingSent = true }); err != nil {
+			return err
+		}
+	}
+	if err := r.periods.transition(PeriodClosed, nil); err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.history = append(r.history, r.periods.Record())
+	r.mu.Unlock()
+	return nil
+}
+
 // CreateWasteCollection crea un registro de recolección de basura.
 func (r *RegisterPeriods) CreateWasteCollection(wasteID int64) error {
-	dBody := map[string]interface{}{
-		"waste_collection_id": 0,
-		"period_id":           r.actualPeriodID,
-		"amount":              1,
-		"waste_id":            wasteID,
-	}
-
-	res, err := r.serviceSensors.RegisterWasteCollection(dBody)
+	id, _, err := r.send(context.Background(), opCreateWaste, true, "", client.WasteCollection{
+		PeriodID: r.GetActualPeriodID(),
+		Amount:   1,
+		WasteID:  wasteID,
+	})
 	if err != nil {
-		return fmt.Errorf("error en RegisterWasteCollection: %w", err)
+		return fmt.Errorf("error en CreateWasteCollection: %w", err)
 	}
 
-	// Replicamos `res.get('data').get('waste_collection_id')`
-	resMap, ok := res.(map[string]interface{})
-	if !ok { return fmt.Errorf("respuesta de RegisterWasteCollection no es un mapa") }
-	data, ok := resMap["data"].(map[string]interface{})
-	if !ok { return fmt.Errorf("respuesta no contiene 'data'") }
-
-	id := int64(getFloat(data, "waste_collection_id", 0))
-
-	if wasteID == 1 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if wasteID == client.WastePET {
 		r.wIDPET = id
 	} else {
 		r.wIDCANS = id
@@ -292,29 +341,93 @@ func (r *RegisterPeriods) CreateWasteCollection(wasteID int64) error {
 	return nil
 }
 
-// UpdateWasteCollection actualiza una recolección.
-func (r *RegisterPeriods) UpdateWasteCollection(wasteCollectionID int64) error {
-	idStr := strconv.FormatInt(wasteCollectionID, 10)
-	res, err := r.serviceSensors.UpdateWasteCollection(idStr)
-	if err != nil {
-		fmt.Println(res) // Imprime respuesta aunque haya error
-		return fmt.Errorf("error en UpdateWasteCollection: %w", err)
+// UpdateWasteCollection suma uno a una recolección. eventKey identifica el
+// evento de recolección: el mismo evento nunca se cuenta dos veces, ni por
+// reintentos ni por reenvíos del diario. Si viene vacío se genera uno. Contra
+// un servidor que no respeta la clave, un fallo que deja la duda de si se
+// sumó no se reintenta: es preferible perder un conteo a duplicarlo.
+func (r *RegisterPeriods) UpdateWasteCollection(wasteCollectionID int64, eventKey string) error {
+	op := incrementWasteOp{WasteCollectionID: wasteCollectionID}
+	if _, _, err := r.send(context.Background(), opIncrementWaste, false, eventKey, op); err != nil {
+		return fmt.Errorf("error en IncrementWasteCollection: %w", err)
 	}
-	fmt.Println(res)
 	return nil
 }
 
 // GetIdWasteCollectionPET es un "getter" simple.
 func (r *RegisterPeriods) GetIdWasteCollectionPET() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.wIDPET
 }
 
 // GetIdWasteCollectionCANS es un "getter" simple.
 func (r *RegisterPeriods) GetIdWasteCollectionCANS() int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	return r.wIDCANS
 }
 
-// GetActualPeriodID es un "getter" para el ID del período actual.
+// GetActualPeriodID es un "getter" para el ID del período abierto, o 0 si no
+// hay ninguno.
 func (r *RegisterPeriods) GetActualPeriodID() int64 {
-	return r.actualPeriodID
-}
\ No newline at end of file
+	rec := r.periods.Record()
+	if rec.State != PeriodOpen {
+		return 0
+	}
+	return rec.PeriodID
+}
+
+// SessionPeriods devuelve los períodos cerrados en esta sesión más el actual,
+// si está abierto o cerrándose.
+func (r *RegisterPeriods) SessionPeriods() []PeriodRecord {
+	r.mu.Lock()
+	out := append([]PeriodRecord(nil), r.history...)
+	r.mu.Unlock()
+	if rec := r.periods.Record(); rec.State == PeriodOpen || rec.State == PeriodClosing {
+		out = append(out, rec)
+	}
+	return out
+}
+
+// LastBackendPeriod consulta el último período abierto en el backend sin
+// tocar el estado local.
+func (r *RegisterPeriods) LastBackendPeriod(ctx context.Context) (client.LastPeriod, error) {
+	return r.api.GetLastPeriod(ctx)
+}
+
+// CloseCurrentPeriod cierra el período abierto (o termina de cerrar uno que
+// quedó a medias) sin abrir otro, con fin en at salvo que el período venga de
+// una sesión anterior (ver CompleteLastPeriod). La lectura final es la del
+// backend.
+func (r *RegisterPeriods) CloseCurrentPeriod(ctx context.Context, at time.Time) error {
+	endHour := r.periods.Record().LastHour
+	if endHour == "" {
+		endHour = at.UTC().Format(time.RFC3339)
+	}
+	return r.finishPeriod(ctx, endHour, nil)
+}
+
+// Readings devuelve la lectura global de un período.
+func (r *RegisterPeriods) Readings(ctx context.Context, periodID int64) (client.Reading, error) {
+	return r.api.GetReadingsGlobal(ctx, r.resolveID(periodID))
+}
+
+// resolveID traduce un ID local ya confirmado al del servidor.
+func (r *RegisterPeriods) resolveID(id int64) int64 {
+	if r.journal == nil {
+		return id
+	}
+	return r.journal.Resolve(id)
+}
+
+// PeriodStatus devuelve el estado persistido del período.
+func (r *RegisterPeriods) PeriodStatus() PeriodRecord {
+	return r.periods.Record()
+}
+
+// APIHealth devuelve el estado de la conexión con la API según la última
+// llamada (incluidos los reenvíos del diario).
+func (r *RegisterPeriods) APIHealth() client.Health {
+	return r.api.Health()
+}
@@ -0,0 +1,295 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"pybot-simulator/api/client"
+)
+
+// errBulkDeferred indica que un lote no puede ir por la ruta bulk porque hay
+// operaciones en el diario o IDs locales; se manda uno por uno vía send.
+var errBulkDeferred = errors.New("lote diferido al diario")
+
+// TelemetryStats resume los envíos por lotes de GPS y peso.
+type TelemetryStats struct {
+	Batches      int           // lotes enviados por la ruta bulk
+	Records      int           // registros enviados en esos lotes
+	Singles      int           // registros enviados uno por uno (sin bulk o sin conexión)
+	Queued       int           // registros esperando a formar un lote
+	BulkDisabled bool          // el servidor no soporta bulk
+	LastLatency  time.Duration // latencia del último lote
+	MaxLatency   time.Duration
+	TotalLatency time.Duration
+}
+
+// AvgLatency es la latencia media por lote.
+func (s TelemetryStats) AvgLatency() time.Duration {
+	if s.Batches == 0 {
+		return 0
+	}
+	return s.TotalLatency / time.Duration(s.Batches)
+}
+
+// telemetryBatcher junta lecturas de GPS y peso y las envía como lotes
+// comprimidos cuando se llega a maxSize registros o pasa window desde el
+// registro más antiguo.
+type telemetryBatcher struct {
+	maxSize int
+	window  time.Duration
+
+	mu      sync.Mutex
+	weights []client.WeightRecord
+	gps     []client.GPSRecord
+	oldest  time.Time
+	stats   TelemetryStats
+
+	flushMu sync.Mutex // un flush a la vez, para no desordenar los lotes
+	kick    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func newTelemetryBatcher(maxSize int, window time.Duration) *telemetryBatcher {
+	return &telemetryBatcher{
+		maxSize: maxSize,
+		window:  window,
+		kick:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// add encola un registro y avisa al bucle si el lote ya está lleno.
+func (b *telemetryBatcher) add(weight *client.WeightRecord, gps *client.GPSRecord) {
+	b.mu.Lock()
+	if len(b.weights)+len(b.gps) == 0 {
+		b.oldest = time.Now()
+	}
+	if weight != nil {
+		b.weights = append(b.weights, *weight)
+	}
+	if gps != nil {
+		b.gps = append(b.gps, *gps)
+	}
+	full := len(b.weights)+len(b.gps) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.kick <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take saca todo lo encolado.
+func (b *telemetryBatcher) take() ([]client.WeightRecord, []client.GPSRecord) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	weights, gps := b.weights, b.gps
+	b.weights, b.gps = nil, nil
+	return weights, gps
+}
+
+// due indica si el registro más antiguo ya esperó window.
+func (b *telemetryBatcher) due() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.weights)+len(b.gps) > 0 && time.Since(b.oldest) >= b.window
+}
+
+func (b *telemetryBatcher) bulkDisabled() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats.BulkDisabled
+}
+
+func (b *telemetryBatcher) record(update func(s *TelemetryStats)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	update(&b.stats)
+}
+
+// runTelemetry envía los lotes en segundo plano hasta que se cierre el batcher.
+func (r *RegisterPeriods) runTelemetry() {
+	b := r.telemetry
+	defer close(b.done)
+
+	tick := b.window / 4
+	if tick < 50*time.Millisecond {
+		tick = 50 * time.Millisecond
+	}
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.stop:
+			return
+		case <-b.kick:
+		case <-ticker.C:
+			if !b.due() {
+				continue
+			}
+		}
+		if err := r.FlushTelemetry(context.Background()); err != nil {
+			log.Printf("[Telemetry] %v", err)
+		}
+	}
+}
+
+// FlushTelemetry envía ya lo que haya encolado. Cada tipo de registro va en
+// su propio lote con su clave de idempotencia. Tras un fallo pasajero el
+// lote puede haberse guardado igual, así que se reintenta entero vía send
+// (que lo deja en el diario) con la misma clave; pero si el fallo fue
+// ambiguo y el servidor no confirmó que respeta las claves, repetirlo podría
+// duplicar los registros, y el lote se da por perdido (ver mayRepeat). Si el
+// servidor no lo guardó
+// (no soporta bulk, lo rechazó, o había que esperar al diario) los registros
+// van uno por uno, con claves derivadas de la del lote.
+func (r *RegisterPeriods) FlushTelemetry(ctx context.Context) error {
+	if r.telemetry == nil {
+		return nil
+	}
+	b := r.telemetry
+	b.flushMu.Lock()
+	defer b.flushMu.Unlock()
+
+	weights, gps := b.take()
+	var errs []error
+	if len(weights) > 0 {
+		local := false
+		for _, w := range weights {
+			local = local || w.PeriodID < 0
+		}
+		key := client.NewIdempotencyKey()
+		err := r.sendBatch(ctx, "weight", len(weights), local, key, func(ctx context.Context) error {
+			return r.api.RegisterWeightBatch(ctx, weights)
+		})
+		switch {
+		case err == nil:
+		case client.IsTemporary(err) && !r.mayRepeat(opWeightBatch, err):
+			errs = append(errs, fmt.Errorf("lote de %d pesos sin confirmar, no se repite para no duplicarlo: %w", len(weights), err))
+		case client.IsTemporary(err):
+			if _, _, err := r.send(ctx, opWeightBatch, false, key, weights); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			for i, w := range weights {
+				if _, _, err := r.send(ctx, opWeight, false, recordKey(key, i), w); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			b.record(func(s *TelemetryStats) { s.Singles += len(weights) })
+		}
+	}
+	if len(gps) > 0 {
+		local := false
+		for _, g := range gps {
+			local = local || g.PeriodID < 0
+		}
+		key := client.NewIdempotencyKey()
+		err := r.sendBatch(ctx, "gps", len(gps), local, key, func(ctx context.Context) error {
+			return r.api.RegisterGPSBatch(ctx, gps)
+		})
+		switch {
+		case err == nil:
+		case client.IsTemporary(err) && !r.mayRepeat(opGPSBatch, err):
+			errs = append(errs, fmt.Errorf("lote de %d registros de GPS sin confirmar, no se repite para no duplicarlo: %w", len(gps), err))
+		case client.IsTemporary(err):
+			if _, _, err := r.send(ctx, opGPSBatch, false, key, gps); err != nil {
+				errs = append(errs, err)
+			}
+		default:
+			for i, g := range gps {
+				if _, _, err := r.send(ctx, opGPS, false, recordKey(key, i), g); err != nil {
+					errs = append(errs, err)
+				}
+			}
+			b.record(func(s *TelemetryStats) { s.Singles += len(gps) })
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// recordKey es la clave de idempotencia del registro i de un lote con clave
+// batchKey, para cuando el lote se manda registro por registro.
+func recordKey(batchKey string, i int) string {
+	return batchKey + "-" + strconv.Itoa(i)
+}
+
+// sendBatch manda un lote por la ruta bulk con la clave key. Devuelve
+// errBulkDeferred si el lote no llegó a salir, o el error del servidor.
+// localIDs indica que algún registro apunta a un período creado sin conexión.
+func (r *RegisterPeriods) sendBatch(ctx context.Context, kind string, n int, localIDs bool, key string, post func(ctx context.Context) error) error {
+	b := r.telemetry
+	if b.bulkDisabled() {
+		return errBulkDeferred
+	}
+
+	r.apiMu.Lock()
+	defer r.apiMu.Unlock()
+	// Con operaciones en el diario (o IDs locales sin traducir) el lote tiene
+	// que ir detrás de ellas, así que va al diario registro por registro.
+	if (r.journal != nil && r.journal.Len() > 0) || localIDs {
+		return errBulkDeferred
+	}
+
+	start := time.Now()
+	// Un solo intento con apiMu tomado; si falla, el reintento va por send
+	err := post(client.SingleAttempt(client.WithIdempotencyKey(ctx, key)))
+	latency := time.Since(start)
+	if err != nil {
+		if client.IsBulkUnsupported(err) {
+			log.Printf("[Telemetry] El servidor no soporta envíos por lotes; se envía uno por uno: %v", err)
+			b.record(func(s *TelemetryStats) { s.BulkDisabled = true })
+		} else {
+			log.Printf("[Telemetry] Lote de %d %s falló tras %s: %v", n, kind, latency.Round(time.Millisecond), err)
+		}
+		return err
+	}
+
+	b.record(func(s *TelemetryStats) {
+		s.Batches++
+		s.Records += n
+		s.LastLatency = latency
+		s.TotalLatency += latency
+		if latency > s.MaxLatency {
+			s.MaxLatency = latency
+		}
+	})
+	log.Printf("[Telemetry] Lote de %d %s enviado en %s", n, kind, latency.Round(time.Millisecond))
+	return nil
+}
+
+// TelemetryStats devuelve las estadísticas de los envíos por lotes.
+func (r *RegisterPeriods) TelemetryStats() TelemetryStats {
+	if r.telemetry == nil {
+		return TelemetryStats{}
+	}
+	b := r.telemetry
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := b.stats
+	stats.Queued = len(b.weights) + len(b.gps)
+	return stats
+}
+
+// stopTelemetry detiene el bucle de envío y manda lo que quede.
+func (r *RegisterPeriods) stopTelemetry(ctx context.Context) error {
+	if r.telemetry == nil {
+		return nil
+	}
+	select {
+	case <-r.telemetry.stop:
+	default:
+		close(r.telemetry.stop)
+	}
+	<-r.telemetry.done
+	return r.FlushTelemetry(ctx)
+}
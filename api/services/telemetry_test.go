@@ -0,0 +1,80 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"pybot-simulator/api/backend"
+)
+
+// lostResponse deja pasar las peticiones al sustituto, pero la primera a
+// route se pierde a la vuelta: el servidor la guardó y el cliente ve un 502.
+type lostResponse struct {
+	next  http.Handler
+	route string
+
+	mu   sync.Mutex
+	lost bool
+}
+
+func (l *lostResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	lose := !l.lost && r.Method+" "+r.URL.Path == l.route
+	l.lost = l.lost || lose
+	l.mu.Unlock()
+	if !lose {
+		l.next.ServeHTTP(w, r)
+		return
+	}
+	l.next.ServeHTTP(httptest.NewRecorder(), r)
+	http.Error(w, `{"message":"respuesta perdida"}`, http.StatusBadGateway)
+}
+
+func TestFlushTelemetryDoesNotDuplicateLostBatch(t *testing.T) {
+	cases := []struct {
+		name        string
+		honoursKeys bool
+		errs        bool // FlushTelemetry avisa que el lote no se repitió
+	}{
+		// El reintento con la misma clave recibe la respuesta original
+		{"servidor con claves", true, false},
+		// Sin claves el reintento se guardaría de nuevo: no se repite
+		{"servidor sin claves", false, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := backend.NewMemoryStore()
+			var handler http.Handler = backend.NewServer(store)
+			if !tc.honoursKeys {
+				handler = ignoreKeys(handler)
+			}
+			r := newJournaledWith(t, &lostResponse{next: handler, route: "POST /sensors/weight/bulk"})
+			if err := r.CreateNewPeriod(time.Now()); err != nil {
+				t.Fatal(err)
+			}
+
+			r.telemetry = newTelemetryBatcher(50, time.Hour)
+			for _, w := range []float64{0.5, 1, 1.5} {
+				if err := r.RegisterWeigh(w); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := r.FlushTelemetry(t.Context()); (err != nil) != tc.errs {
+				t.Fatalf("FlushTelemetry: %v", err)
+			}
+
+			if n := store.Counts()["weights"]; n != 3 {
+				t.Errorf("el servidor tiene %d pesos, se esperaban 3", n)
+			}
+			if n := r.PendingOps(); n != 0 {
+				t.Errorf("quedaron %d operaciones en el diario", n)
+			}
+			if s := r.TelemetryStats(); s.Singles != 0 {
+				t.Errorf("%d registros se mandaron uno por uno", s.Singles)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrPoolClosed se devuelve al enviar trabajos a un pool que ya se cerró.
+var ErrPoolClosed = errors.New("pool cerrado")
+
+// ErrQueueFull se devuelve cuando la cola está llena (back-pressure).
+var ErrQueueFull = errors.New("cola llena")
+
+// Job es un trabajo de I/O en segundo plano. Debe respetar ctx cuando pueda:
+// se cancela si el apagado excede su tiempo límite.
+type Job func(ctx context.Context)
+
+type task struct {
+	name string
+	job  Job
+}
+
+// Metrics es una foto de los contadores del pool.
+type Metrics struct {
+	Submitted  uint64
+	Completed  uint64
+	Dropped    uint64 // rechazados por cola llena o pool cerrado
+	Overflowed uint64 // críticos que corrieron fuera de la cola por estar llena
+	Panicked   uint64
+	Queued     int
+	InFlight   int64
+	QueueSize  int
+	HighWater  int // máximo de trabajos encolados observado
+	DroppedFor map[string]uint64
+	QueuedFor  map[string]int // encolados por nombre de trabajo
+}
+
+// Pool ejecuta trabajos con un número fijo de workers y una cola acotada.
+type Pool struct {
+	name   string
+	queue  chan task
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu         sync.Mutex
+	closed     bool
+	highWater  int
+	droppedFor map[string]uint64
+	queuedFor  map[string]int
+
+	submitted atomic.Uint64
+	completed atomic.Uint64
+	dropped   atomic.Uint64
+	overflow  atomic.Uint64
+	panicked  atomic.Uint64
+	inFlight  atomic.Int64
+}
+
+// NewPool arranca workers goroutines que consumen una cola de queueSize trabajos.
+func NewPool(name string, workers, queueSize int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queueSize < 1 {
+		queueSize = 1
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Pool{
+		name:       name,
+		queue:      make(chan task, queueSize),
+		ctx:        ctx,
+		cancel:     cancel,
+		droppedFor: make(map[string]uint64),
+		queuedFor:  make(map[string]int),
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.worker()
+	}
+	return p
+}
+
+func (p *Pool) worker() {
+	defer p.wg.Done()
+	for t := range p.queue {
+		p.mu.Lock()
+		p.dequeue(t.name)
+		if p.ctx.Err() != nil {
+			// El apagado venció: lo que quede en la cola se descarta
+			p.drop(t.name)
+			p.mu.Unlock()
+			continue
+		}
+		p.mu.Unlock()
+		p.run(t)
+	}
+}
+
+func (p *Pool) run(t task) {
+	p.inFlight.Add(1)
+	defer func() {
+		p.inFlight.Add(-1)
+		if r := recover(); r != nil {
+			p.panicked.Add(1)
+			log.Printf("[Workers:%s] Pánico en trabajo %s: %v", p.name, t.name, r)
+			return
+		}
+		p.completed.Add(1)
+	}()
+	t.job(p.ctx)
+}
+
+// Submit encola un trabajo sin bloquear. Si la cola está llena o el pool ya
+// se cerró, el trabajo se descarta y se contabiliza en Dropped. Es para lo
+// que se puede perder, como una lectura de sensor que la próxima reemplaza.
+func (p *Pool) Submit(name string, job Job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.drop(name)
+		return ErrPoolClosed
+	}
+	if !p.enqueue(task{name: name, job: job}) {
+		p.drop(name)
+		return ErrQueueFull
+	}
+	return nil
+}
+
+// SubmitCritical encola un trabajo que no se puede perder. Con la cola llena
+// no se descarta: corre en una goroutine propia, que Shutdown también espera,
+// y se contabiliza en Overflowed. Solo falla si el pool ya se cerró.
+func (p *Pool) SubmitCritical(name string, job Job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		p.drop(name)
+		return ErrPoolClosed
+	}
+	t := task{name: name, job: job}
+	if p.enqueue(t) {
+		return nil
+	}
+	p.submitted.Add(1)
+	p.overflow.Add(1)
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(t)
+	}()
+	return nil
+}
+
+// enqueue se llama con mu tomado y el pool abierto. Reporta si había lugar.
+func (p *Pool) enqueue(t task) bool {
+	select {
+	case p.queue <- t:
+		p.submitted.Add(1)
+		p.queuedFor[t.name]++
+		if n := len(p.queue); n > p.highWater {
+			p.highWater = n
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// drop se llama con mu tomado.
+func (p *Pool) drop(name string) {
+	p.dropped.Add(1)
+	p.droppedFor[name]++
+}
+
+// dequeue se llama con mu tomado.
+func (p *Pool) dequeue(name string) {
+	if p.queuedFor[name]--; p.queuedFor[name] <= 0 {
+		delete(p.queuedFor, name)
+	}
+}
+
+// Metrics devuelve los contadores actuales.
+func (p *Pool) Metrics() Metrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	droppedFor := make(map[string]uint64, len(p.droppedFor))
+	for k, v := range p.droppedFor {
+		droppedFor[k] = v
+	}
+	queuedFor := make(map[string]int, len(p.queuedFor))
+	for k, v := range p.queuedFor {
+		queuedFor[k] = v
+	}
+	return Metrics{
+		Submitted:  p.submitted.Load(),
+		Completed:  p.completed.Load(),
+		Dropped:    p.dropped.Load(),
+		Overflowed: p.overflow.Load(),
+		Panicked:   p.panicked.Load(),
+		Queued:     len(p.queue),
+		InFlight:   p.inFlight.Load(),
+		QueueSize:  cap(p.queue),
+		HighWater:  p.highWater,
+		DroppedFor: droppedFor,
+		QueuedFor:  queuedFor,
+	}
+}
+
+// Shutdown deja de aceptar trabajos y espera a que se vacíe la cola y a que
+// terminen los críticos que corrieron fuera de ella.
+// Si ctx vence antes, cancela el contexto de los trabajos en curso, descarta
+// los pendientes y devuelve ctx.Err() sin esperar a los que sigan corriendo.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
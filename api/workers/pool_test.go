@@ -0,0 +1,136 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blocker es un trabajo que avisa cuando arranca y espera a que lo suelten.
+func blocker(started chan<- struct{}, release <-chan struct{}) Job {
+	return func(ctx context.Context) {
+		started <- struct{}{}
+		<-release
+	}
+}
+
+func TestSubmitDropsOnFullQueue(t *testing.T) {
+	p := NewPool("prueba", 1, 1)
+	started, release := make(chan struct{}, 4), make(chan struct{})
+	defer func() {
+		close(release)
+		p.Shutdown(context.Background())
+	}()
+
+	// Uno corre, otro ocupa el único lugar de la cola
+	if err := p.Submit("lento", blocker(started, release)); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if err := p.Submit("lento", blocker(started, release)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := p.Submit("gps", func(ctx context.Context) {}); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Submit con la cola llena: %v, se esperaba ErrQueueFull", err)
+	}
+	m := p.Metrics()
+	if m.Dropped != 1 || m.DroppedFor["gps"] != 1 || m.Queued != 1 {
+		t.Errorf("métricas %+v, se esperaba un gps descartado y uno en cola", m)
+	}
+
+	// Un trabajo crítico no se descarta: corre aparte
+	ran := make(chan struct{})
+	if err := p.SubmitCritical("conteo", func(ctx context.Context) { close(ran) }); err != nil {
+		t.Fatalf("SubmitCritical con la cola llena: %v", err)
+	}
+	select {
+	case <-ran:
+	case <-time.After(5 * time.Second):
+		t.Fatal("el trabajo crítico no corrió con la cola llena")
+	}
+	if m := p.Metrics(); m.Overflowed != 1 || m.Dropped != 1 {
+		t.Errorf("métricas %+v, se esperaba un crítico desbordado", m)
+	}
+}
+
+func TestShutdownDrainsQueue(t *testing.T) {
+	p := NewPool("prueba", 2, 20)
+	var done atomic.Int32
+	for range 20 {
+		if err := p.Submit("trabajo", func(ctx context.Context) {
+			time.Sleep(time.Millisecond)
+			done.Add(1)
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if n := done.Load(); n != 20 {
+		t.Errorf("corrieron %d de 20 trabajos antes de cerrar", n)
+	}
+
+	// Cerrado, ya no acepta nada, ni siquiera críticos
+	if err := p.Submit("trabajo", func(ctx context.Context) {}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Submit después de Shutdown: %v", err)
+	}
+	if err := p.SubmitCritical("conteo", func(ctx context.Context) {}); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("SubmitCritical después de Shutdown: %v", err)
+	}
+	if m := p.Metrics(); m.Completed != 20 || m.Dropped != 2 {
+		t.Errorf("métricas %+v", m)
+	}
+}
+
+func TestShutdownCancelsOnTimeout(t *testing.T) {
+	p := NewPool("prueba", 1, 4)
+	started := make(chan struct{})
+	canceled := make(chan error, 1)
+	if err := p.Submit("lento", func(ctx context.Context) {
+		close(started)
+		<-ctx.Done()
+		canceled <- ctx.Err()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	var ranQueued atomic.Bool
+	if err := p.Submit("encolado", func(ctx context.Context) { ranQueued.Store(true) }); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown: %v, se esperaba el vencimiento", err)
+	}
+
+	// El trabajo en curso ve su contexto cancelado
+	select {
+	case err := <-canceled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("el trabajo en curso terminó con %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("el trabajo en curso no se canceló")
+	}
+
+	// Y el que seguía en la cola se descarta sin correr
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Metrics().DroppedFor["encolado"] == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("el trabajo encolado no se descartó")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if ranQueued.Load() {
+		t.Error("el trabajo encolado corrió después del vencimiento")
+	}
+}
@@ -0,0 +1,196 @@
+// Package atlas lee los metadatos de los spritesheets: qué rectángulo de qué
+// imagen es cada frame, cuánto dura y qué clips (animaciones con nombre) hay
+// para cada estado de una entidad. Así agregar sprites o direcciones es
+// editar un JSON, no el código.
+//
+// Un archivo de atlas se ve así:
+//
+//	{
+//	  "clips": {
+//	    "walk_left": {
+//	      "image": "pybot_walk_left.png",
+//	      "state": "walk",
+//	      "heading": 180,
+//	      "grid": {"x": 0, "y": 0, "w": 75, "h": 75, "count": 4},
+//	      "frame_ms": 133,
+//	      "loop": true
+//	    }
+//	  }
+//	}
+//
+// Los frames se dan con "grid" (una fila de frames del mismo tamaño) o con
+// "frames" (una lista de rectángulos, cada uno con su "ms" opcional). Las
+// rutas de las imágenes son relativas al archivo del atlas.
+package atlas
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// defaultFrameTime es la duración de un frame si el clip no la indica.
+const defaultFrameTime = 100 * time.Millisecond
+
+// Frame es un cuadro de un clip.
+type Frame struct {
+	Rect     image.Rectangle
+	Duration time.Duration
+}
+
+// Clip es una animación con nombre.
+type Clip struct {
+	Name  string
+	Image string // ruta de la imagen, ya resuelta
+	State string // estado de la entidad que representa; vacío = el nombre
+	// Heading es la dirección de movimiento del clip en grados de pantalla
+	// (0 = derecha, 90 = abajo); nil si no depende de la dirección.
+	Heading *float64
+	Frames  []Frame
+	Loop    bool
+}
+
+// Atlas son los clips de una entidad.
+type Atlas struct {
+	Clips map[string]*Clip
+}
+
+type rawRect struct {
+	X  int `json:"x"`
+	Y  int `json:"y"`
+	W  int `json:"w"`
+	H  int `json:"h"`
+	Ms int `json:"ms"`
+}
+
+type rawGrid struct {
+	X     int `json:"x"`
+	Y     int `json:"y"`
+	W     int `json:"w"`
+	H     int `json:"h"`
+	Count int `json:"count"`
+}
+
+type rawClip struct {
+	Image   string    `json:"image"`
+	State   string    `json:"state"`
+	Heading *float64  `json:"heading"`
+	Grid    *rawGrid  `json:"grid"`
+	Frames  []rawRect `json:"frames"`
+	FrameMs int       `json:"frame_ms"`
+	Loop    bool      `json:"loop"`
+}
+
+// Load lee y valida un atlas.
+func Load(path string) (*Atlas, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el atlas %s: %w", path, err)
+	}
+	var file struct {
+		Clips map[string]rawClip `json:"clips"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("error decodificando el atlas %s: %w", path, err)
+	}
+	if len(file.Clips) == 0 {
+		return nil, fmt.Errorf("atlas %s: no tiene clips", path)
+	}
+
+	a := &Atlas{Clips: make(map[string]*Clip, len(file.Clips))}
+	dir := filepath.Dir(path)
+	for name, rc := range file.Clips {
+		clip, err := rc.clip(name, dir)
+		if err != nil {
+			return nil, fmt.Errorf("atlas %s: clip %s: %w", path, name, err)
+		}
+		a.Clips[name] = clip
+	}
+	return a, nil
+}
+
+func (rc rawClip) clip(name, dir string) (*Clip, error) {
+	if rc.Image == "" {
+		return nil, fmt.Errorf("falta la imagen")
+	}
+	frameTime := defaultFrameTime
+	if rc.FrameMs > 0 {
+		frameTime = time.Duration(rc.FrameMs) * time.Millisecond
+	}
+	c := &Clip{
+		Name:    name,
+		Image:   filepath.Join(dir, rc.Image),
+		State:   rc.State,
+		Heading: rc.Heading,
+		Loop:    rc.Loop,
+	}
+	if c.State == "" {
+		c.State = name
+	}
+
+	rects := rc.Frames
+	if rc.Grid != nil {
+		if len(rects) > 0 {
+			return nil, fmt.Errorf("usa grid o frames, no los dos")
+		}
+		g := rc.Grid
+		for i := 0; i < g.Count; i++ {
+			rects = append(rects, rawRect{X: g.X + i*g.W, Y: g.Y, W: g.W, H: g.H})
+		}
+	}
+	if len(rects) == 0 {
+		return nil, fmt.Errorf("no tiene frames")
+	}
+	for i, r := range rects {
+		if r.W <= 0 || r.H <= 0 || r.X < 0 || r.Y < 0 {
+			return nil, fmt.Errorf("frame %d: rectángulo inválido", i)
+		}
+		d := frameTime
+		if r.Ms > 0 {
+			d = time.Duration(r.Ms) * time.Millisecond
+		}
+		c.Frames = append(c.Frames, Frame{Rect: image.Rect(r.X, r.Y, r.X+r.W, r.Y+r.H), Duration: d})
+	}
+	return c, nil
+}
+
+// Images devuelve las rutas de las imágenes que usan los clips, sin repetir.
+func (a *Atlas) Images() []string {
+	seen := map[string]bool{}
+	var paths []string
+	for _, c := range a.Clips {
+		if !seen[c.Image] {
+			seen[c.Image] = true
+			paths = append(paths, c.Image)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// ClipFor elige el clip de state cuya dirección está más cerca de heading
+// (en grados de pantalla). Los clips sin dirección sirven para cualquiera.
+// Devuelve nil si no hay clips para ese estado.
+func (a *Atlas) ClipFor(state string, heading float64) *Clip {
+	var best *Clip
+	bestDiff := math.Inf(1)
+	for _, c := range a.Clips {
+		if c.State != state {
+			continue
+		}
+		diff := 360.0 // los clips con dirección le ganan a los que no tienen
+		if c.Heading != nil {
+			diff = math.Abs(math.Remainder(heading-*c.Heading, 360))
+		}
+		// Empates por nombre, para que la elección no dependa del mapa
+		if diff < bestDiff || diff == bestDiff && c.Name < best.Name {
+			best, bestDiff = c, diff
+		}
+	}
+	return best
+}
@@ -0,0 +1,120 @@
+package atlas
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func heading(deg float64) *float64 { return &deg }
+
+func walkAtlas() *Atlas {
+	a := &Atlas{Clips: map[string]*Clip{}}
+	for _, c := range []*Clip{
+		{Name: "walk_right", State: "walk", Heading: heading(0)},
+		{Name: "walk_down", State: "walk", Heading: heading(90)},
+		{Name: "walk_left", State: "walk", Heading: heading(180)},
+		{Name: "walk_up", State: "walk", Heading: heading(270)},
+		{Name: "idle", State: "idle"},
+		{Name: "idle_left", State: "idle", Heading: heading(180)},
+		{Name: "charge", State: "charge"},
+	} {
+		a.Clips[c.Name] = c
+	}
+	return a
+}
+
+func TestClipFor(t *testing.T) {
+	a := walkAtlas()
+	cases := []struct {
+		state   string
+		heading float64
+		want    string
+	}{
+		{"walk", 10, "walk_right"},
+		{"walk", 100, "walk_down"},
+		{"walk", -80, "walk_up"},
+		// La diferencia da la vuelta: 350 está a 10 de la derecha
+		{"walk", 350, "walk_right"},
+		{"walk", 530, "walk_left"},
+		// A mitad de camino gana el nombre menor, siempre el mismo
+		{"walk", 45, "walk_down"},
+		{"walk", 135, "walk_down"},
+		{"walk", 225, "walk_left"},
+		// Un clip con dirección le gana a uno sin dirección, aunque esté lejos
+		{"idle", 0, "idle_left"},
+		// Sin clips con dirección sirve el que no tiene
+		{"charge", 90, "charge"},
+		{"jump", 0, ""},
+	}
+	for _, tc := range cases {
+		got := a.ClipFor(tc.state, tc.heading)
+		name := ""
+		if got != nil {
+			name = got.Name
+		}
+		if name != tc.want {
+			t.Errorf("ClipFor(%q, %g) = %q, se esperaba %q", tc.state, tc.heading, name, tc.want)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "robot.json")
+	raw := `{"clips": {
+		"walk_left": {"image": "img/walk.png", "state": "walk", "heading": 180,
+			"grid": {"x": 10, "y": 5, "w": 20, "h": 30, "count": 3}, "frame_ms": 50, "loop": true},
+		"idle": {"image": "img/idle.png",
+			"frames": [{"x": 0, "y": 0, "w": 8, "h": 8}, {"x": 8, "y": 0, "w": 8, "h": 8, "ms": 400}]}
+	}}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	a, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	walk := a.Clips["walk_left"]
+	if walk.Image != filepath.Join(dir, "img", "walk.png") || !walk.Loop || walk.Heading == nil || *walk.Heading != 180 {
+		t.Errorf("walk_left = %+v", walk)
+	}
+	if len(walk.Frames) != 3 || walk.Frames[2].Rect.Min.X != 50 || walk.Frames[2].Rect.Dy() != 30 || walk.Frames[0].Duration != 50*time.Millisecond {
+		t.Errorf("frames de walk_left = %+v", walk.Frames)
+	}
+
+	idle := a.Clips["idle"]
+	if idle.State != "idle" || idle.Heading != nil || idle.Loop {
+		t.Errorf("idle = %+v", idle)
+	}
+	if idle.Frames[0].Duration != defaultFrameTime || idle.Frames[1].Duration != 400*time.Millisecond {
+		t.Errorf("duraciones de idle = %s, %s", idle.Frames[0].Duration, idle.Frames[1].Duration)
+	}
+	if images := a.Images(); len(images) != 2 || images[0] != idle.Image {
+		t.Errorf("Images = %v", images)
+	}
+}
+
+func TestLoadRejects(t *testing.T) {
+	cases := []struct {
+		name, clips, error string
+	}{
+		{"sin clips", `{}`, "no tiene clips"},
+		{"sin imagen", `{"a": {"frames": [{"w": 1, "h": 1}]}}`, "falta la imagen"},
+		{"sin frames", `{"a": {"image": "a.png"}}`, "no tiene frames"},
+		{"grid y frames", `{"a": {"image": "a.png", "grid": {"w": 1, "h": 1, "count": 1}, "frames": [{"w": 1, "h": 1}]}}`, "no los dos"},
+		{"rectángulo vacío", `{"a": {"image": "a.png", "frames": [{"w": 0, "h": 1}]}}`, "inválido"},
+	}
+	for _, tc := range cases {
+		path := filepath.Join(t.TempDir(), "atlas.json")
+		if err := os.WriteFile(path, []byte(`{"clips": `+tc.clips+`}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), tc.error) {
+			t.Errorf("%s: error %v, se esperaba uno con %q", tc.name, err, tc.error)
+		}
+	}
+}
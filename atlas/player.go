@@ -0,0 +1,45 @@
+package atlas
+
+import "time"
+
+// Player reproduce un clip: lleva el frame actual según el tiempo que pasa.
+type Player struct {
+	clip    *Clip
+	frame   int
+	elapsed time.Duration // dentro del frame actual
+}
+
+// Play cambia al clip c desde su primer frame. Si c ya se está
+// reproduciendo, sigue donde iba.
+func (p *Player) Play(c *Clip) {
+	if c == p.clip {
+		return
+	}
+	p.clip, p.frame, p.elapsed = c, 0, 0
+}
+
+// Clip devuelve el clip actual (nil si no hay).
+func (p *Player) Clip() *Clip {
+	return p.clip
+}
+
+// Advance avanza la animación dt. Un clip sin loop se queda en su último frame.
+func (p *Player) Advance(dt time.Duration) {
+	if p.clip == nil || len(p.clip.Frames) < 2 {
+		return
+	}
+	p.elapsed += dt
+	for p.elapsed >= p.clip.Frames[p.frame].Duration {
+		if p.frame == len(p.clip.Frames)-1 && !p.clip.Loop {
+			p.elapsed = 0
+			return
+		}
+		p.elapsed -= p.clip.Frames[p.frame].Duration
+		p.frame = (p.frame + 1) % len(p.clip.Frames)
+	}
+}
+
+// Frame devuelve el índice del frame actual.
+func (p *Player) Frame() int {
+	return p.frame
+}
@@ -0,0 +1,91 @@
+package atlas
+
+import (
+	"testing"
+	"time"
+)
+
+// clipOf arma un clip con frames de las duraciones dadas, en milisegundos.
+func clipOf(loop bool, ms ...int) *Clip {
+	c := &Clip{Name: "clip", Loop: loop}
+	for _, d := range ms {
+		c.Frames = append(c.Frames, Frame{Duration: time.Duration(d) * time.Millisecond})
+	}
+	return c
+}
+
+func TestAdvanceLoops(t *testing.T) {
+	var p Player
+	p.Play(clipOf(true, 100, 100, 100))
+	steps := []struct {
+		dt    time.Duration
+		frame int
+	}{
+		{99 * time.Millisecond, 0},
+		{time.Millisecond, 1},
+		{150 * time.Millisecond, 2},
+		{50 * time.Millisecond, 0}, // vuelve a empezar
+		// Un paso largo salta varios frames y conserva el resto
+		{250 * time.Millisecond, 2},
+		{50 * time.Millisecond, 0},
+	}
+	for i, s := range steps {
+		p.Advance(s.dt)
+		if p.Frame() != s.frame {
+			t.Fatalf("paso %d: frame %d, se esperaba %d", i, p.Frame(), s.frame)
+		}
+	}
+}
+
+func TestAdvanceHoldsLastFrame(t *testing.T) {
+	var p Player
+	p.Play(clipOf(false, 100, 200))
+	p.Advance(100 * time.Millisecond)
+	if p.Frame() != 1 {
+		t.Fatalf("frame %d, se esperaba 1", p.Frame())
+	}
+	for range 10 {
+		p.Advance(time.Second)
+		if p.Frame() != 1 {
+			t.Fatalf("sin loop pasó al frame %d", p.Frame())
+		}
+	}
+}
+
+func TestAdvanceFrameDurations(t *testing.T) {
+	var p Player
+	p.Play(clipOf(true, 50, 300))
+	p.Advance(60 * time.Millisecond)
+	p.Advance(250 * time.Millisecond)
+	// 310ms: 50 en el primero y 260 de los 300 del segundo
+	if p.Frame() != 1 {
+		t.Errorf("frame %d, se esperaba 1", p.Frame())
+	}
+	p.Advance(40 * time.Millisecond)
+	if p.Frame() != 0 {
+		t.Errorf("frame %d, se esperaba 0", p.Frame())
+	}
+}
+
+func TestPlay(t *testing.T) {
+	var p Player
+	p.Advance(time.Second) // sin clip no hace nada
+	walk, idle := clipOf(true, 100, 100), clipOf(true, 100)
+
+	p.Play(walk)
+	p.Advance(100 * time.Millisecond)
+	// Volver a pedir el mismo clip no lo reinicia
+	p.Play(walk)
+	if p.Frame() != 1 || p.Clip() != walk {
+		t.Errorf("Play del mismo clip lo reinició: frame %d", p.Frame())
+	}
+	p.Play(idle)
+	if p.Frame() != 0 || p.Clip() != idle {
+		t.Errorf("Play de otro clip no empezó desde el primer frame: frame %d", p.Frame())
+	}
+	// Un clip de un solo frame no avanza
+	p.Advance(time.Hour)
+	if p.Frame() != 0 {
+		t.Errorf("un clip de un frame pasó al frame %d", p.Frame())
+	}
+}
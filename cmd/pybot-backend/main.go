@@ -0,0 +1,99 @@
+// Command pybot-backend corre el sustituto local de la API de pybot.
+//
+//	go run ./cmd/pybot-backend -addr 127.0.0.1:8080 -data pybot-backend.json
+//
+// Después se apunta el simulador con -api-url http://127.0.0.1:8080.
+//
+// Para probar autenticación y TLS:
+//
+//	go run ./cmd/pybot-backend -token secreto -tls-cert server.pem -tls-key server-key.pem -client-ca ca.pem
+//
+// y en el simulador -api-url https://127.0.0.1:8080 -api-token secreto
+// -tls-ca-file ca.pem -tls-cert-file client.pem -tls-key-file client-key.pem.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"pybot-simulator/api/backend"
+	"pybot-simulator/api/client"
+)
+
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8080", "dirección donde escuchar")
+	data := flag.String("data", "", "archivo JSON donde persistir los datos (vacío = solo memoria)")
+	token := flag.String("token", "", "token bearer exigido (vacío = sin token)")
+	apiKey := flag.String("api-key", "", "API key exigida (vacío = sin API key)")
+	apiKeyHeader := flag.String("api-key-header", client.DefaultAPIKeyHeader, "cabecera de la API key")
+	tlsCert := flag.String("tls-cert", "", "certificado del servidor (PEM); activa https")
+	tlsKey := flag.String("tls-key", "", "clave del certificado del servidor (PEM)")
+	clientCA := flag.String("client-ca", "", "CA (PEM) para exigir certificado de cliente")
+	flag.Parse()
+
+	store := backend.NewMemoryStore()
+	if *data != "" {
+		var err error
+		store, err = backend.OpenFileStore(*data)
+		if err != nil {
+			log.Fatalf("[Backend] %v", err)
+		}
+	}
+
+	tlsConfig, err := serverTLS(*tlsCert, *tlsKey, *clientCA)
+	if err != nil {
+		log.Fatalf("[Backend] %v", err)
+	}
+	auth := backend.WithAuth(client.Auth{Token: *token, APIKey: *apiKey, APIKeyHeader: *apiKeyHeader})
+	running, err := backend.StartTLS(*addr, store, tlsConfig, auth)
+	if err != nil {
+		log.Fatalf("[Backend] %v", err)
+	}
+	log.Printf("[Backend] Escuchando en %s", running.URL)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := running.Close(ctx); err != nil {
+		log.Printf("[Backend] Error cerrando: %v", err)
+	}
+}
+
+// serverTLS arma el TLS del servidor; nil si no se pidió https.
+func serverTLS(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		if clientCAFile != "" {
+			return nil, fmt.Errorf("-client-ca necesita -tls-cert y -tls-key")
+		}
+		return nil, nil
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("certificado del servidor: %w", err)
+	}
+	cfg := &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("-client-ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("-client-ca: %s no tiene certificados PEM", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
@@ -0,0 +1,263 @@
+// Command pybot-periods inspecciona y repara los períodos de trabajo de un
+// prototipo sin abrir el simulador. Usa la misma configuración (archivo,
+// .env, variables de entorno y flags), el mismo diario offline y el mismo
+// estado del período que el juego, así que no conviene correrlo mientras el
+// juego está abierto.
+//
+//	pybot-periods status                  estado local y último período del backend
+//	pybot-periods open                    abre un período nuevo
+//	pybot-periods close                   cierra el período abierto
+//	pybot-periods readings <id>           lectura global de un período
+//
+// No hay un comando para las recolecciones de un período: la API real no
+// tiene una ruta para leerlas (solo POST /sensors/waste y PATCH /sensors/),
+// y los conteos no quedan en ningún otro lado. Hace falta que el backend
+// agregue algo como GET /sensors/waste?periodId= para poder ofrecerlo.
+//
+// Con -json la salida es JSON. El resto de los flags son los del simulador,
+// por ejemplo:
+//
+//	go run ./cmd/pybot-periods status -json -api-url http://127.0.0.1:8080
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"pybot-simulator/api/client"
+	"pybot-simulator/api/services"
+	"pybot-simulator/config"
+)
+
+const usage = `uso: pybot-periods <comando> [id] [-json] [flags del simulador]
+
+comandos:
+  status          estado local y último período del backend
+  open            abre un período nuevo
+  close           cierra el período abierto
+  readings <id>   lectura global de un período
+`
+
+// commandTimeout limita cada comando, incluido el envío del diario.
+const commandTimeout = 30 * time.Second
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "pybot-periods: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// invocation es una línea de comandos ya separada.
+type invocation struct {
+	command string
+	id      int64
+	asJSON  bool
+	flags   []string // para config.Load
+}
+
+func parseArgs(args []string) (invocation, error) {
+	var inv invocation
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return inv, errors.New("falta el comando\n" + usage)
+	}
+	inv.command, args = args[0], args[1:]
+
+	switch inv.command {
+	case "status", "open", "close":
+	case "readings":
+		if len(args) == 0 {
+			return inv, fmt.Errorf("%s necesita el ID del período", inv.command)
+		}
+		// Un ID negativo es el local de un período creado sin conexión
+		id, err := strconv.ParseInt(args[0], 10, 64)
+		if err != nil && strings.HasPrefix(args[0], "-") {
+			return inv, fmt.Errorf("%s necesita el ID del período", inv.command)
+		}
+		if err != nil {
+			return inv, fmt.Errorf("ID de período inválido: %q", args[0])
+		}
+		inv.id, args = id, args[1:]
+	default:
+		return inv, fmt.Errorf("comando desconocido %q\n%s", inv.command, usage)
+	}
+
+	for _, arg := range args {
+		switch arg {
+		case "-json", "--json":
+			inv.asJSON = true
+		default:
+			inv.flags = append(inv.flags, arg)
+		}
+	}
+	return inv, nil
+}
+
+func run(args []string, out io.Writer) error {
+	inv, err := parseArgs(args)
+	if err != nil {
+		return err
+	}
+	settings, err := config.Load(inv.flags)
+	if err != nil {
+		return err
+	}
+	// El CLI no necesita juntar telemetría en lotes
+	settings.TelemetryBatch = false
+
+	r, err := services.NewRegisterPeriods(settings)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	// Lo que quedó pendiente de otra sesión va primero, para no desordenar
+	if r.PendingOps() > 0 {
+		if err := r.Flush(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "pybot-periods: aviso: %v\n", err)
+		}
+	}
+
+	var result interface{}
+	switch inv.command {
+	case "status":
+		result, err = status(ctx, r)
+	case "open":
+		result, err = open(r)
+	case "close":
+		result, err = closePeriod(ctx, r)
+	case "readings":
+		result, err = r.Readings(ctx, inv.id)
+	}
+	if err != nil {
+		return err
+	}
+
+	if inv.asJSON {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(result)
+	}
+	return printHuman(out, result)
+}
+
+// statusResult es la salida de status.
+type statusResult struct {
+	Local      services.PeriodRecord `json:"local"`
+	Backend    *client.LastPeriod    `json:"backend,omitempty"`
+	BackendErr string                `json:"backend_error,omitempty"`
+	PendingOps int                   `json:"pending_ops"`
+}
+
+func status(ctx context.Context, r *services.RegisterPeriods) (statusResult, error) {
+	res := statusResult{Local: r.PeriodStatus(), PendingOps: r.PendingOps()}
+	last, err := r.LastBackendPeriod(ctx)
+	if err != nil {
+		res.BackendErr = err.Error()
+	} else {
+		res.Backend = &last
+	}
+	return res, nil
+}
+
+// changeResult es la salida de open y close.
+type changeResult struct {
+	Action     string                `json:"action"`
+	Period     services.PeriodRecord `json:"period"`
+	PendingOps int                   `json:"pending_ops"` // operaciones que quedaron en el diario
+}
+
+// open abre un período como lo hace el juego al arrancar: con la lectura
+// vacía y las recolecciones de PET y latas.
+func open(r *services.RegisterPeriods) (changeResult, error) {
+	// Si el estado local no tiene período pero el backend sí, se adopta
+	if _, err := r.StatusPeriod(); err != nil {
+		return changeResult{}, err
+	}
+	if rec := r.PeriodStatus(); rec.State == services.PeriodOpen || rec.State == services.PeriodClosing {
+		return changeResult{}, fmt.Errorf("ya hay un período %s (ID %d); ciérralo primero", rec.State, rec.PeriodID)
+	}
+	if err := r.CreateNewPeriod(time.Now()); err != nil {
+		return changeResult{}, err
+	}
+	if err := r.CreateVoidReading(); err != nil {
+		return changeResult{}, err
+	}
+	for _, wasteID := range []int64{client.WastePET, client.WasteCans} {
+		if err := r.CreateWasteCollection(wasteID); err != nil {
+			return changeResult{}, err
+		}
+	}
+	return changeResult{Action: "open", Period: r.PeriodStatus(), PendingOps: r.PendingOps()}, nil
+}
+
+func closePeriod(ctx context.Context, r *services.RegisterPeriods) (changeResult, error) {
+	// Si el estado local no tiene período pero el backend sí, se adopta
+	if _, err := r.StatusPeriod(); err != nil {
+		return changeResult{}, err
+	}
+	if rec := r.PeriodStatus(); rec.State != services.PeriodOpen && rec.State != services.PeriodClosing {
+		return changeResult{}, errors.New("no hay un período abierto que cerrar")
+	}
+	if err := r.CloseCurrentPeriod(ctx, time.Now()); err != nil {
+		return changeResult{}, err
+	}
+	return changeResult{Action: "close", Period: r.PeriodStatus(), PendingOps: r.PendingOps()}, nil
+}
+
+// printHuman imprime result como tabla legible.
+func printHuman(out io.Writer, result interface{}) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	switch res := result.(type) {
+	case statusResult:
+		printPeriod(w, "Local", res.Local)
+		if res.Backend != nil {
+			if res.Backend.PeriodID == 0 {
+				fmt.Fprintln(w, "Backend:\tsin período abierto")
+			} else {
+				fmt.Fprintf(w, "Backend:\tperíodo %d abierto (último dato %s)\n", res.Backend.PeriodID, res.Backend.LastHour)
+			}
+		} else {
+			fmt.Fprintf(w, "Backend:\tno disponible (%s)\n", res.BackendErr)
+		}
+		fmt.Fprintf(w, "Diario:\t%d operaciones pendientes\n", res.PendingOps)
+	case changeResult:
+		printPeriod(w, "Período", res.Period)
+		if res.PendingOps > 0 {
+			fmt.Fprintf(w, "Diario:\t%d operaciones pendientes (sin conexión, se enviarán después)\n", res.PendingOps)
+		}
+	case client.Reading:
+		fmt.Fprintf(w, "Período:\t%d\n", res.PeriodID)
+		fmt.Fprintf(w, "Distancia:\t%.2f m\n", res.DistanceTraveled)
+		fmt.Fprintf(w, "Peso:\t%.4f\n", res.WeightWaste)
+	}
+	return w.Flush()
+}
+
+func printPeriod(w io.Writer, label string, rec services.PeriodRecord) {
+	if rec.State == services.PeriodNone {
+		fmt.Fprintf(w, "%s:\tsin período\n", label)
+		return
+	}
+	fmt.Fprintf(w, "%s:\tperíodo %d (%s)\n", label, rec.PeriodID, rec.State)
+	if rec.StartHour != "" {
+		fmt.Fprintf(w, "  Inicio:\t%s\n", rec.StartHour)
+	}
+	if rec.EndHour != "" {
+		fmt.Fprintf(w, "  Fin:\t%s\n", rec.EndHour)
+	}
+	if rec.State == services.PeriodClosing {
+		fmt.Fprintf(w, "  Cierre:\tcierre enviado=%t, lectura enviada=%t\n", rec.CloseSent, rec.ReadingSent)
+	}
+}
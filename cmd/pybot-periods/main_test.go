@@ -0,0 +1,52 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	cases := []struct {
+		name  string
+		args  []string
+		want  invocation
+		error string // fragmento del error esperado, o "" si no falla
+	}{
+		{"status", []string{"status"}, invocation{command: "status"}, ""},
+		{"json", []string{"open", "-json"}, invocation{command: "open", asJSON: true}, ""},
+		{"json con dos guiones", []string{"close", "--json"}, invocation{command: "close", asJSON: true}, ""},
+		{"readings", []string{"readings", "42"}, invocation{command: "readings", id: 42}, ""},
+		{"ID local", []string{"readings", "-3"}, invocation{command: "readings", id: -3}, ""},
+		{
+			"flags del simulador",
+			[]string{"readings", "7", "-api-url", "http://127.0.0.1:8080", "-json"},
+			invocation{command: "readings", id: 7, asJSON: true, flags: []string{"-api-url", "http://127.0.0.1:8080"}},
+			"",
+		},
+		{"sin argumentos", nil, invocation{}, "falta el comando"},
+		{"flag primero", []string{"-json", "status"}, invocation{}, "falta el comando"},
+		{"desconocido", []string{"reopen"}, invocation{}, "comando desconocido"},
+		{"waste ya no existe", []string{"waste", "1"}, invocation{}, "comando desconocido"},
+		{"readings sin ID", []string{"readings"}, invocation{}, "necesita el ID"},
+		{"readings con flag", []string{"readings", "-json"}, invocation{}, "necesita el ID"},
+		{"ID inválido", []string{"readings", "uno"}, invocation{}, "inválido"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseArgs(tc.args)
+			if tc.error != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.error) {
+					t.Fatalf("error %v, se esperaba uno con %q", err, tc.error)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.command != tc.want.command || got.id != tc.want.id || got.asJSON != tc.want.asJSON || !slices.Equal(got.flags, tc.want.flags) {
+				t.Errorf("parseArgs(%q) = %+v, se esperaba %+v", tc.args, got, tc.want)
+			}
+		})
+	}
+}
@@ -10,7 +10,25 @@ const (
 	CanSize    = 24
 	GridMargin = 60
 
+	// Huella del robot para chocar con paredes y muebles del mapa; más chica
+	// que el sprite para que quepa por los pasillos de un tile
+	RobotBodySize = 24
+
+	// Campo de visión de la cámara: alcance en píxeles y apertura en grados
+	CameraRange = 200
+	CameraFOV   = 70
+
+	// Escala del mundo: 1 píxel = 0.1 m (misma escala que usa el GPS)
+	MetersPerPixel = 0.1
+
 	ScreenWidth  = 978
 	ScreenHeight = 640
+
+	// Tamaño mínimo de la ventana: el panel de controles (200x272 abajo a la
+	// izquierda, a 20 px de los bordes) no llega a las líneas de información
+	// de arriba (hasta y≈106) ni a las estadísticas de depuración, que ocupan
+	// los últimos 366 px de la derecha.
+	MinScreenWidth  = 600
+	MinScreenHeight = 420
 )
 
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig arma la configuración TLS compartida por la API (https) y el
+// broker (amqps): CA propia, certificado de cliente y pines de certificado.
+// Devuelve nil si no hay nada que personalizar.
+func (s *Settings) TLSConfig() (*tls.Config, error) {
+	if s.TLSCAFile == "" && s.TLSCertFile == "" && s.TLSKeyFile == "" && s.TLSPins == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if s.TLSCAFile != "" {
+		pem, err := os.ReadFile(s.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls_ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("tls_ca_file: %s no tiene certificados PEM", s.TLSCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+
+	if s.TLSCertFile != "" || s.TLSKeyFile != "" {
+		if s.TLSCertFile == "" || s.TLSKeyFile == "" {
+			return nil, fmt.Errorf("tls_cert_file y tls_key_file van juntos")
+		}
+		cert, err := tls.LoadX509KeyPair(s.TLSCertFile, s.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("certificado de cliente: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	if s.TLSPins != "" {
+		pins, err := parsePins(s.TLSPins)
+		if err != nil {
+			return nil, err
+		}
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			for _, cert := range cs.PeerCertificates {
+				if pins[SPKIPin(cert)] {
+					return nil
+				}
+			}
+			return fmt.Errorf("ningún certificado del servidor coincide con tls_pins")
+		}
+	}
+	return cfg, nil
+}
+
+// SPKIPin es el pin de un certificado: SHA-256 de su clave pública (SPKI) en
+// base64, el mismo formato que "pin-sha256" de HPKP. Se obtiene con:
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der |
+//	  openssl dgst -sha256 -binary | base64
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func parsePins(list string) (map[string]bool, error) {
+	pins := map[string]bool{}
+	for _, pin := range strings.Split(list, ",") {
+		pin = strings.TrimPrefix(strings.TrimSpace(pin), "sha256/")
+		if pin == "" {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(pin)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("tls_pins: pin inválido %q (SHA-256 en base64)", pin)
+		}
+		pins[pin] = true
+	}
+	return pins, nil
+}
+
+// credentialKeys son los ajustes que puede dar el archivo de credenciales.
+var credentialKeys = map[string]bool{
+	"api_token":     true,
+	"api_key":       true,
+	"rabbitmq_url":  true,
+	"tls_cert_file": true,
+	"tls_key_file":  true,
+}
+
+// applyCredentials carga las credenciales del prototipo desde el archivo
+// credentials_file:
+//
+//	{"<prototype_id>": {"api_token": "...", "rabbitmq_url": "amqps://...", ...}}
+//
+// Sobrescriben a los valores por defecto y al archivo de configuración, pero
+// no a lo que venga de .env, variables de entorno o flags.
+func (s *Settings) applyCredentials() error {
+	if s.CredentialsFile == "" {
+		return nil
+	}
+	raw, err := os.ReadFile(s.CredentialsFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("credentials_file %s no existe", s.CredentialsFile)
+	}
+	if err != nil {
+		return fmt.Errorf("error leyendo %s: %w", s.CredentialsFile, err)
+	}
+	var all map[string]map[string]string
+	if err := json.Unmarshal(raw, &all); err != nil {
+		return fmt.Errorf("error decodificando %s: %w", s.CredentialsFile, err)
+	}
+	creds, ok := all[s.PrototypeID]
+	if !ok {
+		return nil
+	}
+
+	values := map[string]string{}
+	for key, v := range creds {
+		if !credentialKeys[key] {
+			return fmt.Errorf("%s: %q no es una credencial (%s)", s.CredentialsFile, key, "api_token, api_key, rabbitmq_url, tls_cert_file, tls_key_file")
+		}
+		if src := s.sources[key]; src == "" || strings.HasPrefix(src, "file:") {
+			values[key] = v
+		}
+	}
+	return s.apply(values, "credentials:"+s.PrototypeID)
+}
@@ -0,0 +1,300 @@
+package config
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPKI es una CA de prueba con un certificado de servidor para 127.0.0.1
+// y uno de cliente, escritos en PEM en un directorio temporal.
+type testPKI struct {
+	dir        string
+	caPool     *x509.CertPool
+	serverCert tls.Certificate
+	server     *x509.Certificate
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	p := &testPKI{dir: t.TempDir(), caPool: x509.NewCertPool()}
+
+	caKey := newKey(t)
+	caTmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "pybot test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTmpl, caTmpl, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.caPool.AddCert(ca)
+	p.write(t, "ca.pem", "CERTIFICATE", caDER)
+
+	issue := func(serial int64, name string, usage x509.ExtKeyUsage) (tls.Certificate, *x509.Certificate) {
+		key := newKey(t)
+		tmpl := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: name},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, ca, &key.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.write(t, name+".pem", "CERTIFICATE", der)
+		p.write(t, name+"-key.pem", "EC PRIVATE KEY", keyDER)
+		leaf, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, leaf
+	}
+	p.serverCert, p.server = issue(2, "server", x509.ExtKeyUsageServerAuth)
+	issue(3, "client", x509.ExtKeyUsageClientAuth)
+	return p
+}
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func (p *testPKI) write(t *testing.T, name, kind string, der []byte) {
+	t.Helper()
+	data := pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der})
+	if err := os.WriteFile(p.path(name), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (p *testPKI) path(name string) string { return filepath.Join(p.dir, name) }
+
+// serve arranca un servidor https con el certificado de la PKI. Con
+// clientAuth exige un certificado de cliente firmado por la CA.
+func (p *testPKI) serve(t *testing.T, clientAuth bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{p.serverCert}}
+	if clientAuth {
+		srv.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+		srv.TLS.ClientCAs = p.caPool
+	}
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // los handshakes rechazados son esperados
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// get hace un GET a url con cfg y devuelve el error de la conexión.
+func get(cfg *tls.Config, url string) error {
+	c := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: cfg},
+		Timeout:   5 * time.Second,
+	}
+	resp, err := c.Get(url)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func TestTLSConfigEmpty(t *testing.T) {
+	cfg, err := Defaults().TLSConfig()
+	if err != nil || cfg != nil {
+		t.Errorf("sin ajustes TLS: %v, %v; se esperaba nil", cfg, err)
+	}
+}
+
+func TestTLSConfigCustomCA(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := pki.serve(t, false)
+
+	// Sin la CA propia el certificado del servidor no es de confianza
+	if err := get(nil, srv.URL); err == nil {
+		t.Error("se aceptó el servidor sin la CA propia")
+	}
+
+	s := Defaults()
+	s.TLSCAFile = pki.path("ca.pem")
+	cfg, err := s.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(cfg, srv.URL); err != nil {
+		t.Errorf("con la CA propia: %v", err)
+	}
+
+	// Un archivo sin certificados es un error de configuración
+	s.TLSCAFile = pki.path("server-key.pem")
+	if _, err := s.TLSConfig(); err == nil {
+		t.Error("se aceptó una CA sin certificados")
+	}
+}
+
+func TestTLSConfigClientCertificate(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := pki.serve(t, true)
+
+	s := Defaults()
+	s.TLSCAFile = pki.path("ca.pem")
+	cfg, err := s.TLSConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := get(cfg, srv.URL); err == nil {
+		t.Error("el servidor aceptó una conexión sin certificado de cliente")
+	}
+
+	s.TLSCertFile, s.TLSKeyFile = pki.path("client.pem"), pki.path("client-key.pem")
+	if cfg, err = s.TLSConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(cfg, srv.URL); err != nil {
+		t.Errorf("con certificado de cliente: %v", err)
+	}
+
+	// El certificado y la clave van juntos
+	s.TLSKeyFile = ""
+	if _, err := s.TLSConfig(); err == nil {
+		t.Error("se aceptó un certificado de cliente sin clave")
+	}
+}
+
+func TestTLSConfigPins(t *testing.T) {
+	pki := newTestPKI(t)
+	srv := pki.serve(t, false)
+	other := SPKIPin(&x509.Certificate{RawSubjectPublicKeyInfo: []byte("otra clave")})
+
+	cases := []struct {
+		name string
+		pins string
+		ok   bool
+	}{
+		{"coincide", SPKIPin(pki.server), true},
+		{"coincide con prefijo y entre otros", other + ", sha256/" + SPKIPin(pki.server), true},
+		{"no coincide", other, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := Defaults()
+			s.TLSCAFile = pki.path("ca.pem")
+			s.TLSPins = tc.pins
+			cfg, err := s.TLSConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = get(cfg, srv.URL)
+			if tc.ok && err != nil {
+				t.Errorf("con el pin correcto: %v", err)
+			}
+			if !tc.ok && (err == nil || !strings.Contains(err.Error(), "tls_pins")) {
+				t.Errorf("error %v, se esperaba el rechazo por tls_pins", err)
+			}
+		})
+	}
+
+	for _, pins := range []string{"no-es-base64!", "c2hvcnQ="} {
+		s := Defaults()
+		s.TLSPins = pins
+		if _, err := s.TLSConfig(); err == nil {
+			t.Errorf("se aceptó el pin inválido %q", pins)
+		}
+	}
+}
+
+func TestApplyCredentialsPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir) // sin pybot.json ni .env del repo
+
+	configFile := filepath.Join(dir, "pybot.json")
+	writeJSON(t, configFile, `{"api_token": "del-archivo", "api_key": "del-archivo", "rabbitmq_url": "amqp://archivo/"}`)
+	credentials := filepath.Join(dir, "credentials.json")
+	writeJSON(t, credentials, `{
+		"proto-1": {"api_token": "credencial", "api_key": "credencial", "rabbitmq_url": "amqps://credencial/"},
+		"proto-2": {"api_token": "de-otro"}
+	}`)
+	t.Setenv("PYBOT_API_KEY", "del-entorno")
+
+	s, err := Load([]string{"-config", configFile, "-credentials-file", credentials,
+		"-prototype-id", "proto-1", "-rabbitmq-url", "amqp://flag/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// La credencial pisa al archivo de configuración, pero no al entorno ni a las flags
+	if s.APIToken != "credencial" {
+		t.Errorf("api_token = %q, se esperaba el de las credenciales", s.APIToken)
+	}
+	if s.APIKey != "del-entorno" {
+		t.Errorf("api_key = %q, se esperaba el del entorno", s.APIKey)
+	}
+	if s.RabbitMQURL != "amqp://flag/" {
+		t.Errorf("rabbitmq_url = %q, se esperaba el de la flag", s.RabbitMQURL)
+	}
+	if got := s.sources["api_token"]; got != "credentials:proto-1" {
+		t.Errorf("origen de api_token %q", got)
+	}
+
+	// Un prototipo sin credenciales se queda con lo demás
+	s, err = Load([]string{"-config", configFile, "-credentials-file", credentials, "-prototype-id", "proto-3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.APIToken != "del-archivo" {
+		t.Errorf("api_token = %q sin credenciales, se esperaba el del archivo", s.APIToken)
+	}
+
+	// Solo se aceptan credenciales
+	writeJSON(t, credentials, `{"proto-1": {"api_url": "http://otro/"}}`)
+	if _, err := Load([]string{"-credentials-file", credentials, "-prototype-id", "proto-1"}); err == nil {
+		t.Error("se aceptó api_url como credencial")
+	}
+	if _, err := Load([]string{"-credentials-file", filepath.Join(dir, "no-existe.json")}); err == nil {
+		t.Error("se aceptó un credentials_file que no existe")
+	}
+}
+
+func writeJSON(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
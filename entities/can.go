@@ -2,6 +2,7 @@ package entities
 
 import (
 	"math/rand"
+	"sync/atomic"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"pybot-simulator/utils"
@@ -12,7 +13,11 @@ const (
 	CAN
 )
 
+// nextCanID numera las latas de la sesión.
+var nextCanID atomic.Int64
+
 type Can struct {
+	ID       int64 // único dentro de la sesión; identifica el evento de recolección
 	Position utils.Vector2D
 	Active   bool
 	Sprite   *ebiten.Image
@@ -21,18 +26,27 @@ type Can struct {
 	WasteID  int64
 }
 
+// NewCan crea un residuo de tipo aleatorio.
 func NewCan(x, y float64, sprite *ebiten.Image) *Can {
 	canType := PET
+	if rand.Float64() > 0.5 {
+		canType = CAN
+	}
+	return NewCanOfType(x, y, canType, sprite)
+}
+
+// NewCanOfType crea un residuo del tipo indicado (PET o CAN).
+func NewCanOfType(x, y float64, canType int, sprite *ebiten.Image) *Can {
 	weight := 10.0
 	wasteID := int64(1)
 
-	if rand.Float64() > 0.5 {
-		canType = CAN
+	if canType == CAN {
 		weight = 20.0
 		wasteID = int64(2)
 	}
 
 	return &Can{
+		ID:       nextCanID.Add(1),
 		Position: utils.Vector2D{X: x, Y: y},
 		Active:   true,
 		Sprite:   sprite,
@@ -7,17 +7,26 @@ import (
 	"pybot-simulator/utils"
 )
 
+// TicksPerSecond es el tick nominal de la simulación: Speed y Velocity están
+// en píxeles por tick de 1/TicksPerSecond segundos.
+const TicksPerSecond = 60
+
 type Robot struct {
 	Position       utils.Vector2D
 	Velocity       utils.Vector2D
 	CansCollected  int
+	CollectedByType map[int]int // recogidos por tipo (PET, CAN)
 	TotalWeight    float64
+	DistanceTraveled float64 // Píxeles recorridos desde el inicio
 	Sprite         *ebiten.Image
-	Sprites        map[string]*ebiten.Image
-	BatterySprite  *ebiten.Image
 	minX, maxX     float64
 	minY, maxY     float64
 	
+	// Obstacles son las paredes y muebles del mapa (nil = área abierta);
+	// el robot choca con ellas con un cuerpo de BodySize x BodySize.
+	Obstacles      *utils.CollisionGrid
+	BodySize       float64
+	
 	Battery        *Battery
 	
 	Target         *utils.Vector2D
@@ -29,19 +38,21 @@ func NewRobot(x, y float64, sprite *ebiten.Image) *Robot {
 		Position:      utils.Vector2D{X: x, Y: y},
 		Velocity:      utils.Vector2D{X: 0, Y: 0},
 		CansCollected: 0,
+		CollectedByType: make(map[int]int),
 		TotalWeight:   0.0,
 		Sprite:        sprite,
-		Sprites:       make(map[string]*ebiten.Image),
 		Battery:       NewBattery(), // Nueva entidad Battery
 		Speed:         2.0,
 		Target:        nil,
 	}
 }
 
-func (r *Robot) Update() {
+// Update avanza al robot dt segundos de tiempo simulado. dt no debería pasar
+// de un tick nominal para no atravesar paredes ni pasarse del objetivo.
+func (r *Robot) Update(dt float64) {
 	// Consumir batería si está en movimiento
 	if r.Velocity.X != 0 || r.Velocity.Y != 0 {
-		r.Battery.Drain(1.0 / 60.0) // Dividir por TPS (60)
+		r.Battery.Drain(dt)
 	}
 	
 	// Si no hay batería, detener movimiento
@@ -73,15 +84,23 @@ func (r *Robot) Update() {
 	}
 	
 	// Actualizar posición
-	newX := r.Position.X + r.Velocity.X
-	newY := r.Position.Y + r.Velocity.Y
+	step := dt * TicksPerSecond
+	newX := r.Position.X + r.Velocity.X*step
+	newY := r.Position.Y + r.Velocity.Y*step
 	
-	if newX >= r.minX && newX <= r.maxX {
+	prev := r.Position
+	if r.CanMoveTo(newX, r.Position.Y) {
 		r.Position.X = newX
 	}
-	if newY >= r.minY && newY <= r.maxY {
+	if r.CanMoveTo(r.Position.X, newY) {
 		r.Position.Y = newY
 	}
+	r.DistanceTraveled += prev.Distance(r.Position)
+	
+	// Atorado contra un obstáculo: soltar el objetivo para que se vuelva a planear
+	if r.Target != nil && r.Position == prev {
+		r.ClearTarget()
+	}
 }
 
 func (r *Robot) SetTarget(target utils.Vector2D) {
@@ -113,8 +132,29 @@ func (r *Robot) SetBounds(minX, maxX, minY, maxY float64) {
 	r.maxY = maxY
 }
 
+func (r *Robot) Bounds() (minX, maxX, minY, maxY float64) {
+	return r.minX, r.maxX, r.minY, r.maxY
+}
+
+func (r *Robot) InBounds(x, y float64) bool {
+	return x >= r.minX && x <= r.maxX && y >= r.minY && y <= r.maxY
+}
+
+// CanMoveTo indica si el robot cabe en (x, y): dentro de los límites y sin
+// chocar con los obstáculos del mapa.
+func (r *Robot) CanMoveTo(x, y float64) bool {
+	if !r.InBounds(x, y) {
+		return false
+	}
+	if r.Obstacles == nil {
+		return true
+	}
+	return r.Obstacles.Free(utils.RectAround(utils.Vector2D{X: x, Y: y}, r.BodySize, r.BodySize))
+}
+
 func (r *Robot) CollectCan(can *Can) {
 	r.CansCollected++
+	r.CollectedByType[can.Type]++
 	r.TotalWeight += can.Weight
 	r.ClearTarget() // Buscar siguiente lata
 }
@@ -0,0 +1,184 @@
+package game
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Camera limits and speeds.
+const (
+	maxZoom      = 4.0
+	zoomStep     = 1.1 // zoom factor per wheel notch
+	cameraPanKey = 8.0 // screen pixels per tick while an arrow key is held
+)
+
+// Camera maps world coordinates, where the robot and the items live, to
+// screen coordinates. It shows the world point (X, Y) at the center of the
+// viewport, scaled by Zoom.
+type Camera struct {
+	X, Y   float64
+	Zoom   float64
+	Follow bool // keep the robot centered
+
+	viewW, viewH   float64
+	worldW, worldH float64
+	minZoom        float64
+
+	dragging     bool
+	dragX, dragY int
+}
+
+// NewCamera creates a camera for a viewport of viewW x viewH screen pixels
+// over a world of worldW x worldH, centered on the world at zoom 1.
+func NewCamera(viewW, viewH, worldW, worldH float64) *Camera {
+	c := &Camera{
+		X: worldW / 2, Y: worldH / 2,
+		Zoom:  1,
+		viewW: viewW, viewH: viewH,
+		worldW: worldW, worldH: worldH,
+	}
+	// Zooming out stops once the whole world fits in the window
+	c.minZoom = math.Min(1, math.Min(viewW/worldW, viewH/worldH))
+	return c
+}
+
+// Resize changes the viewport to viewW x viewH screen pixels, keeping the
+// camera inside the world.
+func (c *Camera) Resize(viewW, viewH float64) {
+	c.viewW, c.viewH = viewW, viewH
+	c.minZoom = math.Min(1, math.Min(viewW/c.worldW, viewH/c.worldH))
+	c.Zoom = math.Max(c.minZoom, c.Zoom)
+	c.clamp()
+}
+
+// GeoM returns the world-to-screen transform, to be concatenated after an
+// image's own world placement.
+func (c *Camera) GeoM() ebiten.GeoM {
+	var m ebiten.GeoM
+	m.Translate(-c.X, -c.Y)
+	m.Scale(c.Zoom, c.Zoom)
+	m.Translate(c.viewW/2, c.viewH/2)
+	return m
+}
+
+// WorldToScreen converts a world point to screen pixels.
+func (c *Camera) WorldToScreen(x, y float64) (float64, float64) {
+	return (x-c.X)*c.Zoom + c.viewW/2, (y-c.Y)*c.Zoom + c.viewH/2
+}
+
+// ScreenToWorld converts a screen pixel to a world point.
+func (c *Camera) ScreenToWorld(x, y float64) (float64, float64) {
+	return (x-c.viewW/2)/c.Zoom + c.X, (y-c.viewH/2)/c.Zoom + c.Y
+}
+
+// Visible reports whether the world rectangle at (x, y) of size w x h
+// overlaps the viewport. Draw code uses it to skip off-screen entities.
+func (c *Camera) Visible(x, y, w, h float64) bool {
+	halfW, halfH := c.viewW/2/c.Zoom, c.viewH/2/c.Zoom
+	return x+w >= c.X-halfW && x <= c.X+halfW &&
+		y+h >= c.Y-halfH && y <= c.Y+halfH
+}
+
+// LookAt centers the camera on a world point.
+func (c *Camera) LookAt(x, y float64) {
+	c.X, c.Y = x, y
+	c.clamp()
+}
+
+// Pan moves the camera by a distance given in screen pixels.
+func (c *Camera) Pan(dx, dy float64) {
+	c.X += dx / c.Zoom
+	c.Y += dy / c.Zoom
+	c.clamp()
+}
+
+// ZoomAt multiplies the zoom by factor, keeping the world point under the
+// screen pixel (sx, sy) in place.
+func (c *Camera) ZoomAt(factor, sx, sy float64) {
+	wx, wy := c.ScreenToWorld(sx, sy)
+	c.Zoom = math.Max(c.minZoom, math.Min(maxZoom, c.Zoom*factor))
+	c.X = wx - (sx-c.viewW/2)/c.Zoom
+	c.Y = wy - (sy-c.viewH/2)/c.Zoom
+	c.clamp()
+}
+
+// clamp keeps the viewport inside the world. On an axis where the whole
+// world fits, the world is centered instead.
+func (c *Camera) clamp() {
+	c.X = clampAxis(c.X, c.viewW/2/c.Zoom, c.worldW)
+	c.Y = clampAxis(c.Y, c.viewH/2/c.Zoom, c.worldH)
+}
+
+func clampAxis(center, half, size float64) float64 {
+	if 2*half >= size {
+		return size / 2
+	}
+	return math.Max(half, math.Min(size-half, center))
+}
+
+// updateCamera applies the camera controls: mouse wheel to zoom, right or
+// middle drag and the arrow keys to pan, F to follow the robot and Home to
+// go back to zoom 1 on the robot. Panning by hand stops following. In
+// manual mode the arrow keys drive the robot instead, and while a control
+// has the keyboard focus they are the control's.
+func (g *Game) updateCamera() {
+	cam := g.camera
+	mx, my := ebiten.CursorPosition()
+	// The wheel and new drags over the control panel belong to the panel; a
+	// drag that started on the world keeps going across it
+	overUI := g.ui.Contains(float64(mx), float64(my))
+
+	if _, wy := ebiten.Wheel(); wy != 0 && !overUI {
+		cam.ZoomAt(math.Pow(zoomStep, wy), float64(mx), float64(my))
+	}
+
+	dragButton := ebiten.IsMouseButtonPressed(ebiten.MouseButtonRight) ||
+		ebiten.IsMouseButtonPressed(ebiten.MouseButtonMiddle)
+	switch {
+	case dragButton && cam.dragging:
+		if mx != cam.dragX || my != cam.dragY {
+			cam.Pan(float64(cam.dragX-mx), float64(cam.dragY-my))
+			cam.Follow = false
+		}
+		cam.dragX, cam.dragY = mx, my
+	case dragButton && !overUI:
+		cam.dragging = true
+		cam.dragX, cam.dragY = mx, my
+	default:
+		cam.dragging = false
+	}
+
+	var dx, dy float64
+	if !g.manual && !g.ui.HasFocus() {
+		if ebiten.IsKeyPressed(ebiten.KeyArrowLeft) {
+			dx -= cameraPanKey
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyArrowRight) {
+			dx += cameraPanKey
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyArrowUp) {
+			dy -= cameraPanKey
+		}
+		if ebiten.IsKeyPressed(ebiten.KeyArrowDown) {
+			dy += cameraPanKey
+		}
+	}
+	if dx != 0 || dy != 0 {
+		cam.Pan(dx, dy)
+		cam.Follow = false
+	}
+
+	if inpututil.IsKeyJustPressed(ebiten.KeyF) {
+		cam.Follow = !cam.Follow
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyHome) {
+		cam.Zoom = 1
+		cam.Follow = true
+	}
+
+	if cam.Follow {
+		cam.LookAt(g.robot.Position.X, g.robot.Position.Y)
+	}
+}
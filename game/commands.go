@@ -0,0 +1,93 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"pybot-simulator/api/rabbitmq"
+	"pybot-simulator/systems"
+	"pybot-simulator/utils"
+)
+
+// startCommandConsumer connects the remote command channel for this prototype.
+func (g *Game) startCommandConsumer() {
+	consumer, err := rabbitmq.NewRabbitMQConsumer(g.settings.PrototypeID)
+	if err != nil {
+		log.Printf("Warning: Failed to start remote command consumer: %v", err)
+		return
+	}
+	g.commandConsumer = consumer
+}
+
+// processCommands applies every command received since the last tick.
+// Commands are only ever applied here, on the game goroutine.
+func (g *Game) processCommands() {
+	if g.commandConsumer == nil {
+		return
+	}
+	for {
+		select {
+		case in := <-g.commandConsumer.Commands():
+			err := g.applyCommand(in.Command)
+			if err != nil {
+				log.Printf("Remote command %s (%s) rejected: %v", in.ID, in.Type, err)
+			} else {
+				log.Printf("Remote command %s (%s) applied", in.ID, in.Type)
+			}
+			g.submitCritical("command_reply", func(ctx context.Context) { in.Respond(err) })
+		default:
+			return
+		}
+	}
+}
+
+func (g *Game) applyCommand(cmd rabbitmq.Command) error {
+	switch cmd.Type {
+	case rabbitmq.CommandSpawn:
+		if cmd.Count <= 0 || cmd.Count > 100 {
+			return fmt.Errorf("count must be between 1 and 100, got %d", cmd.Count)
+		}
+		g.SpawnCans(cmd.Count, spawnMixed)
+	case rabbitmq.CommandRecharge:
+		g.handleRecharge()
+	case rabbitmq.CommandGoTo:
+		if !g.robot.InBounds(cmd.X, cmd.Y) {
+			return fmt.Errorf("coordinate (%.1f, %.1f) is outside the play area", cmd.X, cmd.Y)
+		}
+		if g.robot.Battery.IsEmpty() {
+			return fmt.Errorf("battery is empty")
+		}
+		if g.manual {
+			return fmt.Errorf("robot is under manual control")
+		}
+		if !g.goTo(utils.Vector2D{X: cmd.X, Y: cmd.Y}) {
+			return fmt.Errorf("coordinate (%.1f, %.1f) cannot be reached on the map", cmd.X, cmd.Y)
+		}
+	case rabbitmq.CommandPause:
+		g.paused = true
+	case rabbitmq.CommandResume:
+		g.paused = false
+	case rabbitmq.CommandStrategy:
+		return g.setNavigationStrategy(cmd.Strategy)
+	case rabbitmq.CommandRotatePeriod:
+		// The reply means the rotation started; its outcome is logged later
+		return g.startRotation()
+	default:
+		return fmt.Errorf("unknown command type %q", cmd.Type)
+	}
+	return nil
+}
+
+// setNavigationStrategy swaps the strategy used to pick the next target.
+func (g *Game) setNavigationStrategy(name string) error {
+	minX, maxX, minY, maxY := g.robot.Bounds()
+	strategy, err := systems.NewNavigationStrategy(name, minX, maxX, minY, maxY, g.rng)
+	if err != nil {
+		return err
+	}
+	g.navigation = strategy
+	g.clearRoute()
+	log.Printf("Navigation strategy set to %s", strategy.Name())
+	return nil
+}
@@ -0,0 +1,89 @@
+package game
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"slices"
+
+	"pybot-simulator/entities"
+	"pybot-simulator/systems"
+	"pybot-simulator/ui"
+)
+
+// Control panel layout, in screen pixels. It sits in the bottom-left corner.
+const (
+	controlPanelWidth  = 200.0
+	controlPanelMargin = 20.0
+)
+
+// spawnMixed spawns PET and cans at random; any other spawn type is an
+// entities item type.
+const spawnMixed = -1
+
+// spawnTypes are the choices of the spawn type slider, in order.
+var spawnTypes = []struct {
+	name     string
+	itemType int
+}{
+	{"Mixto", spawnMixed},
+	{"PET", entities.PET},
+	{"Latas", entities.CAN},
+}
+
+// navigationStrategies are the choices of the strategy slider, in order.
+var navigationStrategies = []string{systems.StrategyNearest, systems.StrategyRandom, systems.StrategySweep}
+
+// newControlPanel builds the on-screen controls. Every widget reads the game
+// state it shows, so keyboard shortcuts and remote commands are reflected
+// as they happen.
+func (g *Game) newControlPanel() *ui.UI {
+	notEditing := func() bool { return !g.editor.active }
+
+	spawn := ui.NewButton("Spawn Latas (S)", func() { g.SpawnCans(3, g.spawnType) })
+	spawn.Tooltip = "Agrega 3 residuos del tipo elegido\ndonde el robot puede llegar"
+	spawn.Enabled = notEditing
+
+	recharge := ui.NewButton("Recargar (R)", g.handleRecharge)
+	recharge.Tooltip = "Llena la batería del robot"
+	recharge.Enabled = notEditing
+
+	spawnType := ui.NewSlider("Tipo", 0, float64(len(spawnTypes)-1), 1,
+		func() float64 {
+			for i, t := range spawnTypes {
+				if t.itemType == g.spawnType {
+					return float64(i)
+				}
+			}
+			return 0
+		},
+		func(v float64) { g.spawnType = spawnTypes[int(v)].itemType })
+	spawnType.Format = func(v float64) string { return spawnTypes[int(v)].name }
+	spawnType.Tooltip = "Qué residuos agregan Spawn y la tecla S\n(los comandos remotos siguen mixtos)"
+
+	strategy := ui.NewSlider("Estrategia", 0, float64(len(navigationStrategies)-1), 1,
+		func() float64 { return float64(max(0, slices.Index(navigationStrategies, g.navigation.Name()))) },
+		func(v float64) {
+			if err := g.setNavigationStrategy(navigationStrategies[int(v)]); err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		})
+	strategy.Format = func(v float64) string { return navigationStrategies[int(v)] }
+	strategy.Tooltip = "Cómo elige el robot su próximo objetivo"
+
+	// The time scale doubles or halves on each step, like the +/- keys
+	speed := ui.NewSlider("Velocidad", math.Log2(minTimeScale), math.Log2(maxTimeScale), 1,
+		func() float64 { return math.Log2(g.clock.scale) },
+		func(v float64) { g.setTimeScale(math.Exp2(v)) })
+	speed.Format = func(v float64) string { return fmt.Sprintf("x%g", math.Exp2(v)) }
+	speed.Tooltip = "Escala del tiempo simulado (+/-)"
+
+	pause := ui.NewToggle("Pausa (P)", func() bool { return g.paused }, func(on bool) { g.paused = on })
+	manual := ui.NewToggle("Manual (M)", func() bool { return g.manual }, g.setManual)
+	manual.Tooltip = "Manejar el robot con WASD o las flechas"
+
+	panel := ui.NewPanel(ui.BottomLeft, controlPanelWidth, spawn, recharge, spawnType, strategy, speed, pause, manual)
+	panel.Title = "CONTROLES (F2 = foco)"
+	panel.MarginX, panel.MarginY = controlPanelMargin, controlPanelMargin
+	return ui.New(panel)
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"path/filepath"
+	"time"
+
+	"pybot-simulator/systems"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Coverage overlay controls.
+const (
+	trailToggleKey   = ebiten.KeyT
+	heatmapToggleKey = ebiten.KeyH // with Shift, exports the heatmap as a PNG
+)
+
+// heatmapRefreshTicks is how often the heatmap texture is rebuilt while it
+// is shown; the counts change little from one tick to the next.
+const heatmapRefreshTicks = 30
+
+// coverageOverlay tracks where the robot has been and draws it as a trail
+// and a heatmap over the world.
+type coverageOverlay struct {
+	coverage    *systems.Coverage
+	showTrail   bool
+	showHeatmap bool
+	heat        *ebiten.Image // one pixel per cell
+	heatAge     int           // ticks since heat was rebuilt
+}
+
+// newCoverageOverlay uses the map cells when there is a map, so coverage is
+// measured over the floor the robot can reach (rebuildNav keeps that area up
+// to date), and square cells of CoverageCellSize over the open area otherwise.
+func (g *Game) newCoverageOverlay() *coverageOverlay {
+	var coverage *systems.Coverage
+	if g.worldMap != nil {
+		grid := g.layout.nav.Grid()
+		coverage = systems.NewCoverage(grid.Cols, grid.Rows, grid.CellW, grid.CellH, g.settings.TrailLength)
+		coverage.Restrict(g.layout.nav.Reachable(g.layout.dock))
+	} else {
+		cell := float64(g.settings.CoverageCellSize)
+		cols := int(math.Ceil(float64(g.width) / cell))
+		rows := int(math.Ceil(float64(g.height) / cell))
+		coverage = systems.NewCoverage(cols, rows, cell, cell, g.settings.TrailLength)
+	}
+	return &coverageOverlay{
+		coverage:  coverage,
+		showTrail: true,
+		heat:      ebiten.NewImage(coverage.Cols, coverage.Rows),
+		heatAge:   heatmapRefreshTicks,
+	}
+}
+
+// updateCoverageInput toggles the overlays and exports the heatmap.
+func (g *Game) updateCoverageInput() {
+	if inpututil.IsKeyJustPressed(trailToggleKey) {
+		g.coverage.showTrail = !g.coverage.showTrail
+	}
+	if inpututil.IsKeyJustPressed(heatmapToggleKey) {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.exportHeatmap()
+		} else {
+			g.coverage.showHeatmap = !g.coverage.showHeatmap
+			g.coverage.heatAge = heatmapRefreshTicks
+		}
+	}
+}
+
+// exportHeatmap writes the heatmap to the export directory, named after the
+// navigation strategy so runs with different strategies can be compared.
+func (g *Game) exportHeatmap() {
+	name := fmt.Sprintf("heatmap-%s-%s.png", g.navigation.Name(), time.Now().Format("20060102-150405"))
+	path := filepath.Join(g.settings.HeatmapExportDir, name)
+	if err := g.coverage.coverage.WritePNG(path); err != nil {
+		log.Printf("Warning: Failed to export heatmap: %v", err)
+		return
+	}
+	visited, total := g.coverage.coverage.Covered()
+	log.Printf("Heatmap exported to %s (%d of %d cells covered)", path, visited, total)
+}
+
+// percent is the share of the area the robot has visited.
+func (c *coverageOverlay) percent() float64 {
+	visited, total := c.coverage.Covered()
+	if total == 0 {
+		return 0
+	}
+	return float64(visited) / float64(total) * 100
+}
+
+// DrawCoverage draws the heatmap under the robot and items, and the trail
+// on top of it.
+func (g *Game) DrawCoverage(screen *ebiten.Image) {
+	c := g.coverage
+	if c.showHeatmap {
+		if c.heatAge++; c.heatAge >= heatmapRefreshTicks {
+			c.heat.WritePixels(c.coverage.Heatmap().Pix)
+			c.heatAge = 0
+		}
+		op := &ebiten.DrawImageOptions{}
+		op.GeoM.Scale(c.coverage.CellW, c.coverage.CellH)
+		op.GeoM.Concat(g.camera.GeoM())
+		op.ColorScale.ScaleAlpha(0.55)
+		screen.DrawImage(c.heat, op)
+	}
+
+	if !c.showTrail {
+		return
+	}
+	trail := c.coverage.Trail()
+	for i := 1; i < len(trail); i++ {
+		// Older segments fade out
+		alpha := uint8(40 + 200*i/len(trail))
+		x0, y0 := g.camera.WorldToScreen(trail[i-1].X, trail[i-1].Y)
+		x1, y1 := g.camera.WorldToScreen(trail[i].X, trail[i].Y)
+		vector.StrokeLine(screen, float32(x0), float32(y0), float32(x1), float32(y1), 2,
+			color.NRGBA{0, 200, 255, alpha}, true)
+	}
+}
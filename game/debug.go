@@ -0,0 +1,170 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"pybot-simulator/api/sensors"
+	"pybot-simulator/config"
+	"pybot-simulator/entities"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// debugToggleKey shows and hides the debug overlay.
+const debugToggleKey = ebiten.KeyF1
+
+var (
+	debugTargetColor = color.NRGBA{255, 220, 0, 255}
+	debugRouteColor  = color.NRGBA{255, 140, 0, 200}
+	debugRadiusColor = color.NRGBA{255, 80, 80, 200}
+	debugFOVColor    = color.NRGBA{120, 255, 120, 160}
+	debugGPSColor    = color.NRGBA{200, 120, 255, 255}
+)
+
+// debugOverlay draws what the robot is planning and sensing, so odd
+// behaviour can be seen instead of read from the logs.
+type debugOverlay struct {
+	show     bool
+	tickTime time.Duration // how long the last Update took
+}
+
+// updateDebug toggles the overlay.
+func (g *Game) updateDebug() {
+	if inpututil.IsKeyJustPressed(debugToggleKey) {
+		g.debug.show = !g.debug.show
+	}
+}
+
+// collectRadius is how close the robot has to get to an item to pick it up.
+func collectRadius() float64 {
+	return float64(config.RobotSize/2 + config.CanSize/2)
+}
+
+// DrawDebug draws the debug overlay in world space, then its text block.
+func (g *Game) DrawDebug(screen *ebiten.Image) {
+	if !g.debug.show {
+		return
+	}
+	cam := g.camera
+	robot := g.robot
+	rx, ry := cam.WorldToScreen(robot.Position.X, robot.Position.Y)
+	zoom := float32(cam.Zoom)
+
+	// Camera field of view and the items inside it. This is geometry only:
+	// it decides when an on-change camera publishes, but the published image
+	// and its detections come from the dataset, not from these items.
+	seen := g.cameraView()
+	g.drawFieldOfView(screen)
+	for _, can := range seen {
+		x, y := cam.WorldToScreen(can.Position.X, can.Position.Y)
+		vector.StrokeRect(screen, float32(x)-config.CanSize/2*zoom, float32(y)-config.CanSize/2*zoom,
+			config.CanSize*zoom, config.CanSize*zoom, 2, debugFOVColor, false)
+	}
+
+	// Collect radius
+	vector.StrokeCircle(screen, float32(rx), float32(ry), float32(collectRadius())*zoom, 1, debugRadiusColor, true)
+
+	// Current target, then the rest of the planned route
+	if robot.Target != nil {
+		tx, ty := cam.WorldToScreen(robot.Target.X, robot.Target.Y)
+		vector.StrokeLine(screen, float32(rx), float32(ry), float32(tx), float32(ty), 2, debugTargetColor, true)
+		prevX, prevY := tx, ty
+		for _, p := range g.route {
+			x, y := cam.WorldToScreen(p.X, p.Y)
+			vector.StrokeLine(screen, float32(prevX), float32(prevY), float32(x), float32(y), 1, debugRouteColor, true)
+			vector.FillCircle(screen, float32(x), float32(y), 3, debugRouteColor, true)
+			prevX, prevY = x, y
+		}
+	}
+
+	// Last GPS reading next to the true position
+	fix := g.gpsSensor.LastFix()
+	if !fix.At.IsZero() {
+		gx, gy := cam.WorldToScreen(fix.Position.X, fix.Position.Y)
+		vector.StrokeLine(screen, float32(rx), float32(ry), float32(gx), float32(gy), 1, debugGPSColor, true)
+		vector.StrokeCircle(screen, float32(gx), float32(gy), 5, 2, debugGPSColor, true)
+		ebitenutil.DebugPrintAt(screen, "GPS", int(gx)+7, int(gy)-7)
+	}
+
+	// Item IDs and types
+	for _, can := range g.cans {
+		if !can.Active || !cam.Visible(can.Position.X, can.Position.Y, 1, 1) {
+			continue
+		}
+		x, y := cam.WorldToScreen(can.Position.X, can.Position.Y)
+		ebitenutil.DebugPrintAt(screen, fmt.Sprintf("#%d %s", can.ID, wasteTypeName(can.Type)),
+			int(x)+int(config.CanSize/2*zoom)+2, int(y)-8)
+	}
+
+	g.drawDebugStats(screen, len(seen), fix)
+}
+
+// drawFieldOfView draws the camera cone in front of the robot, labelled as
+// the geometric field of view.
+func (g *Game) drawFieldOfView(screen *ebiten.Image) {
+	heading := g.heading
+	if heading.X == 0 && heading.Y == 0 {
+		return
+	}
+	cam := g.camera
+	pos := g.robot.Position
+	angle := math.Atan2(heading.Y, heading.X)
+	half := config.CameraFOV / 2 * math.Pi / 180
+
+	rx, ry := cam.WorldToScreen(pos.X, pos.Y)
+	edge := func(a float64) (float64, float64) {
+		return cam.WorldToScreen(pos.X+config.CameraRange*math.Cos(a), pos.Y+config.CameraRange*math.Sin(a))
+	}
+	lx, ly := edge(angle - half)
+	hx, hy := edge(angle + half)
+	vector.StrokeLine(screen, float32(rx), float32(ry), float32(lx), float32(ly), 1, debugFOVColor, true)
+	vector.StrokeLine(screen, float32(rx), float32(ry), float32(hx), float32(hy), 1, debugFOVColor, true)
+
+	// The arc, as a few straight segments
+	const arcSegments = 12
+	prevX, prevY := lx, ly
+	for i := 1; i <= arcSegments; i++ {
+		x, y := edge(angle - half + 2*half*float64(i)/arcSegments)
+		vector.StrokeLine(screen, float32(prevX), float32(prevY), float32(x), float32(y), 1, debugFOVColor, true)
+		prevX, prevY = x, y
+	}
+	tx, ty := edge(angle)
+	ebitenutil.DebugPrintAt(screen, "FOV geométrico", int(tx)+4, int(ty)-8)
+}
+
+// drawDebugStats prints tick time and entity counts at the bottom right.
+func (g *Game) drawDebugStats(screen *ebiten.Image, seen int, fix sensors.GPSFix) {
+	active := g.GetActiveCansCount()
+	lines := []string{
+		fmt.Sprintf("Tick: %s | TPS %.0f | FPS %.0f", g.debug.tickTime.Round(time.Microsecond), ebiten.ActualTPS(), ebiten.ActualFPS()),
+		fmt.Sprintf("Items: %d activos / %d total | En el FOV: %d", active, len(g.cans), seen),
+		fmt.Sprintf("Ruta: %d puntos | Robot (%.0f, %.0f)", len(g.route), g.robot.Position.X, g.robot.Position.Y),
+	}
+	if !fix.At.IsZero() {
+		lines = append(lines, fmt.Sprintf("GPS (%.0f, %.0f) hace %s, error %.1f px", fix.Position.X, fix.Position.Y,
+			time.Since(fix.At).Round(100*time.Millisecond), fix.Position.Distance(g.robot.Position)))
+	}
+	x := g.screenWidth - 360
+	y := g.screenHeight - 10 - len(lines)*hudLineHeight
+	ebitenutil.DrawRect(screen, float64(x-6), float64(y-4), 360, float64(len(lines)*hudLineHeight+8), color.RGBA{20, 20, 30, 200})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, x, y+i*hudLineHeight)
+	}
+}
+
+// wasteTypeName is the label of an item type.
+func wasteTypeName(t int) string {
+	switch t {
+	case entities.PET:
+		return "PET"
+	case entities.CAN:
+		return "lata"
+	}
+	return fmt.Sprintf("tipo %d", t)
+}
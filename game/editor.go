@@ -0,0 +1,268 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+
+	"pybot-simulator/config"
+	"pybot-simulator/entities"
+	"pybot-simulator/scenario"
+	"pybot-simulator/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// editorToggleKey enters and leaves edit mode.
+const editorToggleKey = ebiten.KeyE
+
+// minZoneSize is the smallest rectangle the editor keeps, in world pixels;
+// anything smaller is taken as a stray click.
+const minZoneSize = 5.0
+
+type editorTool int
+
+const (
+	toolPET editorTool = iota
+	toolCan
+	toolObstacle
+	toolSpawnZone
+	toolNoGoZone
+	toolDock
+	toolBin
+)
+
+// editorTools are picked with the number keys, in this order.
+var editorTools = []struct {
+	key  ebiten.Key
+	name string
+}{
+	toolPET:       {ebiten.Key1, "PET"},
+	toolCan:       {ebiten.Key2, "Lata"},
+	toolObstacle:  {ebiten.Key3, "Obstáculo"},
+	toolSpawnZone: {ebiten.Key4, "Zona de aparición"},
+	toolNoGoZone:  {ebiten.Key5, "Zona prohibida"},
+	toolDock:      {ebiten.Key6, "Base"},
+	toolBin:       {ebiten.Key7, "Contenedor"},
+}
+
+// editor is the in-game level editor. While it is active the simulation
+// stops and left clicks place things: items and the dock or bin with a
+// click, obstacles and zones by dragging a rectangle. Shift+click erases
+// what the current tool placed under the cursor.
+type editor struct {
+	active    bool
+	tool      editorTool
+	dragging  bool
+	dragStart utils.Vector2D
+}
+
+// updateEditor toggles edit mode and, while it is on, applies the editor
+// controls. It reports whether edit mode is on.
+func (g *Game) updateEditor() bool {
+	e := &g.editor
+	if inpututil.IsKeyJustPressed(editorToggleKey) {
+		e.active = !e.active
+		e.dragging = false
+		if e.active {
+			g.clearRoute()
+		}
+	}
+	if !e.active {
+		return false
+	}
+
+	for tool, t := range editorTools {
+		if inpututil.IsKeyJustPressed(t.key) {
+			e.tool = editorTool(tool)
+			e.dragging = false
+		}
+	}
+	if ebiten.IsKeyPressed(ebiten.KeyControl) {
+		if inpututil.IsKeyJustPressed(ebiten.KeyS) {
+			g.saveScenario()
+		}
+		if inpututil.IsKeyJustPressed(ebiten.KeyL) {
+			g.loadScenario()
+		}
+	}
+
+	mx, my := ebiten.CursorPosition()
+	wx, wy := g.camera.ScreenToWorld(float64(mx), float64(my))
+	cursor := utils.Vector2D{X: wx, Y: wy}
+	overControls := g.ui.Contains(float64(mx), float64(my))
+
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) && !overControls {
+		if ebiten.IsKeyPressed(ebiten.KeyShift) {
+			g.eraseAt(cursor)
+		} else {
+			g.placeAt(cursor)
+		}
+	}
+	if e.dragging && inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		e.dragging = false
+		g.addZone(rectBetween(e.dragStart, cursor))
+	}
+	return true
+}
+
+// placeAt applies the current tool at p; rectangle tools start a drag.
+func (g *Game) placeAt(p utils.Vector2D) {
+	e := &g.editor
+	switch e.tool {
+	case toolPET, toolCan:
+		if !g.reachable(p) {
+			log.Printf("Editor: (%.0f, %.0f) is out of the robot's reach", p.X, p.Y)
+			return
+		}
+		canType := entities.PET
+		if e.tool == toolCan {
+			canType = entities.CAN
+		}
+		g.placeCan(p, canType)
+	case toolObstacle, toolSpawnZone, toolNoGoZone:
+		e.dragging = true
+		e.dragStart = p
+	case toolDock:
+		if !g.fits(p) {
+			log.Printf("Editor: the robot does not fit at (%.0f, %.0f)", p.X, p.Y)
+			return
+		}
+		g.layout.dock = p
+		g.robot.Position = p
+		g.rebuildNav()
+	case toolBin:
+		g.layout.bin = &p
+	}
+}
+
+// addZone adds a dragged rectangle to the list of the current tool.
+func (g *Game) addZone(r utils.Rect) {
+	if r.W < minZoneSize || r.H < minZoneSize {
+		return
+	}
+	l := &g.layout
+	switch g.editor.tool {
+	case toolObstacle, toolNoGoZone:
+		// The robot must still fit where it stands
+		if r.Intersects(utils.RectAround(g.robot.Position, config.RobotBodySize, config.RobotBodySize)) {
+			log.Println("Editor: that would block the robot where it stands")
+			return
+		}
+		if g.editor.tool == toolObstacle {
+			l.obstacles = append(l.obstacles, r)
+		} else {
+			l.noGoZones = append(l.noGoZones, r)
+		}
+		g.rebuildNav()
+		// Items under the zone or cut off from the dock can't be collected anymore
+		if n := g.dropUnreachableItems(); n > 0 {
+			log.Printf("Editor: removed %d items the robot can no longer reach", n)
+		}
+	case toolSpawnZone:
+		l.spawnZones = append(l.spawnZones, r)
+		g.rebuildNav()
+	}
+}
+
+// eraseAt removes what the current tool placed under p: the item there, the
+// newest rectangle that contains p, or the bin.
+func (g *Game) eraseAt(p utils.Vector2D) {
+	l := &g.layout
+	switch g.editor.tool {
+	case toolPET, toolCan:
+		for i := len(g.cans) - 1; i >= 0; i-- {
+			can := g.cans[i]
+			if can.Active && utils.RectAround(can.Position, config.CanSize, config.CanSize).Contains(p) {
+				g.cans = append(g.cans[:i], g.cans[i+1:]...)
+				return
+			}
+		}
+	case toolObstacle:
+		l.obstacles = removeRectAt(l.obstacles, p)
+		g.rebuildNav()
+	case toolSpawnZone:
+		l.spawnZones = removeRectAt(l.spawnZones, p)
+		g.rebuildNav()
+	case toolNoGoZone:
+		l.noGoZones = removeRectAt(l.noGoZones, p)
+		g.rebuildNav()
+	case toolBin:
+		l.bin = nil
+	}
+}
+
+// removeRectAt drops the newest rectangle that contains p.
+func removeRectAt(rects []utils.Rect, p utils.Vector2D) []utils.Rect {
+	for i := len(rects) - 1; i >= 0; i-- {
+		if rects[i].Contains(p) {
+			return append(rects[:i], rects[i+1:]...)
+		}
+	}
+	return rects
+}
+
+// rectBetween is the rectangle with corners a and b, in any order.
+func rectBetween(a, b utils.Vector2D) utils.Rect {
+	x0, y0 := min(a.X, b.X), min(a.Y, b.Y)
+	return utils.Rect{X: x0, Y: y0, W: max(a.X, b.X) - x0, H: max(a.Y, b.Y) - y0}
+}
+
+// saveScenario writes the layout and the items on the floor to the
+// scenario file.
+func (g *Game) saveScenario() {
+	path := g.settings.ScenarioPath
+	s := g.scenarioFromLayout()
+	if err := s.Save(path); err != nil {
+		log.Printf("Warning: Failed to save scenario: %v", err)
+		return
+	}
+	log.Printf("Scenario saved to %s (%d items, %d obstacles)", path, len(s.Items), len(s.Obstacles))
+}
+
+// loadScenario replaces the layout and the items with the scenario file.
+func (g *Game) loadScenario() {
+	path := g.settings.ScenarioPath
+	s, err := scenario.Load(path)
+	if err == nil {
+		err = g.applyScenario(s)
+	}
+	if err != nil {
+		log.Printf("Warning: Failed to load scenario: %v", err)
+		return
+	}
+	log.Printf("Scenario loaded from %s (%d items, %d obstacles)", path, len(s.Items), len(s.Obstacles))
+}
+
+// DrawEditor draws the tool bar and the rectangle being dragged.
+func (g *Game) DrawEditor(screen *ebiten.Image) {
+	e := &g.editor
+	if !e.active {
+		return
+	}
+	if e.dragging {
+		mx, my := ebiten.CursorPosition()
+		x0, y0 := g.camera.WorldToScreen(e.dragStart.X, e.dragStart.Y)
+		x1, y1 := float64(mx), float64(my)
+		vector.StrokeRect(screen, float32(min(x0, x1)), float32(min(y0, y1)),
+			float32(max(x0, x1)-min(x0, x1)), float32(max(y0, y1)-min(y0, y1)), 2, color.White, false)
+	}
+
+	tools := make([]string, len(editorTools))
+	for i, t := range editorTools {
+		label := fmt.Sprintf("%d %s", i+1, t.name)
+		if editorTool(i) == e.tool {
+			label = "[" + label + "]"
+		}
+		tools[i] = label
+	}
+	y := 110
+	ebitenutil.DrawRect(screen, 0, float64(y-4), float64(g.screenWidth), 40, color.RGBA{20, 20, 30, 200})
+	ebitenutil.DebugPrintAt(screen, "EDITOR: "+strings.Join(tools, " | "), 10, y)
+	ebitenutil.DebugPrintAt(screen, fmt.Sprintf("Clic = colocar | Shift+clic = borrar | Ctrl+S = guardar | Ctrl+L = cargar (%s) | E = salir",
+		g.settings.ScenarioPath), 10, y+16)
+}
@@ -1,61 +1,117 @@
 package game
 
 import (
-	"image"
-	"image/color"
+	"context"
+	"fmt"
 	"log"
-	"math"
 	"math/rand"
+	"strconv"
+	"sync/atomic"
+	"pybot-simulator/api/rabbitmq"
 	"pybot-simulator/api/sensors"
+	"pybot-simulator/api/workers"
 	"pybot-simulator/api/services"
 	"time"
 
+	"pybot-simulator/atlas"
 	"pybot-simulator/config"
 	"pybot-simulator/entities"
+	"pybot-simulator/systems"
+	"pybot-simulator/ui"
+	"pybot-simulator/utils"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 )
 
 type Game struct {
-	width  int
+	width  int // world size
 	height int
 
+	screenWidth  int
+	screenHeight int
+	camera       *Camera
+	worldMap     *worldMap        // nil = open area without walls
+	route        []utils.Vector2D // waypoints left after robot.Target
+
 	robot *entities.Robot
 	cans  []*entities.Can
 
-	spawnButton    Button
-	rechargeButton Button
+	ui        *ui.UI
+	spawnType int // item type the spawn controls add, or spawnMixed
 
-	canSprite *ebiten.Image
+	robotSprites   *spriteSheet
+	batterySprites *spriteSheet // nil = battery drawn as a bar
+	trashSprites   *spriteSheet
+	robotAnim      atlas.Player
 
 	rng               *rand.Rand
-	animationCounter  int
 	realTimeCamera    *sensors.RealTimeCamera
-	cameraTicks       int
 	gpsSensor         *sensors.GPSSensor
-	gpsTicks          int
 	weightSensor      *sensors.WeightSensor
 	registerPeriods   *services.RegisterPeriods
 	backupService     *services.Backup
 	batteryDepleted   bool
+	navigation        systems.NavigationStrategy
+	commandConsumer   *rabbitmq.RabbitMQConsumer
+	paused            bool
+	showHUD           bool
+	coverage          *coverageOverlay
+	debug             debugOverlay
+	heading           utils.Vector2D // last direction the robot moved in, where the camera looks
+	manual            bool // driven from the keyboard instead of the strategy
+	layout            layout
+	editor            editor
+	clock             simClock
+	settings          *config.Settings
+	scheduler         *systems.Scheduler
+	workers           *workers.Pool
+	exitRequested     atomic.Bool
+	sessionID         string // distingue las claves de recolección entre ejecuciones
+	rotation          systems.RotationPolicy
+	periodStart       time.Time
+	periodItemsBase   int
+	periodWeightBase  float64
+	rotationRetryAt   time.Time
+	rotating          bool      // a rotation is running on the worker pool
+	rotationDone      chan bool // its outcome, read on the game tick
+	stopSync          context.CancelFunc
+	syncDone          chan struct{}
 }
 
-type Button struct {
-	X, Y, Width, Height float64
-	Text                string
-}
-
-func NewGame(width, height int) *Game {
+// NewGame creates the simulation for a window of screenWidth x screenHeight.
+// The world is the map from the settings or, without one, an open area of
+// WorldWidth x WorldHeight.
+func NewGame(screenWidth, screenHeight int, settings *config.Settings) *Game {
+	width, height := settings.WorldWidth, settings.WorldHeight
+	var scenario *worldMap
+	if settings.MapPath != "" {
+		var err error
+		scenario, err = loadWorldMap(settings.MapPath)
+		if err != nil {
+			log.Fatalf("Failed to load map: %v", err)
+		}
+		width, height = scenario.size()
+	}
 	g := &Game{
 		width:            width,
 		height:           height,
+		screenWidth:      screenWidth,
+		screenHeight:     screenHeight,
+		worldMap:         scenario,
+		settings:         settings,
+		sessionID:        strconv.FormatInt(time.Now().UnixNano(), 36),
 		cans:             make([]*entities.Can, 0),
 		rng:              rand.New(rand.NewSource(time.Now().UnixNano())),
-		animationCounter: 0,
 		batteryDepleted:  false,
+		showHUD:          true,
+		spawnType:        spawnMixed,
+		clock:            newSimClock(),
+		rotationDone:     make(chan bool, 1),
 	}
 
+	// Background I/O (publishing, API calls, backups) runs on a bounded pool
+	g.workers = workers.NewPool("io", settings.WorkerCount, settings.WorkerQueue)
+
 	// Initialize the real-time camera sensor
 	var err error
 	g.realTimeCamera, err = sensors.NewRealTimeCamera()
@@ -64,7 +120,7 @@ func NewGame(width, height int) *Game {
 	}
 
 	// Initialize the work period service
-	g.registerPeriods, err = services.NewRegisterPeriods()
+	g.registerPeriods, err = services.NewRegisterPeriods(settings)
 	if err != nil {
 		log.Fatalf("Failed to initialize register periods service: %v", err)
 	}
@@ -82,51 +138,80 @@ func NewGame(width, height int) *Game {
 	}
 
 	// Initialize the Backup service
-	g.backupService = services.NewBackup()
+	g.backupService, err = services.NewBackup(settings, g.registerPeriods)
+	if err != nil {
+		log.Fatalf("Failed to initialize backup service: %v", err)
+	}
+
+	// Replay API calls stored while offline in a previous session
+	g.flushOfflineJournal()
 
 	// Create a new work period on start
 	log.Println("Creating a new work period...")
 	g.createInitialWorkPeriod()
 
-	// Crear robot en el centro (sin sprite todavía)
-	g.robot = entities.NewRobot(
-		float64(width)/2,
-		float64(height)/2,
-		nil,
-	)
+	// Keep retrying the offline journal in the background
+	g.startOfflineSync()
+
+	// Crear robot en el centro, o en su punto de inicio del mapa (sin sprite todavía)
+	start := utils.Vector2D{X: float64(width) / 2, Y: float64(height) / 2}
+	if g.worldMap != nil {
+		start = g.worldMap.start
+	}
+	g.robot = entities.NewRobot(start.X, start.Y, nil)
 	
-	// Establecer límites del robot
+	// Establecer límites del robot; con mapa, las paredes los ponen las colisiones
 	margin := float64(config.GridMargin)
+	if g.worldMap != nil {
+		margin = 0
+	}
 	g.robot.SetBounds(
 		margin,
 		float64(width)-margin,
 		margin,
 		float64(height)-margin,
 	)
+
+	// La base de carga está donde arranca el robot
+	g.layout.dock = start
+	g.rebuildNav()
+	g.navigation = &systems.Nearest{}
+
+	// La cámara empieza siguiendo al robot
+	g.camera = NewCamera(float64(screenWidth), float64(screenHeight), float64(width), float64(height))
+	g.camera.Follow = true
+	g.camera.LookAt(g.robot.Position.X, g.robot.Position.Y)
+
+	// Rastro y heatmap de cobertura
+	g.coverage = g.newCoverageOverlay()
+
+	// Decidir cuándo se rota el período de trabajo
+	if err := g.setupRotationPolicy(); err != nil {
+		log.Fatalf("Failed to set up period rotation: %v", err)
+	}
+	g.resetPeriodProgress()
+
+	// Programar la publicación de los sensores
+	if err := g.setupSensorSchedules(); err != nil {
+		log.Fatalf("Failed to schedule sensors: %v", err)
+	}
+
+	// Escuchar comandos remotos para este prototipo
+	g.startCommandConsumer()
 	
 	// Cargar sprites
 	g.LoadAssets()
 	
-	// Crear botones
-	g.spawnButton = Button{
-		X:      20,
-		Y:      float64(height - 110),
-		Width:  180,
-		Height: 40,
-		Text:   "Spawn Latas (S)",
-	}
+	// Panel de controles
+	g.ui = g.newControlPanel()
 	
-g.rechargeButton = Button{
-		X:      20,
-		Y:      float64(height - 60),
-		Width:  180,
-		Height: 40,
-		Text:   "Recargar (R)",
+	// Escenario guardado con el editor o, si no, spawn inicial
+	if settings.LoadScenario {
+		g.loadScenario()
+	} else {
+		g.SpawnCans(5, spawnMixed)
 	}
 	
-	// Spawn inicial
-	g.SpawnCans(5)
-	
 	return g
 }
 
@@ -141,7 +226,7 @@ func (g *Game) createInitialWorkPeriod() {
 
 	if newPeriodNeeded {
 		log.Println("No pending period found. Creating a new work period...")
-		if err := g.registerPeriods.CreateNewPeriod(); err != nil {
+		if err := g.registerPeriods.CreateNewPeriod(g.simNow()); err != nil {
 			log.Printf("Warning: Failed to create a new work period: %v", err)
 			return
 		}
@@ -151,7 +236,7 @@ func (g *Game) createInitialWorkPeriod() {
 		}
 	} else {
 		log.Println("Pending period found. Completing last period and creating a new one...")
-		if err := g.registerPeriods.CompleteLastPeriod(); err != nil {
+		if err := g.registerPeriods.CompleteLastPeriod(g.simNow()); err != nil {
 			log.Printf("Warning: Failed to complete pending work period: %v", err)
 			return
 		}
@@ -167,149 +252,33 @@ func (g *Game) createInitialWorkPeriod() {
 	}
 
 	log.Println("Successfully initialized work period.")
-	go g.backupService.Start()
+	g.submitCritical("backup", func(ctx context.Context) { g.backupService.Run(ctx, services.BackupOnStartup) })
 }
 
-func (g *Game) completeAndStartNewPeriod() {
-	if err := g.registerPeriods.CompleteLastPeriod(); err != nil {
-		log.Printf("Warning: Failed to complete work period: %v", err)
-		return
-	}
-	// Create initial waste collection records for the new period
-	if err := g.registerPeriods.CreateWasteCollection(1); err != nil {
-		log.Printf("Warning: Failed to create initial PET waste collection: %v", err)
-	}
-	if err := g.registerPeriods.CreateWasteCollection(2); err != nil {
-		log.Printf("Warning: Failed to create initial CAN waste collection: %v", err)
-	}
-	log.Println("Successfully completed last period and created new one with initial waste collections.")
-	go g.backupService.Start()
-}
-
-func (g *Game) LoadAssets() {
-	// Cargar sprites del robot
-	g.loadRobotSprites()
-	
-	// Cargar sprite de batería
-	g.loadBatterySprite()
-
-	
-	// Cargar sprite de la lata
-	var err error
-	g.canSprite, _, err = ebitenutil.NewImageFromFile("assets/trash-sprites/trash_types.png")
-	if err != nil {
-		log.Printf("No se pudo cargar trash_types.png: %v", err)
-		// Sprite temporal para la lata
-		g.canSprite = ebiten.NewImage(config.CanSize, config.CanSize)
-		g.canSprite.Fill(color.RGBA{255, 200, 50, 255})
-	}
-}
-
-func (g *Game) loadRobotSprites() {
-	sprites := make(map[string]*ebiten.Image)
-	
-spriteFiles := map[string]string{
-		"idle":  "assets/pybot-moves/pybot_idle.png",
-		"up":    "assets/pybot-moves/pybot_walk_up.png",
-		"left":  "assets/pybot-moves/pybot_walk_left.png",
-		"right": "assets/pybot-moves/pybot_walk_right.png",
-	}
-	
-	allLoaded := true
-	for name, path := range spriteFiles {
-		img, _, err := ebitenutil.NewImageFromFile(path)
-		if err != nil {
-			log.Printf("Error cargando sprite %s: %v", name, err)
-			allLoaded = false
-		} else {
-			sprites[name] = img
-		}
-	}
-	
-	if allLoaded && len(sprites) > 0 {
-		g.robot.Sprites = sprites
-	} else {
-		log.Println("Usando sprites temporales para el robot")
-		tempSprite := ebiten.NewImage(config.RobotSize, config.RobotSize)
-		tempSprite.Fill(color.RGBA{100, 150, 255, 255})
-		sprites["idle"] = tempSprite
-		sprites["up"] = tempSprite
-		sprites["left"] = tempSprite
-		sprites["right"] = tempSprite
-		g.robot.Sprites = sprites
-	}
-}
-
-func (g *Game) loadBatterySprite() {
-	img, _, err := ebitenutil.NewImageFromFile("assets/energy-buttons/battery_indicator.png")
-	if err != nil {
-		log.Printf("No se pudo cargar battery.png: %v", err)
-		// Sprite temporal
-		g.robot.BatterySprite = ebiten.NewImage(100, 25)
-		g.robot.BatterySprite.Fill(color.RGBA{0, 255, 0, 255})
-	} else {
-		g.robot.BatterySprite = img
-	}
-}
-
-func (g *Game) SpawnCans(count int) {
-    margin := float64(config.GridMargin)
-    
+// SpawnCans adds count items of itemType, or PET and cans at random with
+// spawnMixed. Only the spawn button and the S key use the type chosen on
+// the control panel; the initial spawn and remote commands stay mixed.
+func (g *Game) SpawnCans(count, itemType int) {
     for i := 0; i < count; i++ {
-        x := margin + g.rng.Float64()*(float64(g.width)-2*margin)
-        y := margin + g.rng.Float64()*(float64(g.height)-2*margin)
-        
-        var sprite *ebiten.Image
-        
-        if g.canSprite != nil {
-            // DIVIDIR EL SPRITESHEET EXACTAMENTE COMO CON LA BATERÍA
-            // Asumiendo que el spritesheet tiene 2 frames horizontales
-            frameWidth := g.canSprite.Bounds().Dx() / 4  // Ancho de cada frame
-            frameHeight := g.canSprite.Bounds().Dy()     // Alto total
-            
-            // Elegir frame aleatorio (0 o 1)
-            frameIndex := g.rng.Intn(2)
-            
-            // Calcular posición X del frame en el sprite sheet (MISMO MÉTODO QUE BATERÍA)
-            sx := frameIndex * frameWidth
-            frameRect := image.Rect(sx, 0, sx+frameWidth, frameHeight)
-            frameImg := g.canSprite.SubImage(frameRect).(*ebiten.Image)
-            
-            sprite = frameImg
-            log.Printf("Spawneando basura con frame %d", frameIndex)
-        } else {
-            // Fallback si no hay sprite cargado
-            sprite = ebiten.NewImage(config.CanSize, config.CanSize)
-            sprite.Fill(color.RGBA{255, 200, 50, 255})
+        // Solo donde el robot puede llegar, dentro de las zonas de aparición
+        p := g.spawnPoint()
+        if itemType != spawnMixed {
+            g.placeCan(p, itemType)
+            continue
         }
-        
-        can := entities.NewCan(x, y, sprite)
+        can := entities.NewCan(p.X, p.Y, nil)
+        can.Sprite = g.canSpriteFor(can.Type)
         g.cans = append(g.cans, can)
     }
 }
 
 func (g *Game) FindNearestCan() *entities.Can {
-	var nearest *entities.Can
-	minDistance := math.MaxFloat64
-	
-	for _, can := range g.cans {
-		if !can.Active {
-			continue
-		}
-		
-		distance := g.robot.Position.Distance(can.Position)
-		if distance < minDistance {
-			minDistance = distance
-			nearest = can
-		}
-	}
-	
-	return nearest
+	return systems.NearestCan(g.robot.Position, g.cans)
 }
 
 func (g *Game) CheckCollisions() {
 	robotPos := g.robot.Position
-	collectRadius := float64(config.RobotSize/2 + config.CanSize/2)
+	collectRadius := collectRadius()
 
 	for _, can := range g.cans {
 		if !can.Active {
@@ -321,19 +290,24 @@ func (g *Game) CheckCollisions() {
 		if distance < collectRadius {
 			can.Deactivate()
 			g.robot.CollectCan(can)
+			g.coverage.coverage.Collect(can.Position)
+			g.route = nil
 			log.Printf("¡Lata recogida! Tipo: %d, Peso: %.2f. Total Cans: %d, Total Peso: %.2f\n", can.Type, can.Weight, g.robot.CansCollected, g.robot.TotalWeight)
 			
 			// Handle the collection event
-			g.handleWasteCollection(can.WasteID, g.robot.TotalWeight)
+			g.handleWasteCollection(can)
 		}
 	}
 }
 
-func (g *Game) handleWasteCollection(wasteID int64, totalWeight float64) {
-	// Register the new total weight
-	g.weightSensor.RegisterWeight(totalWeight)
-	// Update the count for the specific waste type
-	g.weightSensor.UpdateWasteCount(wasteID)
+func (g *Game) handleWasteCollection(can *entities.Can) {
+	// Let the weight sensor publish the new total weight on its schedule
+	g.scheduler.Trigger(sensorWeight)
+	// Update the count for the specific waste type. The key is the same on
+	// every retry, so one pickup is never counted twice.
+	eventKey := fmt.Sprintf("collect-%s-%d", g.sessionID, can.ID)
+	wasteID := can.WasteID
+	g.submitCritical("waste_count", func(ctx context.Context) { g.weightSensor.UpdateWasteCount(wasteID, eventKey) })
 }
 
 func (g *Game) GetActiveCansCount() int {
@@ -347,59 +321,73 @@ func (g *Game) GetActiveCansCount() int {
 }
 
 func (g *Game) Update() error {
-	g.animationCounter++
-	g.HandleInput()
-
-	// Si el robot no tiene objetivo y tiene batería, buscar la lata más cercana
-	if g.robot.Target == nil && !g.robot.Battery.IsEmpty() {
-		nearest := g.FindNearestCan()
-		if nearest != nil {
-			g.robot.SetTarget(nearest.Position)
-		}
+	if ebiten.IsWindowBeingClosed() || g.exitRequested.Load() {
+		return ebiten.Termination
 	}
+	start := time.Now()
+	defer func() { g.debug.tickTime = time.Since(start) }()
 
-	// Handle real-time camera publishing
-	if !g.robot.Battery.IsEmpty() {
-		g.cameraTicks++
-		// Publish an image every 180 ticks (e.g., every 3 seconds at 60 TPS)
-		if g.cameraTicks >= 180 {
-			g.cameraTicks = 0
-			go g.realTimeCamera.PublishRandomImage()
-		}
+	g.ui.Update(g.screenWidth, g.screenHeight)
+	editing := g.updateEditor()
+	if !editing {
+		g.HandleInput()
+	}
+	g.updateControlMode()
+	g.updateCamera()
+	g.updateHUD()
+	g.updateCoverageInput()
+	g.updateDebug()
+	g.updateTimeControls()
+	g.processCommands()
+	g.applyRotationResult()
+
+	if editing {
+		return nil
+	}
+	n, dt := g.simSteps()
+	for i := 0; i < n; i++ {
+		g.step(dt)
+	}
+	return nil
+}
 
-		// Handle GPS publishing when moving
-		if g.robot.Velocity.X != 0 || g.robot.Velocity.Y != 0 {
-			g.gpsTicks++
-			// Publish GPS data every 60 ticks (e.g., every 1 second at 60 TPS)
-			if g.gpsTicks >= 60 {
-				g.gpsTicks = 0
-				go g.sendGPSData()
-			}
-		}
+// step advances the simulation by dt of simulated time.
+func (g *Game) step(dt time.Duration) {
+	// En modo manual manda el teclado; si no, si el robot no tiene objetivo
+	// y tiene batería, seguir la ruta o pedir el siguiente a la estrategia
+	if g.manual {
+		g.driveManually()
+	} else if g.robot.Target == nil && !g.robot.Battery.IsEmpty() && !g.nextWaypoint() {
+		g.nextTarget()
+	}
 
-	} else {
-		// Set flag when battery is depleted
-		if !g.batteryDepleted {
-			log.Println("Robot battery depleted.")
-			g.batteryDepleted = true
-		}
+	// Sensor publishing runs on simulation time
+	g.scheduler.Advance(dt)
+	g.clock.now = g.clock.now.Add(dt)
+
+	// Set flag when battery is depleted
+	if g.robot.Battery.IsEmpty() && !g.batteryDepleted {
+		log.Println("Robot battery depleted.")
+		g.batteryDepleted = true
 	}
 
-	g.robot.Update()
+	g.robot.Update(dt.Seconds())
+	if v := g.robot.Velocity; v.X != 0 || v.Y != 0 {
+		g.heading = v
+	}
+	g.animateRobot(dt)
+	g.coverage.coverage.Visit(g.robot.Position)
 	g.CheckCollisions()
-	return nil
-}
-
-func (g *Game) sendGPSData() {
-	gpsData := g.gpsSensor.GenerateGPSData(g.robot.Position, g.robot.Velocity)
-	g.gpsSensor.SendGPSData(gpsData)
+	g.checkRotation()
 }
 
+// Layout follows the window size: the camera viewport and the anchored
+// controls adapt to it.
 func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return g.width, g.height
-}
-
-func (g *Game) IsPointInButton(btn Button, x, y float64) bool {
-	return x >= btn.X && x <= btn.X+btn.Width &&
-		y >= btn.Y && y <= btn.Y+btn.Height
+	// A minimized window reports no size; keep the last one
+	if outsideWidth > 0 && outsideHeight > 0 && (outsideWidth != g.screenWidth || outsideHeight != g.screenHeight) {
+		g.screenWidth, g.screenHeight = outsideWidth, outsideHeight
+		g.camera.Resize(float64(outsideWidth), float64(outsideHeight))
+	}
+	return g.screenWidth, g.screenHeight
 }
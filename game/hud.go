@@ -0,0 +1,145 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+	"time"
+
+	"pybot-simulator/api/client"
+	"pybot-simulator/api/rabbitmq"
+	"pybot-simulator/entities"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// HUD panel layout, in screen pixels. It sits on the right, under the battery.
+const (
+	hudWidth      = 320.0
+	hudTop        = 80.0
+	hudPadding    = 10.0
+	hudLineHeight = 16
+)
+
+// hudToggleKey shows and hides the operations panel.
+const hudToggleKey = ebiten.KeyTab
+
+// sensorRoutingKeys maps each routing key to the scheduled sensor that
+// publishes on it, to tell how many of its messages wait in the worker pool.
+var sensorRoutingKeys = map[string]string{
+	rabbitmq.RoutingKeyCamera: sensorCamera,
+	rabbitmq.RoutingKeyGPS:    sensorGPS,
+	rabbitmq.RoutingKeyWeight: sensorWeight,
+}
+
+// updateHUD toggles the operations panel.
+func (g *Game) updateHUD() {
+	if inpututil.IsKeyJustPressed(hudToggleKey) {
+		g.showHUD = !g.showHUD
+	}
+}
+
+// DrawHUD draws the operations panel: what was collected, the work period,
+// the battery and the state of the broker and API connections.
+func (g *Game) DrawHUD(screen *ebiten.Image) {
+	// The editor's tool bar takes over the top of the screen
+	if !g.showHUD || g.editor.active {
+		return
+	}
+	lines := g.hudLines()
+
+	x := float64(g.screenWidth) - hudWidth - hudPadding
+	height := float64(len(lines)*hudLineHeight) + 2*hudPadding
+	ebitenutil.DrawRect(screen, x, hudTop, hudWidth, height, color.RGBA{20, 20, 30, 200})
+	for i, line := range lines {
+		ebitenutil.DebugPrintAt(screen, line, int(x+hudPadding), int(hudTop+hudPadding)+i*hudLineHeight)
+	}
+}
+
+func (g *Game) hudLines() []string {
+	robot := g.robot
+	lines := []string{
+		"OPERACIÓN (Tab = ocultar)",
+		fmt.Sprintf("Peso total: %.0f g", robot.TotalWeight),
+		fmt.Sprintf("PET: %d | Latas: %d", robot.CollectedByType[entities.PET], robot.CollectedByType[entities.CAN]),
+		fmt.Sprintf("Cobertura: %.1f%% (%s)", g.coverage.percent(), g.navigation.Name()),
+	}
+
+	lines = append(lines, fmt.Sprintf("Reloj simulado: %s (x%g)", g.simNow().Format("15:04:05"), g.clock.scale))
+
+	period := g.registerPeriods.PeriodStatus()
+	if period.PeriodID == 0 {
+		lines = append(lines, fmt.Sprintf("Período: ninguno (%s)", period.State))
+	} else {
+		start := period.StartHour
+		if t, err := time.Parse(time.RFC3339, period.StartHour); err == nil {
+			start = t.Format("15:04:05")
+		}
+		lines = append(lines, fmt.Sprintf("Período: #%d %s desde %s", period.PeriodID, period.State, start))
+	}
+
+	battery := robot.Battery
+	runtime := "-"
+	if battery.DrainRate > 0 {
+		runtime = (time.Duration(battery.Current/battery.DrainRate) * time.Second).String()
+	}
+	lines = append(lines, fmt.Sprintf("Batería: %.0f%% | Autonomía: %s", battery.GetPercentage()*100, runtime))
+
+	conn := rabbitmq.Connection()
+	broker := fmt.Sprintf("RabbitMQ: %d/%d conectados", conn.Connected, conn.Publishers)
+	if g.commandConsumer != nil {
+		if g.commandConsumer.Connected() {
+			broker += " | comandos: ok"
+		} else {
+			broker += " | comandos: caído"
+		}
+	}
+	lines = append(lines, broker)
+	lines = append(lines, apiHealthLine(g.registerPeriods.APIHealth()))
+
+	telemetry := g.registerPeriods.TelemetryStats()
+	lines = append(lines, fmt.Sprintf("Diario: %d pendientes | Telemetría: %d en lote",
+		g.registerPeriods.PendingOps(), telemetry.Queued))
+
+	lines = append(lines, fmt.Sprintf("%-12s %9s %9s %8s", "Routing key", "enviados", "fallidos", "en cola"))
+	pool := g.workers.Metrics()
+	stats := rabbitmq.Stats()
+	keys := make([]string, 0, len(stats)+len(sensorRoutingKeys))
+	for key := range stats {
+		keys = append(keys, key)
+	}
+	for key := range sensorRoutingKeys {
+		if _, ok := stats[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s := stats[key]
+		queued := s.InFlight
+		if sensor, ok := sensorRoutingKeys[key]; ok {
+			queued += pool.QueuedFor[sensor]
+		}
+		// Messages dropped for lack of a connection count as failed too
+		lines = append(lines, fmt.Sprintf("%-12s %9d %9d %8d", key, s.Sent, s.Failed+s.Skipped, queued))
+	}
+	return lines
+}
+
+// apiHealthLine describes the last call to the API.
+func apiHealthLine(h client.Health) string {
+	if h.Checked.IsZero() {
+		return "API: sin llamadas todavía"
+	}
+	age := time.Since(h.Checked).Round(time.Second)
+	switch {
+	case h.Err == "":
+		return fmt.Sprintf("API: ok (%d, %s) hace %s", h.Status, h.Latency.Round(time.Millisecond), age)
+	case h.Reachable:
+		return fmt.Sprintf("API: error %d hace %s", h.Status, age)
+	default:
+		return fmt.Sprintf("API: inalcanzable hace %s", age)
+	}
+}
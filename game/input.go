@@ -8,37 +8,25 @@ import (
 )
 
 func (g *Game) HandleInput() {
-	// Spawn de latas con tecla S
-	if inpututil.IsKeyJustPressed(ebiten.KeyS) {
-		g.SpawnCans(3)
+	// Spawn de latas con tecla S (en modo manual, S mueve el robot)
+	if !g.manual && inpututil.IsKeyJustPressed(ebiten.KeyS) {
+		g.SpawnCans(3, g.spawnType)
 	}
 
 	// Recargar batería con tecla R
 	if inpututil.IsKeyJustPressed(ebiten.KeyR) {
 		g.handleRecharge()
 	}
-
-	// Click en botones
-	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		mx, my := ebiten.CursorPosition()
-		fx, fy := float64(mx), float64(my)
-
-		if g.IsPointInButton(g.spawnButton, fx, fy) {
-			g.SpawnCans(3)
-		}
-
-		if g.IsPointInButton(g.rechargeButton, fx, fy) {
-			g.handleRecharge()
-		}
-	}
 }
 
 func (g *Game) handleRecharge() {
-	if g.batteryDepleted {
+	if g.batteryDepleted && g.rotation.RotateOnRecharge() {
 		log.Println("Battery was depleted, completing last work period and starting a new one.")
-		g.completeAndStartNewPeriod()
-		g.batteryDepleted = false
+		if err := g.startRotation(); err != nil {
+			log.Printf("Warning: %v", err)
+		}
 	}
+	g.batteryDepleted = false
 	g.robot.Battery.Recharge()
 }
 
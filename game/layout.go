@@ -0,0 +1,319 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"slices"
+
+	"pybot-simulator/config"
+	"pybot-simulator/entities"
+	"pybot-simulator/scenario"
+	"pybot-simulator/systems"
+	"pybot-simulator/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// openAreaCell is the collision cell size of the open area once it has
+// obstacles, in world pixels.
+const openAreaCell = 25
+
+// dockSize and binSize are the side of the dock and bin markers.
+const (
+	dockSize = 40.0
+	binSize  = 30.0
+)
+
+var (
+	obstacleColor  = color.NRGBA{90, 90, 100, 255}
+	spawnZoneColor = color.NRGBA{80, 220, 120, 60}
+	noGoZoneColor  = color.NRGBA{230, 60, 60, 70}
+	dockColor      = color.NRGBA{60, 200, 90, 255}
+	binColor       = color.NRGBA{160, 110, 60, 255}
+)
+
+// layout is what the editor can change on top of the map: placed obstacles,
+// spawn and no-go zones, the dock and the bin, plus the navigation built
+// from the map and all of them.
+type layout struct {
+	obstacles  []utils.Rect
+	spawnZones []utils.Rect // when there are any, random items only appear inside them
+	noGoZones  []utils.Rect // blocked like walls, for driving and for items
+	dock       utils.Vector2D
+	bin        *utils.Vector2D
+
+	nav   *systems.NavGrid // nil in an open area with nothing in the way
+	reach []bool           // by cell, whether the robot gets there from the dock
+	spawn []int            // cells reachable from the dock where items may appear
+}
+
+// rebuildNav rebuilds the collision and navigation grids after the map or
+// the layout changed, and hands the robot the new obstacles. On a map it
+// also moves the coverage area to the floor the robot now reaches.
+func (g *Game) rebuildNav() {
+	l := &g.layout
+	var grid *utils.CollisionGrid
+	switch {
+	case g.worldMap != nil:
+		grid = g.worldMap.tiles.CollisionGrid()
+	case len(l.obstacles) > 0 || len(l.noGoZones) > 0:
+		grid = g.openAreaGrid()
+	default:
+		l.nav, l.reach, l.spawn = nil, nil, nil
+		g.robot.Obstacles, g.robot.BodySize = nil, 0
+		return
+	}
+	for _, r := range l.obstacles {
+		grid.BlockRect(r)
+	}
+	for _, r := range l.noGoZones {
+		grid.BlockRect(r)
+	}
+	l.nav = systems.NewNavGrid(grid, config.RobotBodySize)
+	g.robot.Obstacles, g.robot.BodySize = grid, config.RobotBodySize
+
+	l.reach = make([]bool, grid.Cols*grid.Rows)
+	l.spawn = l.spawn[:0]
+	reached := l.nav.Reachable(l.dock)
+	for _, cell := range reached {
+		l.reach[cell] = true
+		center := grid.CellCenter(cell%grid.Cols, cell/grid.Cols)
+		if len(l.spawnZones) == 0 || inAny(l.spawnZones, center) {
+			l.spawn = append(l.spawn, cell)
+		}
+	}
+	// The coverage grid is the map grid; over the open area its cells differ
+	// and it keeps covering the whole world.
+	if g.worldMap != nil && g.coverage != nil {
+		g.coverage.coverage.Restrict(reached)
+	}
+	g.route = nil
+}
+
+// openAreaGrid is a collision grid over the open area with the margin
+// blocked, for when obstacles are placed on it.
+func (g *Game) openAreaGrid() *utils.CollisionGrid {
+	cols := int(math.Ceil(float64(g.width) / openAreaCell))
+	rows := int(math.Ceil(float64(g.height) / openAreaCell))
+	grid := utils.NewCollisionGrid(cols, rows, openAreaCell, openAreaCell)
+	m := float64(config.GridMargin)
+	w, h := float64(g.width), float64(g.height)
+	grid.BlockRect(utils.Rect{X: 0, Y: 0, W: w, H: m})
+	grid.BlockRect(utils.Rect{X: 0, Y: h - m, W: w, H: m})
+	grid.BlockRect(utils.Rect{X: 0, Y: 0, W: m, H: h})
+	grid.BlockRect(utils.Rect{X: w - m, Y: 0, W: m, H: h})
+	return grid
+}
+
+func inAny(rects []utils.Rect, p utils.Vector2D) bool {
+	for _, r := range rects {
+		if r.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// fits reports whether the robot fits at p.
+func (g *Game) fits(p utils.Vector2D) bool {
+	if !g.robot.InBounds(p.X, p.Y) {
+		return false
+	}
+	return g.layout.nav == nil || g.layout.nav.Fits(p)
+}
+
+// reachable reports whether the robot fits at p and can drive there from
+// the dock.
+func (g *Game) reachable(p utils.Vector2D) bool {
+	if !g.fits(p) {
+		return false
+	}
+	l := &g.layout
+	if l.nav == nil {
+		return true
+	}
+	grid := l.nav.Grid()
+	col, row := grid.Cell(p)
+	return col >= 0 && col < grid.Cols && row >= 0 && row < grid.Rows && l.reach[row*grid.Cols+col]
+}
+
+// dropUnreachableItems removes the items on the floor the robot can no
+// longer get to after the layout changed, and returns how many it removed.
+func (g *Game) dropUnreachableItems() int {
+	n := len(g.cans)
+	g.cans = slices.DeleteFunc(g.cans, func(can *entities.Can) bool {
+		return can.Active && !g.reachable(can.Position)
+	})
+	return n - len(g.cans)
+}
+
+// spawnPoint picks a random point for a new item: somewhere the robot can
+// reach, inside a spawn zone if there are any.
+func (g *Game) spawnPoint() utils.Vector2D {
+	l := &g.layout
+	if l.nav != nil {
+		if len(l.spawn) == 0 {
+			return l.dock
+		}
+		grid := l.nav.Grid()
+		cell := l.spawn[g.rng.Intn(len(l.spawn))]
+		center := grid.CellCenter(cell%grid.Cols, cell/grid.Cols)
+		p := utils.Vector2D{
+			X: center.X + (g.rng.Float64()-0.5)*grid.CellW,
+			Y: center.Y + (g.rng.Float64()-0.5)*grid.CellH,
+		}
+		if !l.nav.Fits(p) {
+			return center
+		}
+		return p
+	}
+
+	margin := float64(config.GridMargin)
+	area := utils.Rect{X: margin, Y: margin, W: float64(g.width) - 2*margin, H: float64(g.height) - 2*margin}
+	if len(l.spawnZones) > 0 {
+		area = clipRect(l.spawnZones[g.rng.Intn(len(l.spawnZones))], area)
+	}
+	return utils.Vector2D{X: area.X + g.rng.Float64()*area.W, Y: area.Y + g.rng.Float64()*area.H}
+}
+
+// clipRect is the part of r inside bounds; bounds itself if they don't overlap.
+func clipRect(r, bounds utils.Rect) utils.Rect {
+	x0, y0 := max(r.X, bounds.X), max(r.Y, bounds.Y)
+	x1, y1 := min(r.X+r.W, bounds.X+bounds.W), min(r.Y+r.H, bounds.Y+bounds.H)
+	if x1 <= x0 || y1 <= y0 {
+		return bounds
+	}
+	return utils.Rect{X: x0, Y: y0, W: x1 - x0, H: y1 - y0}
+}
+
+// scenarioFromLayout captures the layout and the items on the floor.
+func (g *Game) scenarioFromLayout() *scenario.Scenario {
+	l := &g.layout
+	s := &scenario.Scenario{Map: g.settings.MapPath, Dock: scenario.PointOf(l.dock)}
+	if l.bin != nil {
+		s.Bin = scenario.PointOf(*l.bin)
+	}
+	for _, can := range g.cans {
+		if !can.Active {
+			continue
+		}
+		itemType := scenario.ItemPET
+		if can.Type == entities.CAN {
+			itemType = scenario.ItemCan
+		}
+		s.Items = append(s.Items, scenario.Item{X: can.Position.X, Y: can.Position.Y, Type: itemType})
+	}
+	for _, r := range l.obstacles {
+		s.Obstacles = append(s.Obstacles, scenario.RectOf(r))
+	}
+	for _, r := range l.spawnZones {
+		s.SpawnZones = append(s.SpawnZones, scenario.RectOf(r))
+	}
+	for _, r := range l.noGoZones {
+		s.NoGoZones = append(s.NoGoZones, scenario.RectOf(r))
+	}
+	return s
+}
+
+// applyScenario replaces the layout and the items on the floor with s and
+// moves the robot to the dock.
+func (g *Game) applyScenario(s *scenario.Scenario) error {
+	l := layout{dock: g.layout.dock}
+	if s.Dock != nil {
+		l.dock = s.Dock.Vector()
+	}
+	if s.Bin != nil {
+		bin := s.Bin.Vector()
+		l.bin = &bin
+	}
+	for _, r := range s.Obstacles {
+		l.obstacles = append(l.obstacles, r.Rect())
+	}
+	for _, r := range s.SpawnZones {
+		l.spawnZones = append(l.spawnZones, r.Rect())
+	}
+	for _, r := range s.NoGoZones {
+		l.noGoZones = append(l.noGoZones, r.Rect())
+	}
+
+	previous := g.layout
+	g.layout = l
+	g.rebuildNav()
+	if !g.fits(l.dock) {
+		g.layout = previous
+		g.rebuildNav()
+		return fmt.Errorf("the dock (%.0f, %.0f) is blocked; the robot would not fit there", l.dock.X, l.dock.Y)
+	}
+
+	g.cans = g.cans[:0]
+	skipped := 0
+	for _, item := range s.Items {
+		p := utils.Vector2D{X: item.X, Y: item.Y}
+		if !g.reachable(p) {
+			skipped++
+			continue
+		}
+		canType := entities.PET
+		if item.Type == scenario.ItemCan {
+			canType = entities.CAN
+		}
+		g.placeCan(p, canType)
+	}
+	if skipped > 0 {
+		log.Printf("Scenario: skipped %d items the robot cannot reach", skipped)
+	}
+	g.clearRoute()
+	g.robot.Position = l.dock
+	return nil
+}
+
+// placeCan puts an item of the given type at p.
+func (g *Game) placeCan(p utils.Vector2D, canType int) *entities.Can {
+	can := entities.NewCanOfType(p.X, p.Y, canType, g.canSpriteFor(canType))
+	g.cans = append(g.cans, can)
+	return can
+}
+
+// DrawLayout draws the placed obstacles, the dock and the bin and, while
+// editing or debugging, the spawn and no-go zones.
+func (g *Game) DrawLayout(screen *ebiten.Image) {
+	l := &g.layout
+	if g.editor.active || g.debug.show {
+		for _, r := range l.spawnZones {
+			g.fillWorldRect(screen, r, spawnZoneColor)
+		}
+		for _, r := range l.noGoZones {
+			g.fillWorldRect(screen, r, noGoZoneColor)
+		}
+	}
+	for _, r := range l.obstacles {
+		g.fillWorldRect(screen, r, obstacleColor)
+	}
+	g.drawMarker(screen, l.dock, dockSize, dockColor, "BASE")
+	if l.bin != nil {
+		g.drawMarker(screen, *l.bin, binSize, binColor, "CONTENEDOR")
+	}
+}
+
+func (g *Game) fillWorldRect(screen *ebiten.Image, r utils.Rect, clr color.Color) {
+	if !g.camera.Visible(r.X, r.Y, r.W, r.H) {
+		return
+	}
+	x, y := g.camera.WorldToScreen(r.X, r.Y)
+	zoom := g.camera.Zoom
+	vector.FillRect(screen, float32(x), float32(y), float32(r.W*zoom), float32(r.H*zoom), clr, false)
+}
+
+func (g *Game) drawMarker(screen *ebiten.Image, p utils.Vector2D, size float64, clr color.Color, label string) {
+	if !g.camera.Visible(p.X-size/2, p.Y-size/2, size, size) {
+		return
+	}
+	x, y := g.camera.WorldToScreen(p.X-size/2, p.Y-size/2)
+	side := float32(size * g.camera.Zoom)
+	vector.StrokeRect(screen, float32(x), float32(y), side, side, 3, clr, false)
+	ebitenutil.DebugPrintAt(screen, label, int(x), int(y)-16)
+}
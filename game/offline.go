@@ -0,0 +1,49 @@
+package game
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// flushOfflineJournal tries once to send what is left in the offline journal.
+func (g *Game) flushOfflineJournal() {
+	if g.registerPeriods.PendingOps() == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := g.registerPeriods.Flush(ctx); err != nil {
+		log.Printf("Warning: Offline journal not fully sent: %v", err)
+	}
+}
+
+// startOfflineSync replays the offline journal every sync_interval, and runs
+// the backup every backup_interval, until stopOfflineSync is called.
+func (g *Game) startOfflineSync() {
+	ctx, cancel := context.WithCancel(context.Background())
+	g.stopSync = cancel
+	g.syncDone = make(chan struct{})
+	go func() {
+		defer close(g.syncDone)
+		var backups sync.WaitGroup
+		if g.settings.BackupInterval > 0 {
+			backups.Add(1)
+			go func() {
+				defer backups.Done()
+				g.backupService.RunEvery(ctx, g.settings.BackupInterval)
+			}()
+		}
+		g.registerPeriods.RunSync(ctx, g.settings.SyncInterval)
+		backups.Wait()
+	}()
+}
+
+func (g *Game) stopOfflineSync() {
+	if g.stopSync == nil {
+		return
+	}
+	g.stopSync()
+	<-g.syncDone
+}
@@ -2,8 +2,8 @@ package game
 
 import (
 	"fmt"
-	"image"
 	"image/color"
+	"math"
 
 	"pybot-simulator/config"
 
@@ -14,21 +14,34 @@ import (
 func (g *Game) Draw(screen *ebiten.Image) {
 	screen.Fill(color.RGBA{40, 40, 50, 255})
 	g.DrawPlayArea(screen)
+	g.DrawLayout(screen)
+	g.DrawCoverage(screen)
 	g.DrawCans(screen)
 	g.DrawRobot(screen)
+	g.DrawDebug(screen)
 	g.DrawBattery(screen)
-	g.DrawButtons(screen)
 	g.DrawInfo(screen)
+	g.DrawHUD(screen)
+	g.DrawEditor(screen)
+	g.ui.Draw(screen)
 }
 
+// floorGridSpacing is the distance between floor grid lines, in world pixels.
+const floorGridSpacing = 100.0
+
 func (g *Game) DrawPlayArea(screen *ebiten.Image) {
+	if g.worldMap != nil {
+		g.worldMap.draw(screen, g.camera)
+		return
+	}
+	g.drawFloorGrid(screen)
+
 	margin := float64(config.GridMargin)
 	areaColor := color.RGBA{80, 80, 100, 255}
 	
-	x := margin
-	y := margin
-	w := float64(g.width) - 2*margin
-	h := float64(g.height) - 2*margin
+	x, y := g.camera.WorldToScreen(margin, margin)
+	w := (float64(g.width) - 2*margin) * g.camera.Zoom
+	h := (float64(g.height) - 2*margin) * g.camera.Zoom
 	
 	ebitenutil.DrawRect(screen, x, y, w, 2, areaColor)
 	ebitenutil.DrawRect(screen, x, y+h-2, w, 2, areaColor)
@@ -36,72 +49,60 @@ func (g *Game) DrawPlayArea(screen *ebiten.Image) {
 	ebitenutil.DrawRect(screen, x+w-2, y, 2, h, areaColor)
 }
 
-func (g *Game) DrawRobot(screen *ebiten.Image) {
-	pos := g.robot.Position
-	vel := g.robot.Velocity
-	
-	// Seleccionar el sprite correcto según el movimiento
-	var spriteName string
-	
-	if vel.X == 0 && vel.Y == 0 {
-		spriteName = "idle"
-	} else {
-		// Priorizar dirección horizontal sobre vertical
-		if vel.X < -0.1 {
-			spriteName = "left"
-		} else if vel.X > 0.1 {
-			spriteName = "right"
-		} else if vel.Y < 0 {
-			spriteName = "up"
-		} else {
-			spriteName = "idle"
-		}
+// drawFloorGrid draws faint lines over the visible part of the world, so
+// panning and zooming can be seen even on an empty floor.
+func (g *Game) drawFloorGrid(screen *ebiten.Image) {
+	cam := g.camera
+	gridColor := color.RGBA{48, 48, 60, 255}
+
+	// Visible part of the world, in world pixels
+	x0, y0 := cam.ScreenToWorld(0, 0)
+	x1, y1 := cam.ScreenToWorld(float64(g.screenWidth), float64(g.screenHeight))
+	x0, y0 = max(x0, 0), max(y0, 0)
+	x1, y1 = min(x1, float64(g.width)), min(y1, float64(g.height))
+
+	left, top := cam.WorldToScreen(x0, y0)
+	right, bottom := cam.WorldToScreen(x1, y1)
+	for x := math.Ceil(x0/floorGridSpacing) * floorGridSpacing; x <= x1; x += floorGridSpacing {
+		sx, _ := cam.WorldToScreen(x, 0)
+		ebitenutil.DrawRect(screen, sx, top, 1, bottom-top, gridColor)
 	}
-	
-	currentSprite := g.robot.Sprites[spriteName]
-	
-	// Si el sprite no existe, usar idle como fallback
-	if currentSprite == nil {
-		currentSprite = g.robot.Sprites["idle"]
+	for y := math.Ceil(y0/floorGridSpacing) * floorGridSpacing; y <= y1; y += floorGridSpacing {
+		_, sy := cam.WorldToScreen(0, y)
+		ebitenutil.DrawRect(screen, left, sy, right-left, 1, gridColor)
 	}
-	
-	// Si aún no hay sprite, no dibujar nada
-	if currentSprite == nil {
+}
+
+func (g *Game) DrawRobot(screen *ebiten.Image) {
+	frameImg := g.robotFrame()
+	if frameImg == nil {
 		return
 	}
-	
-	// Calcular el frame actual de la animación (4 frames por sprite)
-	frameWidth := 75.0 // 300px / 4 frames = 75px por frame
-	frameHeight := 75.0
-	
-	// Animar solo cuando se está moviendo
-	frameIndex := 0
-	if vel.X != 0 || vel.Y != 0 {
-		// Ciclar entre frames 0, 1, 2, 3 basado en el tiempo
-		frameIndex = (g.animationCounter / 8) % 4 // Cambia de frame cada 8 ticks
+	pos := g.robot.Position
+	frameWidth := float64(frameImg.Bounds().Dx())
+	frameHeight := float64(frameImg.Bounds().Dy())
+
+	// Fuera de la vista no se dibuja
+	if !g.camera.Visible(pos.X-frameWidth/2, pos.Y-frameHeight/2, frameWidth, frameHeight) {
+		return
 	}
-	
-	// Crear subimagen del frame actual
-	sx := float64(frameIndex) * frameWidth
-	frameRect := image.Rect(int(sx), 0, int(sx+frameWidth), int(frameHeight))
-	frameImg := currentSprite.SubImage(frameRect).(*ebiten.Image)
-	
+
 	// Dibujar el frame centrado en la posición del robot
 	op := &ebiten.DrawImageOptions{}
 	op.GeoM.Translate(-frameWidth/2, -frameHeight/2)
 	op.GeoM.Translate(pos.X, pos.Y)
-	
+	op.GeoM.Concat(g.camera.GeoM())
+
 	screen.DrawImage(frameImg, op)
 }
 
 func (g *Game) DrawBattery(screen *ebiten.Image) {
 	// Posición de la batería (esquina superior derecha)
-	batteryX := float64(g.width) - 110.0
+	batteryX := float64(g.screenWidth) - 110.0
 	batteryY := 10.0
 	
-	batteryLevel := g.robot.GetBatteryLevel()
-	
-	if g.robot.BatterySprite == nil {
+	frameImg := g.batteryFrame()
+	if frameImg == nil {
 		// Dibujar batería simple si no hay sprite
 		colors := []color.RGBA{
 			{50, 255, 50, 255},   // Verde lleno (>75%)
@@ -110,7 +111,7 @@ func (g *Game) DrawBattery(screen *ebiten.Image) {
 			{255, 50, 50, 255},   // Rojo (<25%)
 		}
 		
-		barColor := colors[batteryLevel]
+		barColor := colors[g.robot.GetBatteryLevel()]
 		width := 100.0
 		height := 30.0
 		
@@ -127,19 +128,6 @@ func (g *Game) DrawBattery(screen *ebiten.Image) {
 		ebitenutil.DrawRect(screen, batteryX, batteryY, 2, height, color.RGBA{200, 200, 200, 255})
 		ebitenutil.DrawRect(screen, batteryX+width-2, batteryY, 2, height, color.RGBA{200, 200, 200, 255})
 	} else {
-		// Dibujar sprite de batería (300x60px dividido en 4 frames de 75x60px)
-		// Frame 0 (izq) = Lleno | Frame 3 (der) = Vacío
-		frameWidth := 60.0  // 300px ÷ 4 frames = 75px
-		frameHeight := 60.0
-		
-		// Obtener frame según nivel (0=lleno, 3=vacío)
-		frameIndex := batteryLevel
-		
-		// Calcular posición X del frame en el sprite sheet
-		sx := float64(frameIndex) * frameWidth
-		frameRect := image.Rect(int(sx), 0, int(sx+frameWidth), int(frameHeight))
-		frameImg := g.robot.BatterySprite.SubImage(frameRect).(*ebiten.Image)
-		
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Translate(batteryX, batteryY)
 		screen.DrawImage(frameImg, op)
@@ -147,39 +135,26 @@ func (g *Game) DrawBattery(screen *ebiten.Image) {
 }
 
 func (g *Game) DrawCans(screen *ebiten.Image) {
+	camGeoM := g.camera.GeoM()
 	for _, can := range g.cans {
-		if !can.Active {
+		if !can.Active || can.Sprite == nil {
 			continue
 		}
-		
+
+		// El sprite va centrado en la posición, sea cual sea su tamaño
 		pos := can.Position
+		w, h := float64(can.Sprite.Bounds().Dx()), float64(can.Sprite.Bounds().Dy())
+		if !g.camera.Visible(pos.X-w/2, pos.Y-h/2, w, h) {
+			continue
+		}
 		op := &ebiten.DrawImageOptions{}
-		op.GeoM.Translate(-config.CanSize/2, -config.CanSize/2)
+		op.GeoM.Translate(-w/2, -h/2)
 		op.GeoM.Translate(pos.X, pos.Y)
-		
-		if can.Sprite != nil {
-			screen.DrawImage(can.Sprite, op)
-		}
+		op.GeoM.Concat(camGeoM)
+		screen.DrawImage(can.Sprite, op)
 	}
 }
 
-func (g *Game) DrawButtons(screen *ebiten.Image) {
-	g.DrawButton(screen, g.spawnButton)
-	g.DrawButton(screen, g.rechargeButton)
-}
-
-func (g *Game) DrawButton(screen *ebiten.Image, btn Button) {
-	ebitenutil.DrawRect(screen, btn.X, btn.Y, btn.Width, btn.Height,
-		color.RGBA{70, 130, 180, 255})
-	
-	ebitenutil.DrawRect(screen, btn.X, btn.Y, btn.Width, 2, color.RGBA{200, 200, 200, 255})
-	ebitenutil.DrawRect(screen, btn.X, btn.Y+btn.Height-2, btn.Width, 2, color.RGBA{200, 200, 200, 255})
-	ebitenutil.DrawRect(screen, btn.X, btn.Y, 2, btn.Height, color.RGBA{200, 200, 200, 255})
-	ebitenutil.DrawRect(screen, btn.X+btn.Width-2, btn.Y, 2, btn.Height, color.RGBA{200, 200, 200, 255})
-	
-	ebitenutil.DebugPrintAt(screen, btn.Text, int(btn.X+15), int(btn.Y+13))
-}
-
 func (g *Game) DrawInfo(screen *ebiten.Image) {
 	activeCans := g.GetActiveCansCount()
 	
@@ -188,17 +163,37 @@ func (g *Game) DrawInfo(screen *ebiten.Image) {
 	ebitenutil.DebugPrintAt(screen, info, 10, 10)
 	
 	status := "Estado: Buscando latas"
-	if g.robot.Battery.IsCharging {
+	if g.editor.active {
+		status = "Estado: EDITANDO"
+	} else if g.paused {
+		status = "Estado: PAUSADO"
+	} else if g.robot.Battery.IsCharging {
 		status = "Estado: CARGANDO"
 	} else if g.robot.Battery.IsEmpty() {
 		status = "Estado: SIN BATERÍA"
+	} else if g.manual {
+		status = "Estado: MANUAL (WASD/Flechas)"
 	} else if g.robot.Target != nil {
 		status = "Estado: Recolectando"
 	} else if activeCans == 0 {
 		status = "Estado: Esperando latas"
 	}
+	status += fmt.Sprintf(" | Tiempo x%g (P = Pausa, N = Paso, +/- = Velocidad, 0 = x1)", g.clock.scale)
 	ebitenutil.DebugPrintAt(screen, status, 10, 30)
 	
-	controls := "Controles: S = Spawn latas | R = Recargar"
+	controls := "Controles: S = Spawn | R = Recargar | M = Manual | Tab = Panel | T = Rastro | H = Heatmap (Shift+H = PNG) | F1 = Depuración | E = Editor"
 	ebitenutil.DebugPrintAt(screen, controls, 10, 50)
+
+	backup := "Backup: pendiente"
+	if last, ok := g.backupService.Last(); ok {
+		backup = fmt.Sprintf("Backup: %s (%s)", last.Status(), last.At.Format("15:04:05"))
+	}
+	ebitenutil.DebugPrintAt(screen, backup, 10, 70)
+
+	camera := fmt.Sprintf("Cámara: x%.2f", g.camera.Zoom)
+	if g.camera.Follow {
+		camera += " siguiendo al robot"
+	}
+	camera += " | Rueda = Zoom | Clic der./Flechas = Mover | F = Seguir | Inicio = Reiniciar"
+	ebitenutil.DebugPrintAt(screen, camera, 10, 90)
 }
\ No newline at end of file
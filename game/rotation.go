@@ -0,0 +1,132 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pybot-simulator/api/client"
+	"pybot-simulator/api/services"
+	"pybot-simulator/systems"
+)
+
+// rotationRetryDelay spaces out retries when a policy-driven rotation fails
+// (for example while the backend is unreachable).
+const rotationRetryDelay = 30 * time.Second
+
+// errRotationInProgress is returned when a rotation is requested while the
+// previous one is still running.
+var errRotationInProgress = errors.New("work period rotation already in progress")
+
+// setupRotationPolicy builds the period rotation policy from the settings.
+func (g *Game) setupRotationPolicy() error {
+	at, err := g.settings.RotationShiftTimes()
+	if err != nil {
+		return err
+	}
+	policy, err := systems.NewRotationPolicy(g.settings.RotationPolicy, g.settings.RotationEvery, at,
+		g.settings.RotationMaxItems, g.settings.RotationMaxWeight)
+	if err != nil {
+		return err
+	}
+	g.rotation = policy
+	log.Printf("Work period rotation policy: %s", policy.Name())
+	return nil
+}
+
+// resetPeriodProgress marks the start of a new work period: item and weight
+// counts for the rotation policy are measured from here. The period clock
+// runs on simulated time, so shifts pass faster at higher time scales; the
+// start and end hours sent to the backend use the same clock, so a simulated
+// one-hour shift is stored as one hour.
+func (g *Game) resetPeriodProgress() {
+	g.periodStart = g.simNow()
+	g.periodItemsBase = g.robot.CansCollected
+	g.periodWeightBase = g.robot.TotalWeight
+	g.rotationRetryAt = time.Time{}
+}
+
+// periodProgress reports how far the current work period has gone.
+func (g *Game) periodProgress() systems.PeriodProgress {
+	return systems.PeriodProgress{
+		Start:  g.periodStart,
+		Now:    g.simNow(),
+		Items:  g.robot.CansCollected - g.periodItemsBase,
+		Weight: g.robot.TotalWeight - g.periodWeightBase,
+	}
+}
+
+// checkRotation rotates the work period when the policy asks for it.
+// Retries after a failure are spaced in wall-clock time, since they wait on
+// the backend.
+func (g *Game) checkRotation() {
+	progress := g.periodProgress()
+	if time.Now().Before(g.rotationRetryAt) || !g.rotation.ShouldRotate(progress) {
+		return
+	}
+	if g.rotating {
+		return
+	}
+	log.Printf("Rotation policy %s: completing work period (%d items, %.2f weight)",
+		g.rotation.Name(), progress.Items, progress.Weight)
+	g.startRotation()
+}
+
+// startRotation closes the current work period and opens a new one on the
+// worker pool: the backend calls retry with backoff, and the game loop must
+// not wait for them. applyRotationResult picks up the outcome on a later tick.
+// It returns an error when no rotation was started.
+func (g *Game) startRotation() error {
+	if g.rotating {
+		return errRotationInProgress
+	}
+	g.rotating = true
+	at := g.simNow()
+	err := g.submitCritical("rotation", func(ctx context.Context) {
+		g.rotationDone <- g.rotatePeriod(at)
+	})
+	if err != nil {
+		g.rotating = false
+		g.rotationRetryAt = time.Now().Add(rotationRetryDelay)
+		return fmt.Errorf("starting work period rotation: %w", err)
+	}
+	return nil
+}
+
+// rotatePeriod runs on the worker pool. It completes the current work period
+// at the simulated time at, opens a new one with its waste collections and
+// reports whether the new period was started. It must not touch game state.
+func (g *Game) rotatePeriod(at time.Time) bool {
+	if err := g.registerPeriods.CompleteLastPeriod(at); err != nil {
+		log.Printf("Warning: Failed to complete work period: %v", err)
+		return false
+	}
+	// Create initial waste collection records for the new period
+	if err := g.registerPeriods.CreateWasteCollection(client.WastePET); err != nil {
+		log.Printf("Warning: Failed to create initial PET waste collection: %v", err)
+	}
+	if err := g.registerPeriods.CreateWasteCollection(client.WasteCans); err != nil {
+		log.Printf("Warning: Failed to create initial CAN waste collection: %v", err)
+	}
+	log.Println("Successfully completed last period and created new one with initial waste collections.")
+	return true
+}
+
+// applyRotationResult applies a finished rotation on the game tick: a new
+// period restarts the rotation counters and triggers a backup, a failed one
+// is retried after rotationRetryDelay.
+func (g *Game) applyRotationResult() {
+	select {
+	case ok := <-g.rotationDone:
+		g.rotating = false
+		if !ok {
+			g.rotationRetryAt = time.Now().Add(rotationRetryDelay)
+			return
+		}
+		g.resetPeriodProgress()
+		g.submitCritical("backup", func(ctx context.Context) { g.backupService.Run(ctx, services.BackupOnRotation) })
+	default:
+	}
+}
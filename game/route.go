@@ -0,0 +1,58 @@
+package game
+
+import (
+	"slices"
+
+	"pybot-simulator/entities"
+	"pybot-simulator/utils"
+)
+
+// goTo sends the robot to target. With walls or obstacles the robot follows
+// a route around them, one waypoint at a time; in an empty open area it
+// drives straight. It reports false when target cannot be reached.
+func (g *Game) goTo(target utils.Vector2D) bool {
+	if g.layout.nav == nil {
+		g.route = nil
+		g.robot.SetTarget(target)
+		return true
+	}
+	path := g.layout.nav.FindPath(g.robot.Position, target)
+	if path == nil {
+		return false
+	}
+	g.robot.SetTarget(path[0])
+	g.route = path[1:]
+	return true
+}
+
+// nextTarget asks the navigation strategy where to go next and sends the
+// robot there. Items goTo cannot route to are left out and the strategy is
+// asked again, so one unreachable item doesn't stall the robot.
+func (g *Game) nextTarget() {
+	cans := g.cans
+	for range len(g.cans) + 1 {
+		target := g.navigation.NextTarget(g.robot.Position, cans)
+		if target == nil || g.goTo(*target) {
+			return
+		}
+		cans = slices.DeleteFunc(slices.Clone(cans), func(can *entities.Can) bool {
+			return can.Position == *target
+		})
+	}
+}
+
+// nextWaypoint hands the robot the next point of its route, if any.
+func (g *Game) nextWaypoint() bool {
+	if len(g.route) == 0 {
+		return false
+	}
+	g.robot.SetTarget(g.route[0])
+	g.route = g.route[1:]
+	return true
+}
+
+// clearRoute stops the robot and drops the rest of its route.
+func (g *Game) clearRoute() {
+	g.route = nil
+	g.robot.ClearTarget()
+}
@@ -0,0 +1,108 @@
+package game
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"pybot-simulator/config"
+	"pybot-simulator/entities"
+	"pybot-simulator/systems"
+	"pybot-simulator/utils"
+)
+
+// Names of the scheduled sensors.
+const (
+	sensorCamera = "camera"
+	sensorGPS    = "gps"
+	sensorWeight = "weight"
+)
+
+// setupSensorSchedules registers every sensor with the rate, jitter and
+// trigger mode taken from the settings.
+func (g *Game) setupSensorSchedules() error {
+	g.scheduler = systems.NewScheduler(g.rng)
+
+	hasBattery := func() bool { return !g.robot.Battery.IsEmpty() }
+	isMoving := func() bool {
+		return hasBattery() && (g.robot.Velocity.X != 0 || g.robot.Velocity.Y != 0)
+	}
+
+	schedules := []struct {
+		name     string
+		mode     string
+		schedule systems.SensorSchedule
+	}{
+		{sensorCamera, g.settings.CameraMode, systems.SensorSchedule{
+			Interval: g.settings.CameraInterval,
+			Jitter:   g.settings.CameraJitter,
+			Enabled:  hasBattery,
+			Probe:    g.cameraState,
+			Fire:     g.publishCameraImage,
+		}},
+		{sensorGPS, g.settings.GPSMode, systems.SensorSchedule{
+			Interval: g.settings.GPSInterval,
+			Jitter:   g.settings.GPSJitter,
+			Enabled:  isMoving,
+			Probe:    func() interface{} { return g.robot.Position },
+			Fire:     g.publishGPSData,
+		}},
+		{sensorWeight, g.settings.WeightMode, systems.SensorSchedule{
+			Interval: g.settings.WeightInterval,
+			Jitter:   g.settings.WeightJitter,
+			Probe:    func() interface{} { return g.robot.TotalWeight },
+			Fire:     g.publishWeight,
+		}},
+	}
+
+	for _, s := range schedules {
+		mode, err := systems.ParseTriggerMode(s.mode)
+		if err != nil {
+			return err
+		}
+		s.schedule.Name = s.name
+		s.schedule.Mode = mode
+		if err := g.scheduler.Register(s.schedule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cameraView is what the camera sees: the active items in its field of
+// view, in front of the robot.
+func (g *Game) cameraView() []*entities.Can {
+	return systems.Detect(g.robot.Position, g.heading, g.cans, config.CameraRange, config.CameraFOV)
+}
+
+// cameraState is the camera's on-change probe: the IDs of the items in
+// view, so the camera publishes when an item enters or leaves the frame.
+func (g *Game) cameraState() interface{} {
+	var ids strings.Builder
+	for _, can := range g.cameraView() {
+		ids.WriteString(strconv.FormatInt(can.ID, 10))
+		ids.WriteByte(',')
+	}
+	return ids.String()
+}
+
+func (g *Game) publishCameraImage() {
+	if g.realTimeCamera != nil {
+		g.submit(sensorCamera, g.realTimeCamera.PublishRandomImage)
+	}
+}
+
+func (g *Game) publishGPSData() {
+	position, velocity := g.robot.Position, g.robot.Velocity
+	g.submit(sensorGPS, func(ctx context.Context) { g.sendGPSData(ctx, position, velocity) })
+}
+
+func (g *Game) publishWeight() {
+	totalWeight := g.robot.TotalWeight
+	g.submit(sensorWeight, func(ctx context.Context) { g.weightSensor.RegisterWeight(ctx, totalWeight) })
+}
+
+func (g *Game) sendGPSData(ctx context.Context, position, velocity utils.Vector2D) {
+	gpsData := g.gpsSensor.GenerateGPSData(position, velocity)
+	g.gpsSensor.SendGPSData(ctx, gpsData)
+}
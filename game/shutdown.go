@@ -0,0 +1,105 @@
+package game
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pybot-simulator/api/workers"
+	"pybot-simulator/config"
+)
+
+// finalCallTimeout bounds the period close and the journal flush at
+// shutdown. Each gets its own deadline, so a slow worker drain that used up
+// the shutdown context still leaves them time to run.
+const finalCallTimeout = 5 * time.Second
+
+// submit queues a sensor publish on the worker pool. When the queue is full
+// the job is dropped and counted, instead of piling up goroutines: the next
+// reading replaces it.
+func (g *Game) submit(name string, job workers.Job) {
+	if err := g.workers.Submit(name, job); err != nil {
+		log.Printf("Warning: Dropped background job %s: %v", name, err)
+	}
+}
+
+// submitCritical queues background work that must not be lost, such as a
+// collection count or a command ack. A full queue does not drop it; it only
+// fails once the pool is shut down.
+func (g *Game) submitCritical(name string, job workers.Job) error {
+	err := g.workers.SubmitCritical(name, job)
+	if err != nil {
+		log.Printf("Warning: Could not run background job %s: %v", name, err)
+	}
+	return err
+}
+
+// RequestExit asks the game loop to stop on its next tick. It is safe to
+// call from any goroutine (for example a signal handler).
+func (g *Game) RequestExit() {
+	g.exitRequested.Store(true)
+}
+
+// Shutdown flushes pending publishes and API calls, then stops accepting
+// remote commands, closes the active work period and closes every broker
+// connection. It gives up on pending work when ctx expires.
+func (g *Game) Shutdown(ctx context.Context) error {
+	log.Println("Shutting down: flushing background work...")
+	endHour := g.simNow()
+
+	g.stopOfflineSync()
+
+	// Queued jobs may still publish or ack on the broker connections, so they
+	// drain before anything is closed
+	err := g.workers.Shutdown(ctx)
+	m := g.workers.Metrics()
+	log.Printf("Background work: %d completed, %d dropped, %d overflowed, %d still queued", m.Completed, m.Dropped, m.Overflowed, m.Queued)
+	if err != nil {
+		log.Printf("Warning: Shutdown timed out before all background work finished: %v", err)
+	}
+
+	if g.commandConsumer != nil {
+		g.commandConsumer.Close()
+	}
+
+	if closeErr := g.closeActivePeriod(endHour); closeErr != nil {
+		log.Printf("Warning: Failed to close the active work period: %v", closeErr)
+		if err == nil {
+			err = closeErr
+		}
+	} else {
+		log.Println("Active work period closed.")
+	}
+
+	// Whatever could not be sent stays in the journal for the next session
+	if g.registerPeriods.PendingOps() > 0 {
+		flushCtx, cancel := context.WithTimeout(context.Background(), finalCallTimeout)
+		if flushErr := g.registerPeriods.Flush(flushCtx); flushErr != nil {
+			log.Printf("Warning: %d API calls kept in the offline journal: %v", g.registerPeriods.PendingOps(), flushErr)
+		}
+		cancel()
+	}
+	g.registerPeriods.Close()
+	if t := g.registerPeriods.TelemetryStats(); t.Batches > 0 || t.Singles > 0 {
+		log.Printf("Telemetry: %d batches (%d records, avg %s, max %s), %d sent one by one",
+			t.Batches, t.Records, t.AvgLatency().Round(time.Millisecond), t.MaxLatency.Round(time.Millisecond), t.Singles)
+	}
+
+	if g.realTimeCamera != nil {
+		g.realTimeCamera.Close()
+	}
+	g.gpsSensor.Close()
+	g.weightSensor.Close()
+	return err
+}
+
+// closeActivePeriod pushes the final distance/weight reading and closes the
+// current period at the simulated endHour, bounded by finalCallTimeout.
+func (g *Game) closeActivePeriod(endHour time.Time) error {
+	distance := g.robot.DistanceTraveled * config.MetersPerPixel
+	weight := g.robot.TotalWeight
+
+	ctx, cancel := context.WithTimeout(context.Background(), finalCallTimeout)
+	defer cancel()
+	return g.registerPeriods.ClosePeriod(ctx, endHour, distance, weight)
+}
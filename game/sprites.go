@@ -0,0 +1,159 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"time"
+
+	"pybot-simulator/atlas"
+	"pybot-simulator/config"
+	"pybot-simulator/entities"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Atlas files. Frame geometry, timing and clip names live in them, next to
+// the images, so new sprites or directions need no code changes.
+const (
+	robotAtlasPath   = "assets/pybot-moves/pybot.atlas.json"
+	batteryAtlasPath = "assets/energy-buttons/battery.atlas.json"
+	trashAtlasPath   = "assets/trash-sprites/trash.atlas.json"
+)
+
+// Clip states the game asks the atlases for.
+const (
+	robotIdle    = "idle"
+	robotWalk    = "walk"  // picked by heading among the walk clips
+	batteryLevel = "level" // frames from full to empty
+)
+
+// trashClips names the trash atlas clip of each item type.
+var trashClips = map[int]string{
+	entities.PET: "pet",
+	entities.CAN: "can",
+}
+
+// spriteSheet is an atlas with its frames cut out of the loaded images.
+type spriteSheet struct {
+	atlas  *atlas.Atlas
+	frames map[*atlas.Clip][]*ebiten.Image
+}
+
+func loadSpriteSheet(path string) (*spriteSheet, error) {
+	a, err := atlas.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	images := make(map[string]*ebiten.Image)
+	for _, p := range a.Images() {
+		img, _, err := ebitenutil.NewImageFromFile(p)
+		if err != nil {
+			return nil, err
+		}
+		images[p] = img
+	}
+
+	s := &spriteSheet{atlas: a, frames: make(map[*atlas.Clip][]*ebiten.Image)}
+	for _, c := range a.Clips {
+		img := images[c.Image]
+		for i, f := range c.Frames {
+			if !f.Rect.In(img.Bounds()) {
+				return nil, fmt.Errorf("%s: frame %d of clip %s is outside %s", path, i, c.Name, c.Image)
+			}
+			s.frames[c] = append(s.frames[c], img.SubImage(f.Rect).(*ebiten.Image))
+		}
+	}
+	return s, nil
+}
+
+// placeholderSheet stands in for a sheet that failed to load: a plain square
+// as the single frame of each of the given clips.
+func placeholderSheet(size int, clr color.Color, clips ...string) *spriteSheet {
+	img := ebiten.NewImage(size, size)
+	img.Fill(clr)
+	s := &spriteSheet{atlas: &atlas.Atlas{Clips: make(map[string]*atlas.Clip)}, frames: make(map[*atlas.Clip][]*ebiten.Image)}
+	for _, name := range clips {
+		c := &atlas.Clip{Name: name, State: name, Frames: []atlas.Frame{{Rect: img.Bounds()}}}
+		s.atlas.Clips[name] = c
+		s.frames[c] = []*ebiten.Image{img}
+	}
+	return s
+}
+
+// frame is frame i of clip c, or nil if there is no such frame.
+func (s *spriteSheet) frame(c *atlas.Clip, i int) *ebiten.Image {
+	if s == nil || c == nil || i >= len(s.frames[c]) {
+		return nil
+	}
+	return s.frames[c][i]
+}
+
+// LoadAssets loads the sprite sheets, with plain placeholders for the robot
+// and the items if theirs are missing. Without a battery sheet the battery
+// is drawn as a bar.
+func (g *Game) LoadAssets() {
+	var err error
+	if g.robotSprites, err = loadSpriteSheet(robotAtlasPath); err != nil {
+		log.Printf("Warning: Failed to load robot sprites, using placeholders: %v", err)
+		g.robotSprites = placeholderSheet(config.RobotSize, color.RGBA{100, 150, 255, 255}, robotIdle)
+	}
+	if g.batterySprites, err = loadSpriteSheet(batteryAtlasPath); err != nil {
+		log.Printf("Warning: Failed to load battery sprites: %v", err)
+	}
+	if g.trashSprites, err = loadSpriteSheet(trashAtlasPath); err != nil {
+		log.Printf("Warning: Failed to load trash sprites, using placeholders: %v", err)
+		g.trashSprites = placeholderSheet(config.CanSize, color.RGBA{255, 200, 50, 255}, trashClips[entities.PET], trashClips[entities.CAN])
+	}
+}
+
+// animateRobot picks the robot clip for its current motion and advances it
+// dt of simulated time: walk clips face the way the robot moves.
+func (g *Game) animateRobot(dt time.Duration) {
+	sheet := g.robotSprites.atlas
+	clip := sheet.ClipFor(robotIdle, 0)
+	if vel := g.robot.Velocity; vel.X != 0 || vel.Y != 0 {
+		heading := math.Atan2(vel.Y, vel.X) * 180 / math.Pi
+		if walk := sheet.ClipFor(robotWalk, heading); walk != nil {
+			clip = walk
+		}
+	}
+	g.robotAnim.Play(clip)
+	g.robotAnim.Advance(dt)
+}
+
+// robotFrame is the robot frame to draw: the current animation frame, or the
+// first idle frame before the robot has been animated.
+func (g *Game) robotFrame() *ebiten.Image {
+	if c := g.robotAnim.Clip(); c != nil {
+		return g.robotSprites.frame(c, g.robotAnim.Frame())
+	}
+	return g.robotSprites.frame(g.robotSprites.atlas.ClipFor(robotIdle, 0), 0)
+}
+
+// batteryFrame is the battery frame for the charge left; the last frame is
+// only shown once the battery is empty. Nil without a battery sheet.
+func (g *Game) batteryFrame() *ebiten.Image {
+	if g.batterySprites == nil {
+		return nil
+	}
+	clip := g.batterySprites.atlas.ClipFor(batteryLevel, 0)
+	if clip == nil {
+		return nil
+	}
+	n := len(clip.Frames)
+	i := n - 1
+	if !g.robot.Battery.IsEmpty() && n > 1 {
+		charge := g.robot.Battery.Current / g.robot.Battery.Max
+		i = min(n-2, int((1-charge)*float64(n-1)))
+	}
+	return g.batterySprites.frame(clip, i)
+}
+
+// canSpriteFor is the first frame of the trash clip for the item type.
+func (g *Game) canSpriteFor(canType int) *ebiten.Image {
+	clip := g.trashSprites.atlas.Clips[trashClips[canType]]
+	return g.trashSprites.frame(clip, 0)
+}
@@ -0,0 +1,60 @@
+package game
+
+import (
+	"log"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// manualToggleKey switches between autonomous and manual control.
+const manualToggleKey = ebiten.KeyM
+
+// updateControlMode toggles manual control. Taking over drops whatever the
+// robot was heading to; handing back stops it so the strategy picks again.
+func (g *Game) updateControlMode() {
+	if inpututil.IsKeyJustPressed(manualToggleKey) {
+		g.setManual(!g.manual)
+	}
+}
+
+// setManual switches manual control on or off.
+func (g *Game) setManual(on bool) {
+	if on == g.manual {
+		return
+	}
+	g.manual = on
+	g.clearRoute()
+	if g.manual {
+		log.Println("Manual control: drive with WASD or the arrow keys")
+	} else {
+		log.Println("Autonomous control")
+	}
+}
+
+// driveManually sets the robot's velocity from WASD or the arrow keys, at
+// the robot's own speed in every direction, diagonals included. While a
+// control has the keyboard focus the arrow keys are its own.
+func (g *Game) driveManually() {
+	held := func(letter, arrow ebiten.Key) bool {
+		return ebiten.IsKeyPressed(letter) || !g.ui.HasFocus() && ebiten.IsKeyPressed(arrow)
+	}
+	var dx, dy float64
+	if held(ebiten.KeyA, ebiten.KeyArrowLeft) {
+		dx--
+	}
+	if held(ebiten.KeyD, ebiten.KeyArrowRight) {
+		dx++
+	}
+	if held(ebiten.KeyW, ebiten.KeyArrowUp) {
+		dy--
+	}
+	if held(ebiten.KeyS, ebiten.KeyArrowDown) {
+		dy++
+	}
+	if dx != 0 && dy != 0 {
+		dx, dy = dx/math.Sqrt2, dy/math.Sqrt2
+	}
+	g.robot.SetVelocity(dx*g.robot.Speed, dy*g.robot.Speed)
+}
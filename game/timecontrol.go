@@ -0,0 +1,96 @@
+package game
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"pybot-simulator/entities"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Time scale limits. Each change doubles or halves the scale.
+const (
+	minTimeScale = 0.25
+	maxTimeScale = 16
+)
+
+// Time controls.
+const (
+	pauseKey = ebiten.KeyP
+	stepKey  = ebiten.KeyN // one tick while paused
+	resetKey = ebiten.Key0 // back to 1x
+)
+
+// simStep is the longest step the simulation takes at once. Faster time
+// scales run more steps per frame instead of longer ones, so the robot does
+// not tunnel through walls or overshoot items.
+const simStep = time.Second / entities.TicksPerSecond
+
+// simClock is the simulated time: what the battery, the sensor schedules and
+// the period clock follow.
+type simClock struct {
+	now      time.Time
+	scale    float64
+	stepOnce bool // run a single step on the next frame while paused
+}
+
+func newSimClock() simClock {
+	return simClock{now: time.Now(), scale: 1}
+}
+
+// simNow is the current simulated time.
+func (g *Game) simNow() time.Time {
+	return g.clock.now
+}
+
+// updateTimeControls applies pause, single-step and time scale keys.
+func (g *Game) updateTimeControls() {
+	c := &g.clock
+	if inpututil.IsKeyJustPressed(pauseKey) {
+		g.paused = !g.paused
+	}
+	if inpututil.IsKeyJustPressed(stepKey) {
+		g.paused = true
+		c.stepOnce = true
+	}
+
+	scale := c.scale
+	if inpututil.IsKeyJustPressed(ebiten.KeyEqual) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadAdd) {
+		scale *= 2
+	}
+	if inpututil.IsKeyJustPressed(ebiten.KeyMinus) || inpututil.IsKeyJustPressed(ebiten.KeyNumpadSubtract) {
+		scale /= 2
+	}
+	if inpututil.IsKeyJustPressed(resetKey) {
+		scale = 1
+	}
+	g.setTimeScale(scale)
+}
+
+// setTimeScale sets the time scale, kept within its limits.
+func (g *Game) setTimeScale(scale float64) {
+	c := &g.clock
+	if scale = math.Max(minTimeScale, math.Min(maxTimeScale, scale)); scale != c.scale {
+		c.scale = scale
+		log.Printf("Time scale x%g", scale)
+	}
+}
+
+// simSteps splits the simulated time of this frame into steps of at most
+// simStep. Paused, it is a single step if one was requested, or none.
+func (g *Game) simSteps() (n int, dt time.Duration) {
+	c := &g.clock
+	if g.paused {
+		if !c.stepOnce {
+			return 0, 0
+		}
+		c.stepOnce = false
+		return 1, simStep
+	}
+	frame := time.Duration(c.scale * float64(time.Second) / float64(ebiten.TPS()))
+	n = int(math.Ceil(float64(frame) / float64(simStep)))
+	return n, frame / time.Duration(n)
+}
@@ -0,0 +1,111 @@
+package game
+
+import (
+	"fmt"
+	"math"
+
+	"pybot-simulator/config"
+	"pybot-simulator/tilemap"
+	"pybot-simulator/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// robotStartObject is the point object that marks where the robot starts.
+const robotStartObject = "robot_start"
+
+// worldMap is the scenario loaded from a Tiled map: what is drawn and, from
+// the same layers, where the robot can drive.
+type worldMap struct {
+	tiles  *tilemap.Map
+	images map[string]*ebiten.Image // tileset images by path
+	frames map[uint32]*ebiten.Image // tile sub-images by GID
+	start  utils.Vector2D
+}
+
+// loadWorldMap reads the map and its tileset images and checks that the
+// robot fits at its start point.
+func loadWorldMap(path string) (*worldMap, error) {
+	tiles, err := tilemap.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	wm := &worldMap{
+		tiles:  tiles,
+		images: make(map[string]*ebiten.Image),
+		frames: make(map[uint32]*ebiten.Image),
+	}
+	for _, ts := range tiles.Tilesets {
+		img, _, err := ebitenutil.NewImageFromFile(ts.Image)
+		if err != nil {
+			return nil, fmt.Errorf("tileset %s: %w", ts.Name, err)
+		}
+		wm.images[ts.Image] = img
+	}
+
+	w, h := tiles.PixelSize()
+	wm.start = utils.Vector2D{X: float64(w) / 2, Y: float64(h) / 2}
+	if obj, ok := tiles.Object(robotStartObject); ok {
+		wm.start = utils.Vector2D{X: obj.X, Y: obj.Y}
+	}
+	body := utils.RectAround(wm.start, config.RobotBodySize, config.RobotBodySize)
+	if !tiles.CollisionGrid().Free(body) {
+		return nil, fmt.Errorf("the robot does not fit at its start point (%.0f, %.0f); add a %q point on the floor",
+			wm.start.X, wm.start.Y, robotStartObject)
+	}
+	return wm, nil
+}
+
+// size is the map size in world pixels.
+func (wm *worldMap) size() (int, int) {
+	return wm.tiles.PixelSize()
+}
+
+// frame returns the sub-image of a tile, cached by GID.
+func (wm *worldMap) frame(gid uint32) *ebiten.Image {
+	if img, ok := wm.frames[gid]; ok {
+		return img
+	}
+	var img *ebiten.Image
+	if ts, rect, ok := wm.tiles.Tile(gid); ok {
+		img = wm.images[ts.Image].SubImage(rect).(*ebiten.Image)
+	}
+	wm.frames[gid] = img
+	return img
+}
+
+// draw draws the visible tiles of every visible tile layer, in map order.
+func (wm *worldMap) draw(screen *ebiten.Image, cam *Camera) {
+	m := wm.tiles
+	bounds := screen.Bounds()
+	x0, y0 := cam.ScreenToWorld(0, 0)
+	x1, y1 := cam.ScreenToWorld(float64(bounds.Dx()), float64(bounds.Dy()))
+	c0 := max(0, int(math.Floor(x0/float64(m.TileWidth))))
+	r0 := max(0, int(math.Floor(y0/float64(m.TileHeight))))
+	c1 := min(m.Width-1, int(math.Floor(x1/float64(m.TileWidth))))
+	r1 := min(m.Height-1, int(math.Floor(y1/float64(m.TileHeight))))
+	camGeoM := cam.GeoM()
+
+	for _, layer := range m.Layers {
+		if layer.Type != tilemap.TileLayer || !layer.Visible {
+			continue
+		}
+		for row := r0; row <= r1; row++ {
+			for col := c0; col <= c1; col++ {
+				img := wm.frame(layer.Data[row*m.Width+col])
+				if img == nil {
+					continue
+				}
+				// Tiles taller than the grid grow upwards, as in Tiled
+				op := &ebiten.DrawImageOptions{}
+				op.GeoM.Translate(float64(col*m.TileWidth), float64((row+1)*m.TileHeight-img.Bounds().Dy()))
+				op.GeoM.Concat(camGeoM)
+				if layer.Opacity < 1 {
+					op.ColorScale.ScaleAlpha(float32(layer.Opacity))
+				}
+				screen.DrawImage(img, op)
+			}
+		}
+	}
+}
@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"pybot-simulator/api/backend"
 	"pybot-simulator/config"
 	"pybot-simulator/game"
 
@@ -10,12 +15,71 @@ import (
 )
 
 func main() {
+	settings, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatalf("Configuración inválida: %v", err)
+	}
+	if settings.LocalBackend {
+		running := startLocalBackend(settings)
+		defer running.Close(context.Background())
+	}
+	log.Print(settings)
+
 	ebiten.SetWindowSize(config.ScreenWidth, config.ScreenHeight)
 	ebiten.SetWindowTitle("Robot Recolector")
-	
-	g := game.NewGame(config.ScreenWidth, config.ScreenHeight)
-	
-	if err := ebiten.RunGame(g); err != nil {
-		log.Fatal(err)
+	// La ventana se puede agrandar: la cámara y los controles se acomodan solos,
+	// hasta un mínimo en el que los paneles no se pisan
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowSizeLimits(config.MinScreenWidth, config.MinScreenHeight, -1, -1)
+	// Cerrar la ventana no termina el proceso: Update devuelve ebiten.Termination
+	// y aquí abajo se vacían los trabajos pendientes antes de salir.
+	ebiten.SetWindowClosingHandled(true)
+
+	// SIGINT/SIGTERM terminan igual que cerrar la ventana. Se instala antes de
+	// NewGame, que puede quedarse esperando a la API o al broker: una señal
+	// durante el arranque pide la salida apenas el juego exista, y una segunda
+	// corta el arranque sin esperar.
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	games := make(chan *game.Game, 1)
+	go func() {
+		sig := <-signals
+		log.Printf("Señal %v recibida, cerrando...", sig)
+		select {
+		case g := <-games:
+			g.RequestExit()
+		case sig := <-signals:
+			log.Fatalf("Señal %v recibida durante el arranque, saliendo sin cerrar", sig)
+		}
+	}()
+
+	g := game.NewGame(config.ScreenWidth, config.ScreenHeight, settings)
+	games <- g
+
+	runErr := ebiten.RunGame(g)
+
+	ctx, cancel := context.WithTimeout(context.Background(), settings.ShutdownTimeout)
+	defer cancel()
+	g.Shutdown(ctx)
+
+	if runErr != nil {
+		log.Fatal(runErr)
+	}
+}
+
+// startLocalBackend arranca el sustituto local de la API y apunta api_url a él.
+func startLocalBackend(settings *config.Settings) *backend.Running {
+	store := backend.NewMemoryStore()
+	if settings.LocalBackendData != "" {
+		var err error
+		if store, err = backend.OpenFileStore(settings.LocalBackendData); err != nil {
+			log.Fatalf("No se pudo abrir el almacenamiento del backend local: %v", err)
+		}
+	}
+	running, err := backend.Start(settings.LocalBackendAddr, store)
+	if err != nil {
+		log.Fatalf("No se pudo arrancar el backend local: %v", err)
 	}
-}
\ No newline at end of file
+	settings.UseAPIBaseURL(running.URL, "local_backend")
+	return running
+}
@@ -0,0 +1,138 @@
+// Package scenario guarda y carga la disposición de un escenario armada con
+// el editor del simulador: residuos colocados a mano, obstáculos, zonas de
+// aparición y zonas prohibidas, y la posición de la base de carga y del
+// contenedor. Va encima del mapa (o del área abierta), no lo reemplaza.
+package scenario
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"pybot-simulator/utils"
+)
+
+// Tipos de residuo en el archivo.
+const (
+	ItemPET = "pet"
+	ItemCan = "can"
+)
+
+// Version es la versión del formato que escribe Save.
+const Version = 1
+
+// Point es una posición en píxeles del mundo.
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Rect es un rectángulo en píxeles del mundo.
+type Rect struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+// Item es un residuo colocado a mano.
+type Item struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Type string  `json:"type"`
+}
+
+type Scenario struct {
+	Version int    `json:"version"`
+	Map     string `json:"map,omitempty"` // mapa sobre el que se armó; solo informativo
+
+	Items      []Item `json:"items,omitempty"`
+	Obstacles  []Rect `json:"obstacles,omitempty"`
+	SpawnZones []Rect `json:"spawn_zones,omitempty"` // si hay alguna, los residuos aleatorios solo aparecen ahí
+	NoGoZones  []Rect `json:"no_go_zones,omitempty"` // el robot no entra y no aparecen residuos
+
+	Dock *Point `json:"dock,omitempty"` // base de carga; el robot arranca ahí
+	Bin  *Point `json:"bin,omitempty"`  // contenedor
+}
+
+// Load lee y valida un escenario.
+func Load(path string) (*Scenario, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el escenario %s: %w", path, err)
+	}
+	var s Scenario
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("error decodificando el escenario %s: %w", path, err)
+	}
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("escenario %s: %w", path, err)
+	}
+	return &s, nil
+}
+
+func (s *Scenario) validate() error {
+	if s.Version > Version {
+		return fmt.Errorf("versión %d no soportada (máximo %d)", s.Version, Version)
+	}
+	for i, item := range s.Items {
+		if item.Type != ItemPET && item.Type != ItemCan {
+			return fmt.Errorf("residuo %d: tipo %q desconocido", i, item.Type)
+		}
+	}
+	groups := []struct {
+		name  string
+		rects []Rect
+	}{
+		{"obstáculo", s.Obstacles},
+		{"zona de aparición", s.SpawnZones},
+		{"zona prohibida", s.NoGoZones},
+	}
+	for _, group := range groups {
+		for i, r := range group.rects {
+			if r.W <= 0 || r.H <= 0 {
+				return fmt.Errorf("%s %d: ancho y alto deben ser mayores a cero", group.name, i)
+			}
+		}
+	}
+	return nil
+}
+
+// Save escribe el escenario en path. Escribe a un temporal y lo renombra
+// para no dejar el archivo a medias.
+func (s *Scenario) Save(path string) error {
+	s.Version = Version
+	raw, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creando directorio del escenario: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, raw, 0o644); err != nil {
+		return fmt.Errorf("error guardando escenario: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Vector convierte el punto al tipo del simulador.
+func (p Point) Vector() utils.Vector2D {
+	return utils.Vector2D{X: p.X, Y: p.Y}
+}
+
+// PointOf convierte una posición del simulador.
+func PointOf(v utils.Vector2D) *Point {
+	return &Point{X: v.X, Y: v.Y}
+}
+
+// Rect convierte el rectángulo al tipo del simulador.
+func (r Rect) Rect() utils.Rect {
+	return utils.Rect{X: r.X, Y: r.Y, W: r.W, H: r.H}
+}
+
+// RectOf convierte un rectángulo del simulador.
+func RectOf(r utils.Rect) Rect {
+	return Rect{X: r.X, Y: r.Y, W: r.W, H: r.H}
+}
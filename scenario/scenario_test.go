@@ -0,0 +1,105 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSaveLoadRoundTrip(t *testing.T) {
+	want := &Scenario{
+		Map: "maps/warehouse.json",
+		Items: []Item{
+			{X: 120.5, Y: 80, Type: ItemPET},
+			{X: 300, Y: 410.25, Type: ItemCan},
+		},
+		Obstacles:  []Rect{{X: 200, Y: 200, W: 50, H: 120}},
+		SpawnZones: []Rect{{X: 0, Y: 0, W: 400, H: 300}, {X: 500, Y: 100, W: 80, H: 80}},
+		NoGoZones:  []Rect{{X: 600, Y: 600, W: 30, H: 40}},
+		Dock:       &Point{X: 60, Y: 60},
+		Bin:        &Point{X: 700, Y: 90},
+	}
+	// Save crea el directorio si no existe
+	path := filepath.Join(t.TempDir(), "escenarios", "test.json")
+	if err := want.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if want.Version != Version {
+		t.Errorf("Save dejó la versión en %d, se esperaba %d", want.Version, Version)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("quedó el temporal: %v", err)
+	}
+
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("recargado %+v, se esperaba %+v", got, want)
+	}
+
+	// Guardar lo recargado da el mismo archivo
+	first, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := got.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	second, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(first) != string(second) {
+		t.Errorf("el segundo guardado cambió el archivo:\n%s\n---\n%s", first, second)
+	}
+}
+
+func TestSaveLoadEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vacio.json")
+	if err := (&Scenario{}).Save(path); err != nil {
+		t.Fatal(err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&Scenario{Version: Version}); !reflect.DeepEqual(got, want) {
+		t.Errorf("recargado %+v, se esperaba %+v", got, want)
+	}
+}
+
+func TestLoadRejectsInvalid(t *testing.T) {
+	cases := []struct {
+		name string
+		raw  string
+		want string // parte del mensaje de error
+	}{
+		{"versión futura", `{"version": 2}`, "versión 2"},
+		{"tipo desconocido", `{"items": [{"x": 1, "y": 1, "type": "vidrio"}]}`, "residuo 0"},
+		{"obstáculo vacío", `{"obstacles": [{"x": 1, "y": 1, "w": 0, "h": 10}]}`, "obstáculo 0"},
+		{"zona de aparición negativa", `{"spawn_zones": [{"x": 1, "y": 1, "w": 10, "h": -5}]}`, "zona de aparición 0"},
+		{"zona prohibida vacía", `{"no_go_zones": [{"x": 1, "y": 1, "w": 10, "h": 10}, {"x": 1, "y": 1}]}`, "zona prohibida 1"},
+		{"JSON roto", `{"items": [`, "decodificando"},
+	}
+	dir := t.TempDir()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(dir, "escenario.json")
+			if err := os.WriteFile(path, []byte(tc.raw), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("error %v, se esperaba uno con %q", err, tc.want)
+			}
+		})
+	}
+
+	if _, err := Load(filepath.Join(dir, "no-existe.json")); err == nil {
+		t.Error("se cargó un archivo que no existe")
+	}
+}
@@ -0,0 +1,202 @@
+package systems
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+
+	"pybot-simulator/utils"
+)
+
+// trailStep es lo mínimo que tiene que moverse el robot, en píxeles, para
+// agregar un punto al rastro.
+const trailStep = 4.0
+
+// Coverage acumula durante la sesión por dónde pasó el robot y dónde
+// recogió residuos, en una rejilla de celdas de CellW x CellH píxeles, y
+// guarda su rastro reciente.
+type Coverage struct {
+	Cols, Rows   int
+	CellW, CellH float64
+
+	visits      []int // ticks pasados en cada celda
+	collections []int // residuos recogidos en cada celda
+	area        []bool
+	areaCells   int
+
+	trail    []utils.Vector2D // buffer circular; el más viejo está en head
+	trailMax int
+	head     int
+}
+
+// NewCoverage crea la rejilla para un mundo de cols x rows celdas. El rastro
+// guarda hasta trailLength puntos (0 = sin rastro).
+func NewCoverage(cols, rows int, cellW, cellH float64, trailLength int) *Coverage {
+	c := &Coverage{
+		Cols:        cols,
+		Rows:        rows,
+		CellW:       cellW,
+		CellH:       cellH,
+		visits:      make([]int, cols*rows),
+		collections: make([]int, cols*rows),
+		area:        make([]bool, cols*rows),
+		areaCells:   cols * rows,
+		trailMax:    trailLength,
+	}
+	for i := range c.area {
+		c.area[i] = true
+	}
+	return c
+}
+
+// Restrict limita el área a cubrir a cells (índices fila*Cols+columna), por
+// ejemplo las celdas del mapa a las que llega el robot. Solo afecta a
+// Covered; las visitas fuera del área se siguen contando.
+func (c *Coverage) Restrict(cells []int) {
+	for i := range c.area {
+		c.area[i] = false
+	}
+	c.areaCells = 0
+	for _, i := range cells {
+		if i >= 0 && i < len(c.area) && !c.area[i] {
+			c.area[i] = true
+			c.areaCells++
+		}
+	}
+}
+
+// cell devuelve el índice de la celda de p, o -1 si p está fuera del mundo.
+func (c *Coverage) cell(p utils.Vector2D) int {
+	col := int(math.Floor(p.X / c.CellW))
+	row := int(math.Floor(p.Y / c.CellH))
+	if col < 0 || col >= c.Cols || row < 0 || row >= c.Rows {
+		return -1
+	}
+	return row*c.Cols + col
+}
+
+// Visit registra un tick del robot en p.
+func (c *Coverage) Visit(p utils.Vector2D) {
+	if i := c.cell(p); i >= 0 {
+		c.visits[i]++
+	}
+	if c.trailMax == 0 {
+		return
+	}
+	if len(c.trail) > 0 && c.last().Distance(p) < trailStep {
+		return
+	}
+	if len(c.trail) < c.trailMax {
+		c.trail = append(c.trail, p)
+		return
+	}
+	c.trail[c.head] = p
+	c.head = (c.head + 1) % c.trailMax
+}
+
+func (c *Coverage) last() utils.Vector2D {
+	if len(c.trail) < c.trailMax {
+		return c.trail[len(c.trail)-1]
+	}
+	return c.trail[(c.head+c.trailMax-1)%c.trailMax]
+}
+
+// Collect registra un residuo recogido en p.
+func (c *Coverage) Collect(p utils.Vector2D) {
+	if i := c.cell(p); i >= 0 {
+		c.collections[i]++
+	}
+}
+
+// Trail devuelve el rastro reciente, del punto más viejo al más nuevo.
+func (c *Coverage) Trail() []utils.Vector2D {
+	out := make([]utils.Vector2D, 0, len(c.trail))
+	out = append(out, c.trail[c.head:]...)
+	return append(out, c.trail[:c.head]...)
+}
+
+// Covered devuelve cuántas celdas del área visitó el robot y cuántas hay.
+func (c *Coverage) Covered() (visited, total int) {
+	for i, v := range c.visits {
+		if v > 0 && c.area[i] {
+			visited++
+		}
+	}
+	return visited, c.areaCells
+}
+
+// Heatmap dibuja la rejilla con un píxel por celda. Las visitas van de azul
+// (pocas) a rojo (muchas) en escala logarítmica; las celdas con residuos
+// recogidos, en verde más intenso cuantos más hubo. Las celdas sin visitas
+// quedan transparentes.
+func (c *Coverage) Heatmap() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, c.Cols, c.Rows))
+	maxVisits, maxCollections := 0, 0
+	for i := range c.visits {
+		maxVisits = max(maxVisits, c.visits[i])
+		maxCollections = max(maxCollections, c.collections[i])
+	}
+	for i := range c.visits {
+		var clr color.RGBA
+		switch {
+		case c.collections[i] > 0:
+			t := logScale(c.collections[i], maxCollections)
+			clr = color.RGBA{0, uint8(140 + 115*t), 60, 255}
+		case c.visits[i] > 0:
+			clr = heatColor(logScale(c.visits[i], maxVisits))
+		default:
+			continue
+		}
+		img.SetRGBA(i%c.Cols, i/c.Cols, clr)
+	}
+	return img
+}
+
+// logScale lleva v a [0, 1] respecto de top, en escala logarítmica para
+// que las celdas poco visitadas se sigan viendo.
+func logScale(v, top int) float64 {
+	if top <= 1 {
+		return 1
+	}
+	return math.Log1p(float64(v)) / math.Log1p(float64(top))
+}
+
+// heatColor va de azul (t=0) a amarillo (t=0.5) y a rojo (t=1).
+func heatColor(t float64) color.RGBA {
+	if t < 0.5 {
+		k := t * 2
+		return color.RGBA{uint8(255 * k), uint8(255 * k), uint8(255 * (1 - k)), 255}
+	}
+	k := (t - 0.5) * 2
+	return color.RGBA{255, uint8(255 * (1 - k)), 0, 255}
+}
+
+// WritePNG guarda el heatmap en path al tamaño del mundo (cada celda ocupa
+// CellW x CellH píxeles) y crea el directorio si hace falta.
+func (c *Coverage) WritePNG(path string) error {
+	heat := c.Heatmap()
+	w, h := int(float64(c.Cols)*c.CellW), int(float64(c.Rows)*c.CellH)
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetRGBA(x, y, heat.RGBAAt(int(float64(x)/c.CellW), int(float64(y)/c.CellH)))
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creando directorio del heatmap: %w", err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creando heatmap: %w", err)
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("codificando heatmap: %w", err)
+	}
+	return f.Close()
+}
@@ -1 +1,136 @@
-package systems
\ No newline at end of file
+package systems
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"pybot-simulator/entities"
+	"pybot-simulator/utils"
+)
+
+// Nombres de las estrategias de navegación disponibles.
+const (
+	StrategyNearest = "nearest"
+	StrategyRandom  = "random"
+	StrategySweep   = "sweep"
+)
+
+// NavigationStrategy decide el siguiente objetivo del robot cuando no tiene uno.
+// Devuelve nil si no hay a dónde ir.
+type NavigationStrategy interface {
+	Name() string
+	NextTarget(robot utils.Vector2D, cans []*entities.Can) *utils.Vector2D
+}
+
+// NewNavigationStrategy construye una estrategia por nombre.
+// Los límites son los mismos que se usan en Robot.SetBounds.
+func NewNavigationStrategy(name string, minX, maxX, minY, maxY float64, rng *rand.Rand) (NavigationStrategy, error) {
+	switch name {
+	case StrategyNearest, "":
+		return &Nearest{}, nil
+	case StrategyRandom:
+		return &Random{rng: rng}, nil
+	case StrategySweep:
+		return NewSweep(minX, maxX, minY, maxY, 80), nil
+	default:
+		return nil, fmt.Errorf("estrategia de navegación desconocida: %q", name)
+	}
+}
+
+// NearestCan devuelve la lata activa más cercana a la posición dada.
+func NearestCan(from utils.Vector2D, cans []*entities.Can) *entities.Can {
+	var nearest *entities.Can
+	minDistance := math.MaxFloat64
+
+	for _, can := range cans {
+		if !can.Active {
+			continue
+		}
+
+		distance := from.Distance(can.Position)
+		if distance < minDistance {
+			minDistance = distance
+			nearest = can
+		}
+	}
+
+	return nearest
+}
+
+func hasActiveCans(cans []*entities.Can) bool {
+	for _, can := range cans {
+		if can.Active {
+			return true
+		}
+	}
+	return false
+}
+
+// Nearest va siempre a la lata activa más cercana (comportamiento original).
+type Nearest struct{}
+
+func (n *Nearest) Name() string { return StrategyNearest }
+
+func (n *Nearest) NextTarget(robot utils.Vector2D, cans []*entities.Can) *utils.Vector2D {
+	nearest := NearestCan(robot, cans)
+	if nearest == nil {
+		return nil
+	}
+	target := nearest.Position
+	return &target
+}
+
+// Random elige una lata activa al azar.
+type Random struct {
+	rng *rand.Rand
+}
+
+func (r *Random) Name() string { return StrategyRandom }
+
+func (r *Random) NextTarget(robot utils.Vector2D, cans []*entities.Can) *utils.Vector2D {
+	active := make([]*entities.Can, 0, len(cans))
+	for _, can := range cans {
+		if can.Active {
+			active = append(active, can)
+		}
+	}
+	if len(active) == 0 {
+		return nil
+	}
+	target := active[r.rng.Intn(len(active))].Position
+	return &target
+}
+
+// Sweep recorre el área en zigzag (patrón de cortacésped) y recoge lo que
+// encuentre a su paso. Solo se mueve mientras queden latas activas.
+type Sweep struct {
+	waypoints []utils.Vector2D
+	index     int
+}
+
+// NewSweep genera los puntos de paso separados por laneSpacing píxeles.
+func NewSweep(minX, maxX, minY, maxY, laneSpacing float64) *Sweep {
+	s := &Sweep{}
+	leftToRight := true
+	for y := minY; y <= maxY; y += laneSpacing {
+		if leftToRight {
+			s.waypoints = append(s.waypoints, utils.Vector2D{X: minX, Y: y}, utils.Vector2D{X: maxX, Y: y})
+		} else {
+			s.waypoints = append(s.waypoints, utils.Vector2D{X: maxX, Y: y}, utils.Vector2D{X: minX, Y: y})
+		}
+		leftToRight = !leftToRight
+	}
+	return s
+}
+
+func (s *Sweep) Name() string { return StrategySweep }
+
+func (s *Sweep) NextTarget(robot utils.Vector2D, cans []*entities.Can) *utils.Vector2D {
+	if len(s.waypoints) == 0 || !hasActiveCans(cans) {
+		return nil
+	}
+	target := s.waypoints[s.index]
+	s.index = (s.index + 1) % len(s.waypoints)
+	return &target
+}
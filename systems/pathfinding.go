@@ -0,0 +1,216 @@
+package systems
+
+import (
+	"container/heap"
+	"math"
+
+	"pybot-simulator/utils"
+)
+
+// NavGrid planea rutas para un cuerpo de body x body píxeles sobre la rejilla
+// de colisiones del mapa. Una celda es transitable si el cuerpo cabe centrado
+// en ella.
+type NavGrid struct {
+	grid     *utils.CollisionGrid
+	body     float64
+	walkable []bool
+}
+
+// NewNavGrid precalcula las celdas transitables de grid para un cuerpo de
+// body píxeles de lado.
+func NewNavGrid(grid *utils.CollisionGrid, body float64) *NavGrid {
+	n := &NavGrid{grid: grid, body: body, walkable: make([]bool, grid.Cols*grid.Rows)}
+	for row := 0; row < grid.Rows; row++ {
+		for col := 0; col < grid.Cols; col++ {
+			center := grid.CellCenter(col, row)
+			n.walkable[row*grid.Cols+col] = grid.Free(utils.RectAround(center, body, body))
+		}
+	}
+	return n
+}
+
+// Grid devuelve la rejilla de colisiones.
+func (n *NavGrid) Grid() *utils.CollisionGrid { return n.grid }
+
+// Walkable indica si el cuerpo cabe centrado en la celda.
+func (n *NavGrid) Walkable(col, row int) bool {
+	if col < 0 || col >= n.grid.Cols || row < 0 || row >= n.grid.Rows {
+		return false
+	}
+	return n.walkable[row*n.grid.Cols+col]
+}
+
+// Fits indica si el cuerpo cabe centrado en p.
+func (n *NavGrid) Fits(p utils.Vector2D) bool {
+	return n.grid.Free(utils.RectAround(p, n.body, n.body))
+}
+
+// Reachable devuelve las celdas transitables a las que se llega desde from,
+// como índices fila*Cols+columna.
+func (n *NavGrid) Reachable(from utils.Vector2D) []int {
+	start, ok := n.nearestWalkable(from)
+	if !ok {
+		return nil
+	}
+	seen := map[int]bool{start: true}
+	queue := []int{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		n.neighbors(cur, func(next int, _ float64) {
+			if !seen[next] {
+				seen[next] = true
+				queue = append(queue, next)
+			}
+		})
+	}
+	cells := make([]int, 0, len(seen))
+	for row := 0; row < n.grid.Rows; row++ {
+		for col := 0; col < n.grid.Cols; col++ {
+			if i := row*n.grid.Cols + col; seen[i] {
+				cells = append(cells, i)
+			}
+		}
+	}
+	return cells
+}
+
+// FindPath busca una ruta de from a to (A* con 8 vecinos, sin cortar
+// esquinas) y la devuelve como puntos de paso, sin incluir from y terminando
+// en to. Los tramos rectos se juntan en uno solo. nil si no hay ruta.
+func (n *NavGrid) FindPath(from, to utils.Vector2D) []utils.Vector2D {
+	start, ok := n.nearestWalkable(from)
+	if !ok {
+		return nil
+	}
+	goalCol, goalRow := n.grid.Cell(to)
+	goal := goalRow*n.grid.Cols + goalCol
+	if !n.Walkable(goalCol, goalRow) || !n.Fits(to) {
+		return nil
+	}
+
+	cost := map[int]float64{start: 0}
+	came := map[int]int{}
+	open := &nodeHeap{{cell: start, f: n.heuristic(start, goal)}}
+	for open.Len() > 0 {
+		cur := heap.Pop(open).(node)
+		if cur.cell == goal {
+			return n.smooth(from, n.cells(came, start, goal), to)
+		}
+		if cur.g > cost[cur.cell] {
+			continue // entrada vieja
+		}
+		n.neighbors(cur.cell, func(next int, step float64) {
+			g := cost[cur.cell] + step
+			if old, seen := cost[next]; seen && g >= old {
+				return
+			}
+			cost[next] = g
+			came[next] = cur.cell
+			heap.Push(open, node{cell: next, g: g, f: g + n.heuristic(next, goal)})
+		})
+	}
+	return nil
+}
+
+// nearestWalkable devuelve la celda transitable más cercana a p, buscando
+// hasta unas pocas celdas alrededor (el robot puede estar entre dos celdas).
+func (n *NavGrid) nearestWalkable(p utils.Vector2D) (int, bool) {
+	col, row := n.grid.Cell(p)
+	best, bestDist := -1, math.MaxFloat64
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			c, r := col+dx, row+dy
+			if !n.Walkable(c, r) {
+				continue
+			}
+			if d := p.Distance(n.grid.CellCenter(c, r)); d < bestDist {
+				best, bestDist = r*n.grid.Cols+c, d
+			}
+		}
+	}
+	return best, best >= 0
+}
+
+// neighbors visita las celdas vecinas transitables. Las diagonales solo
+// cuentan si las dos celdas que rodean la esquina también son transitables.
+func (n *NavGrid) neighbors(cell int, visit func(next int, step float64)) {
+	col, row := cell%n.grid.Cols, cell/n.grid.Cols
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if dx == 0 && dy == 0 || !n.Walkable(col+dx, row+dy) {
+				continue
+			}
+			step := 1.0
+			if dx != 0 && dy != 0 {
+				if !n.Walkable(col+dx, row) || !n.Walkable(col, row+dy) {
+					continue
+				}
+				step = math.Sqrt2
+			}
+			visit((row+dy)*n.grid.Cols+col+dx, step)
+		}
+	}
+}
+
+// heuristic es la distancia octil entre dos celdas.
+func (n *NavGrid) heuristic(a, b int) float64 {
+	dx := math.Abs(float64(a%n.grid.Cols - b%n.grid.Cols))
+	dy := math.Abs(float64(a/n.grid.Cols - b/n.grid.Cols))
+	return math.Max(dx, dy) + (math.Sqrt2-1)*math.Min(dx, dy)
+}
+
+// cells reconstruye la ruta de celdas de start a goal.
+func (n *NavGrid) cells(came map[int]int, start, goal int) []utils.Vector2D {
+	var path []utils.Vector2D
+	for cell := goal; ; cell = came[cell] {
+		path = append(path, n.grid.CellCenter(cell%n.grid.Cols, cell/n.grid.Cols))
+		if cell == start {
+			break
+		}
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// smooth reemplaza el centro de la última celda por to y salta los puntos
+// de paso que se pueden recorrer en línea recta.
+func (n *NavGrid) smooth(from utils.Vector2D, centers []utils.Vector2D, to utils.Vector2D) []utils.Vector2D {
+	centers[len(centers)-1] = to
+	var out []utils.Vector2D
+	anchor := from
+	for i := 0; i < len(centers); {
+		far := i
+		for j := len(centers) - 1; j > i; j-- {
+			if n.grid.SweepFree(anchor, centers[j], n.body, n.body) {
+				far = j
+				break
+			}
+		}
+		out = append(out, centers[far])
+		anchor = centers[far]
+		i = far + 1
+	}
+	return out
+}
+
+type node struct {
+	cell int
+	g, f float64
+}
+
+// nodeHeap es la cola de prioridad de A*, ordenada por f.
+type nodeHeap []node
+
+func (h nodeHeap) Len() int            { return len(h) }
+func (h nodeHeap) Less(i, j int) bool  { return h[i].f < h[j].f }
+func (h nodeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *nodeHeap) Push(x interface{}) { *h = append(*h, x.(node)) }
+func (h *nodeHeap) Pop() interface{} {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
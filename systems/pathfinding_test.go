@@ -0,0 +1,147 @@
+package systems
+
+import (
+	"testing"
+
+	"pybot-simulator/utils"
+)
+
+// navCell es el lado de las celdas de los mapas de prueba.
+const navCell = 10
+
+// navFrom arma una NavGrid a partir de un dibujo: '#' es una celda
+// bloqueada. El cuerpo mide media celda.
+func navFrom(rows ...string) *NavGrid {
+	grid := utils.NewCollisionGrid(len(rows[0]), len(rows), navCell, navCell)
+	for row, line := range rows {
+		for col, c := range line {
+			grid.Set(col, row, c == '#')
+		}
+	}
+	return NewNavGrid(grid, navCell/2)
+}
+
+func center(col, row int) utils.Vector2D {
+	return utils.Vector2D{X: (float64(col) + 0.5) * navCell, Y: (float64(row) + 0.5) * navCell}
+}
+
+// checkPath verifica que la ruta termine en to y que cada tramo se pueda
+// recorrer en línea recta.
+func checkPath(t *testing.T, n *NavGrid, from, to utils.Vector2D, path []utils.Vector2D) {
+	t.Helper()
+	if len(path) == 0 {
+		t.Fatal("no encontró ruta")
+	}
+	if path[len(path)-1] != to {
+		t.Errorf("la ruta termina en %v, se esperaba %v", path[len(path)-1], to)
+	}
+	prev := from
+	for _, p := range path {
+		if !n.Grid().SweepFree(prev, p, n.body, n.body) {
+			t.Errorf("el tramo %v -> %v atraviesa una pared", prev, p)
+		}
+		prev = p
+	}
+}
+
+func TestFindPathAroundWall(t *testing.T) {
+	n := navFrom(
+		"......",
+		"..#...",
+		"..#...",
+		"..#...",
+		"......",
+	)
+	from, to := center(0, 2), center(5, 2)
+	path := n.FindPath(from, to)
+	checkPath(t, n, from, to, path)
+	if len(path) < 2 {
+		t.Errorf("ruta %v: en línea recta atravesaría la pared", path)
+	}
+}
+
+func TestFindPathStraightLine(t *testing.T) {
+	n := navFrom(
+		"......",
+		"......",
+	)
+	from, to := center(0, 0), utils.Vector2D{X: 53, Y: 14}
+	// Sin obstáculos los tramos se juntan en uno solo que termina en to
+	if path := n.FindPath(from, to); len(path) != 1 || path[0] != to {
+		t.Errorf("ruta %v, se esperaba solo %v", path, to)
+	}
+}
+
+func TestFindPathDoesNotCutCorners(t *testing.T) {
+	// Solo se pasa de (0, 0) a (1, 1) en diagonal, entre dos paredes
+	n := navFrom(
+		".#",
+		"#.",
+	)
+	if path := n.FindPath(center(0, 0), center(1, 1)); path != nil {
+		t.Errorf("cortó la esquina: %v", path)
+	}
+
+	// Con un lado libre la ruta rodea la esquina por ese lado
+	n = navFrom(
+		"..#",
+		"#..",
+		"###",
+	)
+	from, to := center(0, 0), center(2, 1)
+	path := n.FindPath(from, to)
+	checkPath(t, n, from, to, path)
+}
+
+func TestFindPathUnreachable(t *testing.T) {
+	n := navFrom(
+		".....",
+		".###.",
+		".#.#.",
+		".###.",
+		".....",
+	)
+	// La celda del medio está encerrada
+	if path := n.FindPath(center(0, 0), center(2, 2)); path != nil {
+		t.Errorf("encontró ruta a una celda encerrada: %v", path)
+	}
+	// Un destino en la pared tampoco tiene ruta
+	if path := n.FindPath(center(0, 0), center(1, 1)); path != nil {
+		t.Errorf("encontró ruta a una pared: %v", path)
+	}
+	// Ni un destino fuera del mapa
+	if path := n.FindPath(center(0, 0), utils.Vector2D{X: -20, Y: 5}); path != nil {
+		t.Errorf("encontró ruta fuera del mapa: %v", path)
+	}
+}
+
+func TestReachable(t *testing.T) {
+	n := navFrom(
+		"..#..",
+		"..#..",
+		"#####",
+	)
+	cells := n.Reachable(center(0, 0))
+	want := []int{0, 1, 5, 6}
+	if len(cells) != len(want) {
+		t.Fatalf("celdas alcanzables %v, se esperaban %v", cells, want)
+	}
+	for i := range want {
+		if cells[i] != want[i] {
+			t.Fatalf("celdas alcanzables %v, se esperaban %v", cells, want)
+		}
+	}
+}
+
+func TestWalkableNeedsRoomForTheBody(t *testing.T) {
+	grid := utils.NewCollisionGrid(3, 3, navCell, navCell)
+	grid.Set(1, 0, true)
+	// Un cuerpo de más de una celda no cabe al lado de la pared
+	n := NewNavGrid(grid, navCell*1.5)
+	if n.Walkable(1, 1) || n.Walkable(0, 0) {
+		t.Error("el cuerpo cabe tocando la pared")
+	}
+	if n.Walkable(-1, 0) {
+		t.Error("una celda fuera del mapa es transitable")
+	}
+}
@@ -0,0 +1,122 @@
+package systems
+
+import (
+	"fmt"
+	"time"
+)
+
+// Nombres de las políticas de rotación de períodos disponibles.
+const (
+	RotationBattery = "battery"
+	RotationShift   = "shift"
+	RotationLimit   = "limit"
+	RotationManual  = "manual"
+)
+
+// PeriodProgress es lo que lleva el período de trabajo actual.
+type PeriodProgress struct {
+	Start  time.Time // hora simulada en que se abrió
+	Now    time.Time // hora simulada actual
+	Items  int     // residuos recogidos en el período
+	Weight float64 // peso recogido en el período
+}
+
+// RotationPolicy decide cuándo cerrar el período de trabajo y abrir otro.
+// El comando rotate_period rota siempre, sea cual sea la política.
+type RotationPolicy interface {
+	Name() string
+	// ShouldRotate se consulta en cada tick con el avance del período.
+	ShouldRotate(p PeriodProgress) bool
+	// RotateOnRecharge indica si recargar tras agotar la batería rota el período.
+	RotateOnRecharge() bool
+}
+
+// NewRotationPolicy construye una política por nombre. every y at solo se
+// usan en RotationShift; maxItems y maxWeight en RotationLimit (0 = sin límite).
+func NewRotationPolicy(name string, every time.Duration, at []time.Duration, maxItems int, maxWeight float64) (RotationPolicy, error) {
+	switch name {
+	case RotationBattery, "":
+		return BatteryCycle{}, nil
+	case RotationShift:
+		if every <= 0 && len(at) == 0 {
+			return nil, fmt.Errorf("la política shift necesita un intervalo o una lista de horas")
+		}
+		return &Shift{Every: every, At: at}, nil
+	case RotationLimit:
+		if maxItems <= 0 && maxWeight <= 0 {
+			return nil, fmt.Errorf("la política limit necesita un máximo de residuos o de peso")
+		}
+		return &Limit{MaxItems: maxItems, MaxWeight: maxWeight}, nil
+	case RotationManual:
+		return Manual{}, nil
+	default:
+		return nil, fmt.Errorf("política de rotación desconocida: %q", name)
+	}
+}
+
+// BatteryCycle rota al recargar después de agotar la batería: un período por
+// ciclo de batería.
+type BatteryCycle struct{}
+
+func (BatteryCycle) Name() string                     { return RotationBattery }
+func (BatteryCycle) ShouldRotate(PeriodProgress) bool { return false }
+func (BatteryCycle) RotateOnRecharge() bool           { return true }
+
+// Manual solo rota con el comando rotate_period.
+type Manual struct{}
+
+func (Manual) Name() string                     { return RotationManual }
+func (Manual) ShouldRotate(PeriodProgress) bool { return false }
+func (Manual) RotateOnRecharge() bool           { return false }
+
+// Shift rota en horas fijas del reloj simulado (hora local), que a escala 1
+// va con el de pared. Con Every rota en cada múltiplo del intervalo contado
+// desde medianoche (1h = cada hora en punto); con At rota al pasar por cada
+// una de esas horas del día (por ejemplo 08:00 y 14:00 dan un turno de 08:00
+// a 14:00 y otro de 14:00 a 08:00).
+type Shift struct {
+	Every time.Duration
+	At    []time.Duration // desde medianoche
+}
+
+func (s *Shift) Name() string           { return RotationShift }
+func (s *Shift) RotateOnRecharge() bool { return false }
+
+func (s *Shift) ShouldRotate(p PeriodProgress) bool {
+	return !p.Start.IsZero() && !p.Now.Before(s.NextBoundary(p.Start))
+}
+
+// NextBoundary devuelve la primera hora de cambio de turno posterior a t.
+func (s *Shift) NextBoundary(t time.Time) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	var next time.Time
+	if s.Every > 0 {
+		next = midnight.Add(t.Sub(midnight).Truncate(s.Every) + s.Every)
+	}
+	for day := 0; day <= 1; day++ {
+		base := midnight.AddDate(0, 0, day)
+		for _, offset := range s.At {
+			candidate := base.Add(offset)
+			if candidate.After(t) && (next.IsZero() || candidate.Before(next)) {
+				next = candidate
+			}
+		}
+	}
+	return next
+}
+
+// Limit rota cuando el período llega a un máximo de residuos o de peso.
+type Limit struct {
+	MaxItems  int
+	MaxWeight float64
+}
+
+func (l *Limit) Name() string           { return RotationLimit }
+func (l *Limit) RotateOnRecharge() bool { return false }
+
+func (l *Limit) ShouldRotate(p PeriodProgress) bool {
+	if l.MaxItems > 0 && p.Items >= l.MaxItems {
+		return true
+	}
+	return l.MaxWeight > 0 && p.Weight >= l.MaxWeight
+}
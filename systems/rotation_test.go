@@ -0,0 +1,89 @@
+package systems
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShouldRotate(t *testing.T) {
+	day := func(h, m int) time.Time { return time.Date(2030, 1, 7, h, m, 0, 0, time.Local) }
+	next := func(h, m int) time.Time { return day(h, m).AddDate(0, 0, 1) }
+	hourly := &Shift{Every: time.Hour}
+	turns := &Shift{At: []time.Duration{8 * time.Hour, 14 * time.Hour}}
+	limit := &Limit{MaxItems: 10, MaxWeight: 2.5}
+
+	cases := []struct {
+		name   string
+		policy RotationPolicy
+		p      PeriodProgress
+		want   bool
+	}{
+		{"battery nunca por tick", BatteryCycle{}, PeriodProgress{Start: day(8, 0), Now: next(8, 0), Items: 1000}, false},
+		{"manual nunca por tick", Manual{}, PeriodProgress{Start: day(8, 0), Now: next(8, 0), Items: 1000}, false},
+
+		{"cada hora antes de la hora", hourly, PeriodProgress{Start: day(8, 10), Now: day(8, 59)}, false},
+		{"cada hora al llegar", hourly, PeriodProgress{Start: day(8, 10), Now: day(9, 0)}, true},
+		{"cada hora abierto en punto", hourly, PeriodProgress{Start: day(9, 0), Now: day(9, 30)}, false},
+		{"sin inicio", hourly, PeriodProgress{Now: day(9, 0)}, false},
+
+		{"turnos dentro del primero", turns, PeriodProgress{Start: day(8, 0), Now: day(13, 59)}, false},
+		{"turnos al cambiar", turns, PeriodProgress{Start: day(8, 0), Now: day(14, 0)}, true},
+		{"turno de noche antes de las 8", turns, PeriodProgress{Start: day(14, 0), Now: next(7, 59)}, false},
+		{"turno de noche a las 8", turns, PeriodProgress{Start: day(14, 0), Now: next(8, 0)}, true},
+		{"abierto de madrugada", turns, PeriodProgress{Start: day(3, 0), Now: day(8, 0)}, true},
+
+		{"límite sin alcanzar", limit, PeriodProgress{Items: 9, Weight: 2.4}, false},
+		{"límite de residuos", limit, PeriodProgress{Items: 10}, true},
+		{"límite de peso", limit, PeriodProgress{Weight: 2.5}, true},
+		{"solo peso", &Limit{MaxWeight: 1}, PeriodProgress{Items: 1000, Weight: 0.9}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.ShouldRotate(tc.p); got != tc.want {
+			t.Errorf("%s: ShouldRotate = %v, se esperaba %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRotateOnRecharge(t *testing.T) {
+	cases := []struct {
+		policy RotationPolicy
+		want   bool
+	}{
+		{BatteryCycle{}, true},
+		{Manual{}, false},
+		{&Shift{Every: time.Hour}, false},
+		{&Limit{MaxItems: 1}, false},
+	}
+	for _, tc := range cases {
+		if got := tc.policy.RotateOnRecharge(); got != tc.want {
+			t.Errorf("%s: RotateOnRecharge = %v", tc.policy.Name(), got)
+		}
+	}
+}
+
+func TestNewRotationPolicy(t *testing.T) {
+	cases := []struct {
+		name, policy string
+		every        time.Duration
+		at           []time.Duration
+		items        int
+		weight       float64
+		ok           bool
+	}{
+		{"por defecto", "", 0, nil, 0, 0, true},
+		{"battery", RotationBattery, 0, nil, 0, 0, true},
+		{"manual", RotationManual, 0, nil, 0, 0, true},
+		{"shift con intervalo", RotationShift, time.Hour, nil, 0, 0, true},
+		{"shift con horas", RotationShift, 0, []time.Duration{8 * time.Hour}, 0, 0, true},
+		{"shift vacío", RotationShift, 0, nil, 0, 0, false},
+		{"limit", RotationLimit, 0, nil, 5, 0, true},
+		{"limit vacío", RotationLimit, 0, nil, 0, 0, false},
+		{"desconocida", "weekly", 0, nil, 0, 0, false},
+	}
+	for _, tc := range cases {
+		_, err := NewRotationPolicy(tc.policy, tc.every, tc.at, tc.items, tc.weight)
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: error %v", tc.name, err)
+		}
+	}
+}
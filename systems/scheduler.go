@@ -0,0 +1,155 @@
+package systems
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// TriggerMode indica cuándo se dispara un sensor.
+type TriggerMode string
+
+const (
+	// Periodic dispara cada Interval (± Jitter).
+	Periodic TriggerMode = "periodic"
+	// OnChange dispara cuando cambia el valor de Probe, como mucho una vez por Interval.
+	OnChange TriggerMode = "on-change"
+	// OnEvent dispara solo cuando alguien llama a Scheduler.Trigger.
+	OnEvent TriggerMode = "on-event"
+)
+
+// ParseTriggerMode valida el nombre de un modo de disparo.
+func ParseTriggerMode(s string) (TriggerMode, error) {
+	switch m := TriggerMode(s); m {
+	case Periodic, OnChange, OnEvent:
+		return m, nil
+	}
+	return "", fmt.Errorf("modo de disparo desconocido: %q (periodic, on-change u on-event)", s)
+}
+
+// SensorSchedule declara la frecuencia de publicación de un sensor.
+// Todos los tiempos son de simulación, no de reloj.
+type SensorSchedule struct {
+	Name     string
+	Mode     TriggerMode
+	Interval time.Duration // periodo en Periodic, intervalo mínimo en OnChange/OnEvent
+	Jitter   time.Duration // variación aleatoria máxima sobre Interval
+
+	// Enabled, si no es nil, bloquea el disparo mientras devuelva false.
+	Enabled func() bool
+	// Probe es el valor observado en modo OnChange; debe ser comparable.
+	Probe func() interface{}
+	// Fire es la acción de publicación.
+	Fire func()
+}
+
+type scheduleEntry struct {
+	SensorSchedule
+	next      time.Duration // próximo disparo permitido
+	lastValue interface{}
+	hasValue  bool
+	pending   bool
+}
+
+// Scheduler dispara los sensores según el tiempo de simulación.
+// No es seguro para uso concurrente: se avanza desde el loop del juego.
+type Scheduler struct {
+	now     time.Duration
+	entries []*scheduleEntry
+	byName  map[string]*scheduleEntry
+	rng     *rand.Rand
+}
+
+// NewScheduler crea un scheduler vacío en el instante cero.
+func NewScheduler(rng *rand.Rand) *Scheduler {
+	return &Scheduler{
+		byName: make(map[string]*scheduleEntry),
+		rng:    rng,
+	}
+}
+
+// Register agrega un sensor. El primer disparo periódico ocurre tras un Interval.
+func (s *Scheduler) Register(schedule SensorSchedule) error {
+	if schedule.Fire == nil {
+		return fmt.Errorf("sensor %s sin acción Fire", schedule.Name)
+	}
+	if schedule.Mode == Periodic && schedule.Interval <= 0 {
+		return fmt.Errorf("sensor %s: el modo periódico requiere Interval > 0", schedule.Name)
+	}
+	if schedule.Mode == OnChange && schedule.Probe == nil {
+		return fmt.Errorf("sensor %s: el modo on-change requiere Probe", schedule.Name)
+	}
+	if _, exists := s.byName[schedule.Name]; exists {
+		return fmt.Errorf("sensor %s ya registrado", schedule.Name)
+	}
+	e := &scheduleEntry{SensorSchedule: schedule}
+	if schedule.Mode == Periodic {
+		e.next = s.now + s.withJitter(schedule)
+	}
+	s.entries = append(s.entries, e)
+	s.byName[schedule.Name] = e
+	return nil
+}
+
+// Trigger marca un evento para un sensor OnEvent; se publica en el siguiente Advance.
+func (s *Scheduler) Trigger(name string) {
+	if e, ok := s.byName[name]; ok && e.Mode == OnEvent {
+		e.pending = true
+	}
+}
+
+// Now devuelve el tiempo de simulación acumulado.
+func (s *Scheduler) Now() time.Duration {
+	return s.now
+}
+
+// Advance avanza el reloj de simulación dt y dispara los sensores que toque.
+func (s *Scheduler) Advance(dt time.Duration) {
+	s.now += dt
+	for _, e := range s.entries {
+		if e.Enabled != nil && !e.Enabled() {
+			// Mientras está deshabilitado su cuenta regresiva se congela,
+			// igual que los contadores de ticks que dejaban de incrementar.
+			if e.Mode == Periodic {
+				e.next += dt
+			}
+			continue
+		}
+
+		switch e.Mode {
+		case Periodic:
+			if s.now >= e.next {
+				e.Fire()
+				e.next = s.now + s.withJitter(e.SensorSchedule)
+			}
+		case OnChange:
+			value := e.Probe()
+			if e.hasValue && value == e.lastValue {
+				continue
+			}
+			if s.now < e.next {
+				continue
+			}
+			e.lastValue, e.hasValue = value, true
+			e.Fire()
+			e.next = s.now + e.Interval
+		case OnEvent:
+			if e.pending && s.now >= e.next {
+				e.pending = false
+				e.Fire()
+				e.next = s.now + e.Interval
+			}
+		}
+	}
+}
+
+func (s *Scheduler) withJitter(schedule SensorSchedule) time.Duration {
+	interval := schedule.Interval
+	if schedule.Jitter > 0 && s.rng != nil {
+		interval += time.Duration(s.rng.Int63n(int64(2*schedule.Jitter))) - schedule.Jitter
+	}
+	if interval < 0 {
+		return 0
+	}
+	return interval
+}
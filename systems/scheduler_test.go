@@ -0,0 +1,198 @@
+package systems
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// tick es el paso de simulación de los tests; divide exacto a un segundo.
+const tick = 10 * time.Millisecond
+
+// fireTimes registra en qué tiempo de simulación se dispara un sensor.
+type fireTimes struct {
+	s     *Scheduler
+	times []time.Duration
+}
+
+func (f *fireTimes) fire() { f.times = append(f.times, f.s.Now()) }
+
+func newRecorded(t *testing.T, rng *rand.Rand, schedule SensorSchedule) (*Scheduler, *fireTimes) {
+	t.Helper()
+	s := NewScheduler(rng)
+	f := &fireTimes{s: s}
+	schedule.Name = "sensor"
+	schedule.Fire = f.fire
+	if err := s.Register(schedule); err != nil {
+		t.Fatal(err)
+	}
+	return s, f
+}
+
+func advance(s *Scheduler, d time.Duration) {
+	for range d / tick {
+		s.Advance(tick)
+	}
+}
+
+func TestPeriodicFiresEveryInterval(t *testing.T) {
+	s, f := newRecorded(t, nil, SensorSchedule{Mode: Periodic, Interval: time.Second})
+	advance(s, 3*time.Second)
+	if len(f.times) != 3 || f.times[0] != time.Second || f.times[2] != 3*time.Second {
+		t.Errorf("disparos en %v, se esperaban en 1s, 2s y 3s", f.times)
+	}
+}
+
+func TestPeriodicFreezesWhileDisabled(t *testing.T) {
+	enabled := true
+	s, f := newRecorded(t, nil, SensorSchedule{
+		Mode:     Periodic,
+		Interval: time.Second,
+		Enabled:  func() bool { return enabled },
+	})
+	advance(s, 500*time.Millisecond)
+	enabled = false
+	advance(s, 10*time.Second)
+	if len(f.times) != 0 {
+		t.Fatalf("disparó deshabilitado: %v", f.times)
+	}
+	// Le quedaba medio segundo de cuenta regresiva
+	enabled = true
+	advance(s, 500*time.Millisecond)
+	if len(f.times) != 1 {
+		t.Errorf("disparos %v tras rehabilitarlo, se esperaba uno", f.times)
+	}
+}
+
+func TestPeriodicJitter(t *testing.T) {
+	const interval, jitter = time.Second, 200 * time.Millisecond
+	s, f := newRecorded(t, rand.New(rand.NewSource(1)), SensorSchedule{Mode: Periodic, Interval: interval, Jitter: jitter})
+	advance(s, 100*time.Second)
+
+	if len(f.times) < 80 {
+		t.Fatalf("solo %d disparos en 100s", len(f.times))
+	}
+	gaps := map[time.Duration]bool{}
+	prev := time.Duration(0)
+	for _, at := range f.times {
+		// Cada disparo cae en el primer tick después de su hora
+		if gap := at - prev; gap < interval-jitter || gap > interval+jitter+tick {
+			t.Errorf("intervalo de %s fuera de %s ± %s", gap, interval, jitter)
+		}
+		gaps[at-prev] = true
+		prev = at
+	}
+	if len(gaps) < 5 {
+		t.Errorf("solo %d intervalos distintos: el jitter no varía", len(gaps))
+	}
+}
+
+func TestOnChange(t *testing.T) {
+	value := 0
+	s, f := newRecorded(t, nil, SensorSchedule{
+		Mode:     OnChange,
+		Interval: time.Second,
+		Probe:    func() interface{} { return value },
+	})
+
+	// El primer Advance publica el valor inicial
+	s.Advance(tick)
+	if len(f.times) != 1 {
+		t.Fatalf("%d disparos en el primer Advance, se esperaba 1", len(f.times))
+	}
+	advance(s, 5*time.Second)
+	if len(f.times) != 1 {
+		t.Fatalf("disparó %d veces sin cambios", len(f.times))
+	}
+
+	value = 1
+	s.Advance(tick)
+	if len(f.times) != 2 {
+		t.Fatalf("no disparó al cambiar el valor")
+	}
+	// Un cambio dentro del intervalo espera a que se cumpla, y se publica una vez
+	value = 2
+	advance(s, 500*time.Millisecond)
+	value = 3
+	advance(s, 2*time.Second)
+	if len(f.times) != 3 {
+		t.Fatalf("disparos %v, se esperaban 3", f.times)
+	}
+	if gap := f.times[2] - f.times[1]; gap < time.Second {
+		t.Errorf("dos disparos a %s, menos que Interval", gap)
+	}
+}
+
+func TestOnEvent(t *testing.T) {
+	s, f := newRecorded(t, nil, SensorSchedule{Mode: OnEvent, Interval: time.Second})
+	advance(s, 5*time.Second)
+	if len(f.times) != 0 {
+		t.Fatalf("disparó sin eventos: %v", f.times)
+	}
+
+	// En pausa el juego no avanza el scheduler: el evento queda pendiente
+	s.Trigger("sensor")
+	s.Trigger("sensor")
+	if len(f.times) != 0 {
+		t.Fatal("Trigger publicó sin Advance")
+	}
+	s.Advance(tick)
+	if len(f.times) != 1 {
+		t.Fatalf("%d disparos tras dos eventos, se esperaba 1", len(f.times))
+	}
+
+	// En avance rápido corren muchos pasos por cuadro; un evento dentro del
+	// intervalo mínimo sale cuando se cumple, medido en tiempo de simulación
+	first := f.times[0]
+	s.Trigger("sensor")
+	for range 10 * 16 {
+		s.Advance(tick)
+	}
+	if len(f.times) != 2 || f.times[1] != first+time.Second {
+		t.Errorf("disparos %v, se esperaba el segundo a %s", f.times, first+time.Second)
+	}
+
+	// Trigger de un sensor desconocido no hace nada
+	s.Trigger("otro")
+	s.Advance(tick)
+	if len(f.times) != 2 {
+		t.Errorf("disparos %v", f.times)
+	}
+}
+
+func TestRatesFollowSimulationTime(t *testing.T) {
+	// A 16x el juego corre 16 pasos por cuadro: 100 cuadros son 16s simulados
+	s, f := newRecorded(t, nil, SensorSchedule{Mode: Periodic, Interval: time.Second})
+	for range 100 {
+		for range 16 {
+			s.Advance(tick)
+		}
+	}
+	if len(f.times) != 16 {
+		t.Errorf("%d disparos en 16s simulados, se esperaban 16", len(f.times))
+	}
+}
+
+func TestRegisterValidates(t *testing.T) {
+	fire := func() {}
+	cases := []struct {
+		name     string
+		schedule SensorSchedule
+	}{
+		{"sin Fire", SensorSchedule{Name: "a", Mode: OnEvent}},
+		{"periódico sin Interval", SensorSchedule{Name: "a", Mode: Periodic, Fire: fire}},
+		{"on-change sin Probe", SensorSchedule{Name: "a", Mode: OnChange, Fire: fire}},
+	}
+	for _, tc := range cases {
+		if err := NewScheduler(nil).Register(tc.schedule); err == nil {
+			t.Errorf("%s: Register no falló", tc.name)
+		}
+	}
+	s := NewScheduler(nil)
+	if err := s.Register(SensorSchedule{Name: "a", Mode: OnEvent, Fire: fire}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Register(SensorSchedule{Name: "a", Mode: OnEvent, Fire: fire}); err == nil {
+		t.Error("se registró dos veces el mismo nombre")
+	}
+}
@@ -0,0 +1,38 @@
+package systems
+
+import (
+	"math"
+
+	"pybot-simulator/entities"
+	"pybot-simulator/utils"
+)
+
+// InFieldOfView indica si p está en el cono de visión de la cámara: a menos
+// de rangePx píxeles de origin y a menos de fovDeg/2 grados de heading.
+func InFieldOfView(origin, heading, p utils.Vector2D, rangePx, fovDeg float64) bool {
+	dx, dy := p.X-origin.X, p.Y-origin.Y
+	dist := math.Hypot(dx, dy)
+	if dist > rangePx {
+		return false
+	}
+	if dist == 0 {
+		return true
+	}
+	norm := heading.Magnitude()
+	if norm == 0 {
+		return false
+	}
+	cos := (dx*heading.X + dy*heading.Y) / (dist * norm)
+	return cos >= math.Cos(fovDeg/2*math.Pi/180)
+}
+
+// Detect devuelve los residuos activos que están en el campo de visión.
+func Detect(origin, heading utils.Vector2D, cans []*entities.Can, rangePx, fovDeg float64) []*entities.Can {
+	var seen []*entities.Can
+	for _, can := range cans {
+		if can.Active && InFieldOfView(origin, heading, can.Position, rangePx, fovDeg) {
+			seen = append(seen, can)
+		}
+	}
+	return seen
+}
@@ -0,0 +1,230 @@
+// Package tilemap carga mapas hechos con Tiled (https://www.mapeditor.org)
+// exportados en formato JSON.
+//
+// Solo se soporta lo que usa el simulador: mapas ortogonales finitos, capas
+// de tiles con los datos como arreglo (sin base64 ni compresión), capas de
+// objetos y tilesets embebidos con una sola imagen. Los tiles volteados se
+// dibujan sin voltear.
+//
+// Las capas de tiles o de objetos con la propiedad booleana "collision"
+// bloquean el paso del robot; las celdas sin ningún tile también.
+package tilemap
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+
+	"pybot-simulator/utils"
+)
+
+// Tipos de capa de Tiled.
+const (
+	TileLayer   = "tilelayer"
+	ObjectGroup = "objectgroup"
+)
+
+// CollisionProperty es la propiedad de capa que marca paredes y muebles.
+const CollisionProperty = "collision"
+
+// gidMask quita los bits de volteo de un GID.
+const gidMask = 0x0fffffff
+
+type Map struct {
+	Orientation string    `json:"orientation"`
+	Infinite    bool      `json:"infinite"`
+	Width       int       `json:"width"` // en tiles
+	Height      int       `json:"height"`
+	TileWidth   int       `json:"tilewidth"`
+	TileHeight  int       `json:"tileheight"`
+	Layers      []Layer   `json:"layers"`
+	Tilesets    []Tileset `json:"tilesets"`
+}
+
+type Layer struct {
+	Name       string     `json:"name"`
+	Type       string     `json:"type"`
+	Visible    bool       `json:"visible"`
+	Opacity    float64    `json:"opacity"`
+	Encoding   string     `json:"encoding"`
+	Data       []uint32   `json:"data"` // GIDs por fila; 0 = vacío
+	Objects    []Object   `json:"objects"`
+	Properties []Property `json:"properties"`
+}
+
+type Object struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Type   string  `json:"type"`
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+	Point  bool    `json:"point"`
+}
+
+type Property struct {
+	Name  string      `json:"name"`
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+type Tileset struct {
+	FirstGID    uint32 `json:"firstgid"`
+	Name        string `json:"name"`
+	Source      string `json:"source"` // tileset externo (.tsj), no soportado
+	Image       string `json:"image"`  // ya resuelta respecto del mapa
+	ImageWidth  int    `json:"imagewidth"`
+	ImageHeight int    `json:"imageheight"`
+	TileWidth   int    `json:"tilewidth"`
+	TileHeight  int    `json:"tileheight"`
+	Columns     int    `json:"columns"`
+	TileCount   int    `json:"tilecount"`
+	Margin      int    `json:"margin"`
+	Spacing     int    `json:"spacing"`
+}
+
+// Load lee un mapa JSON de Tiled. Las rutas de las imágenes de los tilesets
+// quedan resueltas respecto del directorio del mapa.
+func Load(path string) (*Map, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error leyendo el mapa %s: %w", path, err)
+	}
+	var m Map
+	if err := json.Unmarshal(raw, &m); err != nil {
+		return nil, fmt.Errorf("error decodificando el mapa %s: %w", path, err)
+	}
+	if err := m.validate(); err != nil {
+		return nil, fmt.Errorf("mapa %s: %w", path, err)
+	}
+	dir := filepath.Dir(path)
+	for i := range m.Tilesets {
+		m.Tilesets[i].Image = filepath.Join(dir, m.Tilesets[i].Image)
+	}
+	return &m, nil
+}
+
+func (m *Map) validate() error {
+	if m.Orientation != "orthogonal" || m.Infinite {
+		return fmt.Errorf("solo se soportan mapas ortogonales finitos")
+	}
+	if m.Width <= 0 || m.Height <= 0 || m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return fmt.Errorf("tamaño de mapa o de tile inválido")
+	}
+	for _, ts := range m.Tilesets {
+		if ts.Source != "" {
+			return fmt.Errorf("tileset externo %s: embébelo en el mapa", ts.Source)
+		}
+		if ts.Image == "" || ts.Columns <= 0 || ts.TileWidth <= 0 || ts.TileHeight <= 0 {
+			return fmt.Errorf("tileset %s: se necesita una imagen con columnas y tamaño de tile", ts.Name)
+		}
+	}
+	for _, l := range m.Layers {
+		if l.Type != TileLayer {
+			continue
+		}
+		if l.Encoding != "" && l.Encoding != "csv" {
+			return fmt.Errorf("capa %s: codificación %q no soportada, exporta los datos como CSV", l.Name, l.Encoding)
+		}
+		if len(l.Data) != m.Width*m.Height {
+			return fmt.Errorf("capa %s: tiene %d tiles, se esperaban %d", l.Name, len(l.Data), m.Width*m.Height)
+		}
+	}
+	return nil
+}
+
+// PixelSize devuelve el tamaño del mapa en píxeles.
+func (m *Map) PixelSize() (w, h int) {
+	return m.Width * m.TileWidth, m.Height * m.TileHeight
+}
+
+// Tile busca el tileset de un GID y el rectángulo del tile dentro de su
+// imagen. false si el GID es 0 o no pertenece a ningún tileset.
+func (m *Map) Tile(gid uint32) (*Tileset, image.Rectangle, bool) {
+	gid &= gidMask
+	if gid == 0 {
+		return nil, image.Rectangle{}, false
+	}
+	var ts *Tileset
+	for i := range m.Tilesets {
+		if m.Tilesets[i].FirstGID <= gid && (ts == nil || m.Tilesets[i].FirstGID > ts.FirstGID) {
+			ts = &m.Tilesets[i]
+		}
+	}
+	if ts == nil {
+		return nil, image.Rectangle{}, false
+	}
+	id := int(gid - ts.FirstGID)
+	if ts.TileCount > 0 && id >= ts.TileCount {
+		return nil, image.Rectangle{}, false
+	}
+	x := ts.Margin + (id%ts.Columns)*(ts.TileWidth+ts.Spacing)
+	y := ts.Margin + (id/ts.Columns)*(ts.TileHeight+ts.Spacing)
+	return ts, image.Rect(x, y, x+ts.TileWidth, y+ts.TileHeight), true
+}
+
+// Object busca un objeto por nombre en todas las capas de objetos.
+func (m *Map) Object(name string) (Object, bool) {
+	for _, l := range m.Layers {
+		for _, o := range l.Objects {
+			if o.Name == name {
+				return o, true
+			}
+		}
+	}
+	return Object{}, false
+}
+
+// Bool devuelve el valor de una propiedad booleana de la capa.
+func (l *Layer) Bool(name string) bool {
+	for _, p := range l.Properties {
+		if p.Name == name {
+			v, _ := p.Value.(bool)
+			return v
+		}
+	}
+	return false
+}
+
+// CollisionGrid arma la rejilla de colisiones del mapa, con una celda por
+// tile. Una celda está bloqueada si no tiene ningún tile o si lo tiene en una
+// capa con colisión; los objetos de las capas de objetos con colisión
+// bloquean las celdas que tocan.
+func (m *Map) CollisionGrid() *utils.CollisionGrid {
+	grid := utils.NewCollisionGrid(m.Width, m.Height, float64(m.TileWidth), float64(m.TileHeight))
+	painted := make([]bool, m.Width*m.Height)
+	for _, l := range m.Layers {
+		if l.Type != TileLayer {
+			continue
+		}
+		collision := l.Bool(CollisionProperty)
+		for i, gid := range l.Data {
+			if gid&gidMask == 0 {
+				continue
+			}
+			painted[i] = true
+			if collision {
+				grid.Set(i%m.Width, i/m.Width, true)
+			}
+		}
+	}
+	for i, p := range painted {
+		if !p {
+			grid.Set(i%m.Width, i/m.Width, true)
+		}
+	}
+	for _, l := range m.Layers {
+		if l.Type != ObjectGroup || !l.Bool(CollisionProperty) {
+			continue
+		}
+		for _, o := range l.Objects {
+			if !o.Point && o.Width > 0 && o.Height > 0 {
+				grid.BlockRect(utils.Rect{X: o.X, Y: o.Y, W: o.Width, H: o.Height})
+			}
+		}
+	}
+	return grid
+}
@@ -0,0 +1,149 @@
+package tilemap
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testMap es un mapa de 4x3 tiles de 8 px con un tileset válido.
+func testMap(layers ...Layer) *Map {
+	return &Map{
+		Orientation: "orthogonal",
+		Width:       4,
+		Height:      3,
+		TileWidth:   8,
+		TileHeight:  8,
+		Layers:      layers,
+		Tilesets: []Tileset{{
+			FirstGID: 1, Name: "suelo", Image: "tiles.png",
+			TileWidth: 8, TileHeight: 8, Columns: 4, TileCount: 16,
+		}},
+	}
+}
+
+func collisionProps() []Property {
+	return []Property{{Name: CollisionProperty, Type: "bool", Value: true}}
+}
+
+func TestCollisionGrid(t *testing.T) {
+	floor := Layer{Name: "suelo", Type: TileLayer, Data: []uint32{
+		1, 1, 1, 0,
+		1, 1, 1, 0,
+		1, 1, 1, 1,
+	}}
+	// Con el bit de volteo horizontal, el tile sigue contando
+	walls := Layer{Name: "paredes", Type: TileLayer, Properties: collisionProps(), Data: []uint32{
+		0, 0, 0, 0,
+		0, 0x80000002, 0, 0,
+		0, 0, 0, 0,
+	}}
+	furniture := Layer{Name: "muebles", Type: ObjectGroup, Properties: collisionProps(), Objects: []Object{
+		{Name: "mesa", X: 0, Y: 16, Width: 4, Height: 4},
+		{Name: "marca", X: 16, Y: 16, Point: true},
+	}}
+	// Los objetos de una capa sin colisión no bloquean
+	spawns := Layer{Name: "zonas", Type: ObjectGroup, Objects: []Object{{Name: "zona", X: 16, Y: 0, Width: 8, Height: 8}}}
+
+	grid := testMap(floor, walls, furniture, spawns).CollisionGrid()
+	want := []string{
+		"...#", // sin pintar en (3, 0)
+		".#.#", // pared en (1, 1), sin pintar en (3, 1)
+		"#...", // la mesa en (0, 2); el punto no bloquea
+	}
+	for row, line := range want {
+		for col, c := range line {
+			if got := grid.Blocked(col, row); got != (c == '#') {
+				t.Errorf("celda (%d, %d) bloqueada = %v", col, row, got)
+			}
+		}
+	}
+	if grid.CellW != 8 || grid.CellH != 8 || grid.Cols != 4 || grid.Rows != 3 {
+		t.Errorf("rejilla de %dx%d celdas de %gx%g", grid.Cols, grid.Rows, grid.CellW, grid.CellH)
+	}
+}
+
+func TestCollisionGridWithoutTilesIsBlocked(t *testing.T) {
+	grid := testMap(Layer{Name: "vacía", Type: TileLayer, Data: make([]uint32, 12)}).CollisionGrid()
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 4; col++ {
+			if !grid.Blocked(col, row) {
+				t.Errorf("la celda sin pintar (%d, %d) está libre", col, row)
+			}
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	data := make([]uint32, 12)
+	cases := []struct {
+		name   string
+		modify func(m *Map)
+		error  string // fragmento del error, o "" si es válido
+	}{
+		{"válido", func(m *Map) {}, ""},
+		{"CSV", func(m *Map) { m.Layers[0].Encoding = "csv" }, ""},
+		{"isométrico", func(m *Map) { m.Orientation = "isometric" }, "ortogonales"},
+		{"infinito", func(m *Map) { m.Infinite = true }, "ortogonales"},
+		{"sin tamaño", func(m *Map) { m.Width = 0 }, "tamaño"},
+		{"tile sin tamaño", func(m *Map) { m.TileHeight = 0 }, "tamaño"},
+		{"tileset externo", func(m *Map) { m.Tilesets[0].Source = "suelo.tsj" }, "externo"},
+		{"tileset sin imagen", func(m *Map) { m.Tilesets[0].Image = "" }, "imagen"},
+		{"base64", func(m *Map) { m.Layers[0].Encoding = "base64" }, "codificación"},
+		{"datos cortos", func(m *Map) { m.Layers[0].Data = data[:11] }, "11 tiles"},
+	}
+	for _, tc := range cases {
+		m := testMap(Layer{Name: "suelo", Type: TileLayer, Data: data})
+		tc.modify(m)
+		err := m.validate()
+		if tc.error == "" {
+			if err != nil {
+				t.Errorf("%s: %v", tc.name, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.error) {
+			t.Errorf("%s: error %v, se esperaba uno con %q", tc.name, err, tc.error)
+		}
+	}
+}
+
+func TestLoadResolvesTilesetImage(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mapa.json")
+	raw := `{"orientation":"orthogonal","width":1,"height":1,"tilewidth":8,"tileheight":8,
+		"layers":[{"name":"suelo","type":"tilelayer","data":[1]}],
+		"tilesets":[{"firstgid":1,"name":"suelo","image":"img/tiles.png","tilewidth":8,"tileheight":8,"columns":1}]}`
+	if err := os.WriteFile(path, []byte(raw), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := m.Tilesets[0].Image, filepath.Join(dir, "img", "tiles.png"); got != want {
+		t.Errorf("imagen %q, se esperaba %q", got, want)
+	}
+	if w, h := m.PixelSize(); w != 8 || h != 8 {
+		t.Errorf("PixelSize = %dx%d", w, h)
+	}
+}
+
+func TestTile(t *testing.T) {
+	m := testMap()
+	m.Tilesets = append(m.Tilesets, Tileset{FirstGID: 17, Name: "muebles", Image: "m.png", TileWidth: 16, TileHeight: 16, Columns: 2, Margin: 1, Spacing: 2})
+
+	if _, _, ok := m.Tile(0); ok {
+		t.Error("el GID 0 tiene tile")
+	}
+	ts, rect, ok := m.Tile(6)
+	if !ok || ts.Name != "suelo" || rect.Min.X != 8 || rect.Min.Y != 8 || rect.Dx() != 8 {
+		t.Errorf("Tile(6) = %v %v %v", ts, rect, ok)
+	}
+	// El tercer tile del segundo tileset, con margen y separación
+	ts, rect, ok = m.Tile(19)
+	if !ok || ts.Name != "muebles" || rect.Min.X != 1 || rect.Min.Y != 19 || rect.Dx() != 16 {
+		t.Errorf("Tile(19) = %v %v %v", ts, rect, ok)
+	}
+}
@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"pybot-simulator/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+)
+
+// Anchor es el borde o la esquina de la pantalla a la que se pega un panel.
+type Anchor int
+
+const (
+	TopLeft Anchor = iota
+	Top
+	TopRight
+	Left
+	Center
+	Right
+	BottomLeft
+	Bottom
+	BottomRight
+)
+
+// Panel apila widgets de arriba a abajo, todos del ancho del panel, en un
+// lugar anclado a la pantalla: si la ventana cambia de tamaño, el panel se
+// mueve con su borde.
+type Panel struct {
+	Title  string
+	Anchor Anchor
+	// MarginX y MarginY separan al panel de los bordes a los que está anclado.
+	MarginX, MarginY float64
+	Width            float64
+	// Visible dice si el panel se muestra; nil = siempre.
+	Visible  func() bool
+	Children []Widget
+
+	bounds utils.Rect
+}
+
+// NewPanel crea un panel de ancho width anclado en anchor.
+func NewPanel(anchor Anchor, width float64, children ...Widget) *Panel {
+	return &Panel{Anchor: anchor, Width: width, Children: children}
+}
+
+func (p *Panel) visible() bool {
+	return p.Visible == nil || p.Visible()
+}
+
+// layout ubica el panel en una pantalla de screenW x screenH y reparte su
+// interior entre los widgets.
+func (p *Panel) layout(screenW, screenH float64, th *Theme) {
+	inner := p.Width - 2*th.Padding
+	height := 2 * th.Padding
+	if p.Title != "" {
+		height += lineHeight + th.Spacing
+	}
+	for i, w := range p.Children {
+		if i > 0 {
+			height += th.Spacing
+		}
+		height += w.height()
+	}
+
+	x := anchored(int(p.Anchor)%3, screenW, p.Width, p.MarginX)
+	y := anchored(int(p.Anchor)/3, screenH, height, p.MarginY)
+	p.bounds = utils.Rect{X: x, Y: y, W: p.Width, H: height}
+
+	y += th.Padding
+	if p.Title != "" {
+		y += lineHeight + th.Spacing
+	}
+	for _, w := range p.Children {
+		h := w.height()
+		w.base().bounds = utils.Rect{X: x + th.Padding, Y: y, W: inner, H: h}
+		y += h + th.Spacing
+	}
+}
+
+// anchored es la posición de un lado de tamaño size en una pantalla de
+// tamaño screen: pegado al principio (0), centrado (1) o pegado al final (2).
+func anchored(side int, screen, size, margin float64) float64 {
+	switch side {
+	case 0:
+		return margin
+	case 1:
+		return (screen-size)/2 + margin
+	default:
+		return screen - size - margin
+	}
+}
+
+func (p *Panel) draw(dst *ebiten.Image, th *Theme) {
+	r := p.bounds
+	ebitenutil.DrawRect(dst, r.X, r.Y, r.W, r.H, th.Panel)
+	if p.Title != "" {
+		ebitenutil.DebugPrintAt(dst, p.Title, int(r.X+th.Padding), int(r.Y+th.Padding))
+	}
+}
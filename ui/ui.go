@@ -0,0 +1,306 @@
+// Package ui es una capa chica de widgets sobre ebiten: botones, toggles,
+// sliders, etiquetas y tooltips, agrupados en paneles anclados a los bordes
+// de la pantalla. Los widgets avisan por callbacks y leen su valor con
+// funciones, así siempre muestran el estado real del juego aunque cambie por
+// teclado o por un comando remoto.
+//
+// Teclado: F2 (Shift+F2) mueve el foco entre los widgets, Enter o Espacio
+// activan el que tiene el foco, las flechas izquierda y derecha mueven un
+// slider y Escape suelta el foco.
+package ui
+
+import (
+	"image/color"
+	"strings"
+	"unicode/utf8"
+
+	"pybot-simulator/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Medidas de la fuente de depuración de ebitenutil, con la que se dibuja el texto.
+const (
+	charWidth  = 6
+	lineHeight = 16
+)
+
+// tooltipDelay es cuántos ticks hay que dejar el cursor quieto sobre un
+// widget para ver su tooltip.
+const tooltipDelay = 30
+
+// Repetición de las teclas mantenidas, en ticks.
+const (
+	keyRepeatDelay    = 24
+	keyRepeatInterval = 4
+)
+
+// Teclas de foco.
+const (
+	focusKey   = ebiten.KeyF2
+	unfocusKey = ebiten.KeyEscape
+)
+
+// Theme son los colores y las medidas de la interfaz.
+type Theme struct {
+	Panel    color.Color
+	Face     color.Color
+	Hover    color.Color
+	Pressed  color.Color
+	Disabled color.Color
+	Border   color.Color
+	Focus    color.Color
+	Accent   color.Color // toggles encendidos y perilla de los sliders
+	Tooltip  color.Color
+	Padding  float64 // borde interior de los paneles
+	Spacing  float64 // separación entre widgets
+}
+
+// DefaultTheme es el tema de los botones de siempre del simulador.
+var DefaultTheme = Theme{
+	Panel:    color.RGBA{20, 20, 30, 200},
+	Face:     color.RGBA{70, 130, 180, 255},
+	Hover:    color.RGBA{90, 155, 205, 255},
+	Pressed:  color.RGBA{45, 95, 140, 255},
+	Disabled: color.RGBA{70, 75, 85, 255},
+	Border:   color.RGBA{200, 200, 200, 255},
+	Focus:    color.RGBA{255, 210, 80, 255},
+	Accent:   color.RGBA{80, 220, 120, 255},
+	Tooltip:  color.RGBA{10, 10, 15, 230},
+	Padding:  8,
+	Spacing:  6,
+}
+
+// state es cómo se dibuja un widget en este frame.
+type state struct {
+	hovered, pressed, focused, disabled bool
+}
+
+// UI son los paneles de la pantalla y el estado del mouse y del foco.
+type UI struct {
+	Theme  Theme
+	panels []*Panel
+
+	hovered    Widget
+	pressed    Widget
+	focused    Widget
+	hoverTicks int
+	screenW    float64
+	screenH    float64
+}
+
+// New crea una interfaz con los paneles dados y el tema por defecto.
+func New(panels ...*Panel) *UI {
+	return &UI{Theme: DefaultTheme, panels: panels}
+}
+
+// Update acomoda los paneles a una pantalla de screenW x screenH y procesa
+// el mouse y el teclado. Va una vez por tick, antes del resto del input.
+func (u *UI) Update(screenW, screenH int) {
+	u.screenW, u.screenH = float64(screenW), float64(screenH)
+	for _, p := range u.panels {
+		p.layout(u.screenW, u.screenH, &u.Theme)
+	}
+
+	mx, my := ebiten.CursorPosition()
+	x, y := float64(mx), float64(my)
+	hovered := u.widgetAt(x, y)
+	if hovered != u.hovered {
+		u.hovered, u.hoverTicks = hovered, 0
+	} else {
+		u.hoverTicks++
+	}
+
+	if u.focused != nil && !u.usable(u.focused) {
+		u.focused = nil
+	}
+	u.updateMouse(x, y)
+	u.updateKeys()
+}
+
+func (u *UI) updateMouse(x, y float64) {
+	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		u.focused = nil
+		if w := u.hovered; w != nil && w.base().enabled() {
+			u.pressed = w
+			if w.focusable() {
+				u.focused = w
+			}
+			w.press(x, y)
+		}
+	}
+	if u.pressed == nil {
+		return
+	}
+	if !u.pressed.base().enabled() {
+		u.pressed = nil
+		return
+	}
+	if inpututil.IsMouseButtonJustReleased(ebiten.MouseButtonLeft) {
+		u.pressed.release(u.hovered == u.pressed)
+		u.pressed = nil
+		return
+	}
+	u.pressed.drag(x, y)
+}
+
+func (u *UI) updateKeys() {
+	if inpututil.IsKeyJustPressed(focusKey) {
+		u.moveFocus(ebiten.IsKeyPressed(ebiten.KeyShift))
+	}
+	if inpututil.IsKeyJustPressed(unfocusKey) {
+		u.focused = nil
+	}
+	if u.focused == nil {
+		return
+	}
+	for _, k := range []ebiten.Key{ebiten.KeyEnter, ebiten.KeySpace, ebiten.KeyArrowLeft, ebiten.KeyArrowRight} {
+		if repeated(k) {
+			u.focused.key(k)
+		}
+	}
+}
+
+// repeated reporta si k se acaba de apretar o si, mantenida, le toca repetir.
+func repeated(k ebiten.Key) bool {
+	d := inpututil.KeyPressDuration(k)
+	return d == 1 || d >= keyRepeatDelay && (d-keyRepeatDelay)%keyRepeatInterval == 0
+}
+
+// moveFocus pasa el foco al siguiente widget que lo acepta, o al anterior.
+func (u *UI) moveFocus(back bool) {
+	var ws []Widget
+	for _, p := range u.panels {
+		if !p.visible() {
+			continue
+		}
+		for _, w := range p.Children {
+			if w.focusable() && w.base().enabled() {
+				ws = append(ws, w)
+			}
+		}
+	}
+	if len(ws) == 0 {
+		u.focused = nil
+		return
+	}
+	i := -1
+	for j, w := range ws {
+		if w == u.focused {
+			i = j
+		}
+	}
+	switch {
+	case i < 0 && back:
+		i = len(ws) - 1
+	case i < 0:
+		i = 0
+	case back:
+		i = (i + len(ws) - 1) % len(ws)
+	default:
+		i = (i + 1) % len(ws)
+	}
+	u.focused = ws[i]
+}
+
+// usable reporta si w está en un panel visible y habilitado.
+func (u *UI) usable(w Widget) bool {
+	if !w.base().enabled() {
+		return false
+	}
+	for _, p := range u.panels {
+		if !p.visible() {
+			continue
+		}
+		for _, c := range p.Children {
+			if c == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// widgetAt es el widget bajo el punto (x, y) de la pantalla; los paneles
+// agregados después quedan encima.
+func (u *UI) widgetAt(x, y float64) Widget {
+	p := utils.Vector2D{X: x, Y: y}
+	for i := len(u.panels) - 1; i >= 0; i-- {
+		panel := u.panels[i]
+		if !panel.visible() || !panel.bounds.Contains(p) {
+			continue
+		}
+		for _, w := range panel.Children {
+			if w.base().bounds.Contains(p) {
+				return w
+			}
+		}
+		return nil
+	}
+	return nil
+}
+
+// Contains reporta si el punto (x, y) de la pantalla cae sobre un panel
+// visible, para que un clic ahí no le llegue al mundo.
+func (u *UI) Contains(x, y float64) bool {
+	p := utils.Vector2D{X: x, Y: y}
+	for _, panel := range u.panels {
+		if panel.visible() && panel.bounds.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFocus reporta si un widget tiene el foco del teclado: mientras tanto,
+// Enter, Espacio y las flechas izquierda y derecha son suyos.
+func (u *UI) HasFocus() bool {
+	return u.focused != nil
+}
+
+// Draw dibuja los paneles visibles y el tooltip del widget bajo el cursor.
+func (u *UI) Draw(dst *ebiten.Image) {
+	th := &u.Theme
+	for _, p := range u.panels {
+		if !p.visible() {
+			continue
+		}
+		p.draw(dst, th)
+		for _, w := range p.Children {
+			w.draw(dst, state{
+				hovered:  w == u.hovered,
+				pressed:  w == u.pressed && w == u.hovered,
+				focused:  w == u.focused,
+				disabled: !w.base().enabled(),
+			}, th)
+		}
+	}
+	if u.hovered != nil && u.hoverTicks >= tooltipDelay && u.hovered.base().Tooltip != "" {
+		u.drawTooltip(dst, u.hovered.base().Tooltip)
+	}
+}
+
+// drawTooltip dibuja text junto al cursor, sin salirse de la pantalla.
+func (u *UI) drawTooltip(dst *ebiten.Image, text string) {
+	w, h := textSize(text)
+	w, h = w+2*u.Theme.Padding, h+2*u.Theme.Padding
+	mx, my := ebiten.CursorPosition()
+	x, y := float64(mx)+12, float64(my)+16
+	x = max(0, min(x, u.screenW-w))
+	if y+h > u.screenH {
+		y = float64(my) - h - 4
+	}
+	ebitenutil.DrawRect(dst, x, y, w, h, u.Theme.Tooltip)
+	ebitenutil.DebugPrintAt(dst, text, int(x+u.Theme.Padding), int(y+u.Theme.Padding))
+}
+
+// textSize es lo que ocupa text, que puede tener varias líneas.
+func textSize(text string) (w, h float64) {
+	lines := strings.Split(text, "\n")
+	for _, l := range lines {
+		w = max(w, float64(utf8.RuneCountInString(l)*charWidth))
+	}
+	return w, float64(len(lines) * lineHeight)
+}
@@ -0,0 +1,166 @@
+package ui
+
+import (
+	"testing"
+
+	"pybot-simulator/utils"
+)
+
+func TestPanelAnchoring(t *testing.T) {
+	// Dos botones: 2*8 de borde + 28 + 6 + 28 = 78 de alto
+	const screenW, screenH = 800.0, 600.0
+	tests := []struct {
+		anchor Anchor
+		want   utils.Rect
+	}{
+		{TopLeft, utils.Rect{X: 10, Y: 20, W: 100, H: 78}},
+		{Top, utils.Rect{X: 360, Y: 20, W: 100, H: 78}},
+		{Center, utils.Rect{X: 360, Y: 281, W: 100, H: 78}},
+		{Right, utils.Rect{X: 690, Y: 281, W: 100, H: 78}},
+		{BottomLeft, utils.Rect{X: 10, Y: 502, W: 100, H: 78}},
+		{BottomRight, utils.Rect{X: 690, Y: 502, W: 100, H: 78}},
+	}
+	for _, tt := range tests {
+		first, second := NewButton("uno", nil), NewButton("dos", nil)
+		p := NewPanel(tt.anchor, 100, first, second)
+		p.MarginX, p.MarginY = 10, 20
+		p.layout(screenW, screenH, &DefaultTheme)
+
+		if p.bounds != tt.want {
+			t.Errorf("ancla %d: panel en %+v, se esperaba %+v", tt.anchor, p.bounds, tt.want)
+		}
+		// Los widgets van adentro del borde, uno debajo del otro
+		want := utils.Rect{X: tt.want.X + 8, Y: tt.want.Y + 8, W: 84, H: buttonHeight}
+		if got := first.Bounds(); got != want {
+			t.Errorf("ancla %d: primer botón en %+v, se esperaba %+v", tt.anchor, got, want)
+		}
+		want.Y += buttonHeight + 6
+		if got := second.Bounds(); got != want {
+			t.Errorf("ancla %d: segundo botón en %+v, se esperaba %+v", tt.anchor, got, want)
+		}
+	}
+
+	// El título agrega una línea antes de los widgets
+	b := NewButton("uno", nil)
+	p := NewPanel(BottomLeft, 100, b)
+	p.Title = "Controles"
+	p.layout(screenW, screenH, &DefaultTheme)
+	if want := 2*8 + lineHeight + 6 + buttonHeight; p.bounds.H != float64(want) {
+		t.Errorf("panel con título de alto %g, se esperaba %d", p.bounds.H, want)
+	}
+	if got := b.Bounds().Y - p.bounds.Y; got != 8+lineHeight+6 {
+		t.Errorf("el botón empieza a %g del borde con título", got)
+	}
+
+	// Contains sigue al panel cuando la pantalla cambia de tamaño
+	u := New(p)
+	for _, size := range [][2]float64{{800, 600}, {1200, 900}} {
+		p.layout(size[0], size[1], &u.Theme)
+		r := p.bounds
+		if !u.Contains(r.X+1, r.Y+1) || u.Contains(r.X-1, r.Y-1) {
+			t.Errorf("pantalla %v: Contains no coincide con el panel en %+v", size, r)
+		}
+	}
+}
+
+func TestSliderSnapsAndClamps(t *testing.T) {
+	var value float64
+	calls := 0
+	sl := NewSlider("velocidad", 0, 10, 0.5, func() float64 { return value }, func(v float64) {
+		value = v
+		calls++
+	})
+
+	tests := []struct {
+		in, want float64
+	}{
+		{3.3, 3.5},
+		{7.74, 7.5},
+		{0.2, 0},
+		{-4, 0},
+		{12, 10},
+		{10.3, 10},
+	}
+	for _, tt := range tests {
+		sl.set(tt.in)
+		if value != tt.want {
+			t.Errorf("set(%g) dejó %g, se esperaba %g", tt.in, value, tt.want)
+		}
+	}
+
+	// Un valor que redondea al actual no vuelve a llamar a Set
+	calls = 0
+	sl.set(9.9)
+	if calls != 0 {
+		t.Errorf("set con el mismo valor llamó %d veces a Set", calls)
+	}
+
+	// Sin paso el valor no se redondea, pero sigue acotado
+	sl.Step = 0
+	sl.set(3.3)
+	if value != 3.3 {
+		t.Errorf("sin paso set(3.3) dejó %g", value)
+	}
+	sl.set(-1)
+	if value != 0 {
+		t.Errorf("sin paso set(-1) dejó %g", value)
+	}
+
+	// El paso cuenta desde Min, no desde cero
+	offset := NewSlider("ítems", 1, 9, 2, func() float64 { return value }, func(v float64) { value = v })
+	offset.set(4.2)
+	if value != 5 {
+		t.Errorf("con Min 1 y paso 2 set(4.2) dejó %g, se esperaba 5", value)
+	}
+}
+
+func TestMoveFocusWrapsAround(t *testing.T) {
+	hidden := false
+	first := NewButton("primero", nil)
+	disabled := NewToggle("apagado", func() bool { return false }, nil)
+	disabled.Enabled = func() bool { return false }
+	slider := NewSlider("valor", 0, 1, 0, func() float64 { return 0 }, nil)
+	inHidden := NewButton("oculto", nil)
+	last := NewButton("último", nil)
+
+	controls := NewPanel(TopLeft, 100, first, NewLabel(func() string { return "etiqueta" }), disabled, slider)
+	other := NewPanel(TopRight, 100, inHidden)
+	other.Visible = func() bool { return hidden }
+	u := New(controls, other, NewPanel(BottomLeft, 100, last))
+	names := map[Widget]string{first: "primero", slider: "valor", inHidden: "oculto", last: "último", nil: "ninguno"}
+
+	// Las etiquetas, los widgets deshabilitados y los paneles ocultos no
+	// reciben el foco
+	forward := []Widget{first, slider, last, first, slider}
+	for i, want := range forward {
+		u.moveFocus(false)
+		if u.focused != want {
+			t.Fatalf("paso %d hacia adelante: foco en %s, se esperaba %s", i, names[u.focused], names[want])
+		}
+	}
+
+	u.focused = nil
+	backward := []Widget{last, slider, first, last}
+	for i, want := range backward {
+		u.moveFocus(true)
+		if u.focused != want {
+			t.Fatalf("paso %d hacia atrás: foco en %s, se esperaba %s", i, names[u.focused], names[want])
+		}
+	}
+
+	// Al mostrarse el panel, su botón entra en la vuelta
+	hidden = true
+	u.focused = slider
+	u.moveFocus(false)
+	if u.focused != inHidden {
+		t.Errorf("después del slider el foco quedó en %s, se esperaba oculto", names[u.focused])
+	}
+
+	// Sin widgets que acepten el foco, nadie lo tiene
+	empty := New(NewPanel(Center, 100, NewLabel(func() string { return "solo texto" })))
+	empty.focused = first
+	empty.moveFocus(false)
+	if empty.HasFocus() {
+		t.Error("el foco quedó en un widget sin panel")
+	}
+}
@@ -0,0 +1,262 @@
+package ui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+
+	"pybot-simulator/utils"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Alto de cada widget dentro de un panel.
+const (
+	buttonHeight = 28
+	toggleHeight = 20
+	sliderHeight = 34
+	toggleBox    = 14
+	sliderKnob   = 8
+)
+
+// Widget es un elemento de un panel. Los widgets son los de este paquete.
+type Widget interface {
+	base() *Base
+	height() float64
+	focusable() bool
+	press(x, y float64)  // clic sobre el widget
+	drag(x, y float64)   // el botón sigue apretado después del clic
+	release(inside bool) // se soltó el botón, inside = sobre el widget
+	key(k ebiten.Key)    // tecla para el widget con el foco
+	draw(dst *ebiten.Image, s state, th *Theme)
+}
+
+// Base es lo común a todos los widgets.
+type Base struct {
+	Tooltip string
+	// Enabled dice si el widget acepta clics y foco; nil = siempre.
+	Enabled func() bool
+
+	bounds utils.Rect
+}
+
+func (b *Base) base() *Base { return b }
+
+func (b *Base) enabled() bool {
+	return b.Enabled == nil || b.Enabled()
+}
+
+// Bounds es el rectángulo del widget en la pantalla, del último Update.
+func (b *Base) Bounds() utils.Rect {
+	return b.bounds
+}
+
+// Por defecto un widget no hace nada con el mouse ni con el teclado.
+func (b *Base) focusable() bool     { return false }
+func (b *Base) press(x, y float64)  {}
+func (b *Base) drag(x, y float64)   {}
+func (b *Base) release(inside bool) {}
+func (b *Base) key(k ebiten.Key)    {}
+
+// face es el color de fondo de un widget según su estado.
+func face(s state, th *Theme) color.Color {
+	switch {
+	case s.disabled:
+		return th.Disabled
+	case s.pressed:
+		return th.Pressed
+	case s.hovered:
+		return th.Hover
+	default:
+		return th.Face
+	}
+}
+
+// frame dibuja el borde de r, resaltado si el widget tiene el foco.
+func frame(dst *ebiten.Image, r utils.Rect, s state, th *Theme) {
+	clr, width := th.Border, float32(1)
+	if s.focused {
+		clr, width = th.Focus, 2
+	}
+	vector.StrokeRect(dst, float32(r.X), float32(r.Y), float32(r.W), float32(r.H), width, clr, false)
+}
+
+// Label es texto fijo o calculado en cada frame.
+type Label struct {
+	Base
+	Text func() string
+}
+
+// NewLabel crea una etiqueta que muestra lo que devuelve text.
+func NewLabel(text func() string) *Label {
+	return &Label{Text: text}
+}
+
+func (l *Label) height() float64 {
+	_, h := textSize(l.Text())
+	return h
+}
+
+func (l *Label) draw(dst *ebiten.Image, s state, th *Theme) {
+	ebitenutil.DebugPrintAt(dst, l.Text(), int(l.bounds.X), int(l.bounds.Y))
+}
+
+// Button llama a OnClick al soltar el clic sobre él o con Enter o Espacio.
+type Button struct {
+	Base
+	Text    string
+	OnClick func()
+}
+
+// NewButton crea un botón.
+func NewButton(text string, onClick func()) *Button {
+	return &Button{Text: text, OnClick: onClick}
+}
+
+func (b *Button) height() float64 { return buttonHeight }
+func (b *Button) focusable() bool { return true }
+
+func (b *Button) release(inside bool) {
+	if inside {
+		b.OnClick()
+	}
+}
+
+func (b *Button) key(k ebiten.Key) {
+	if k == ebiten.KeyEnter || k == ebiten.KeySpace {
+		b.OnClick()
+	}
+}
+
+func (b *Button) draw(dst *ebiten.Image, s state, th *Theme) {
+	r := b.bounds
+	ebitenutil.DrawRect(dst, r.X, r.Y, r.W, r.H, face(s, th))
+	frame(dst, r, s, th)
+	w, h := textSize(b.Text)
+	ebitenutil.DebugPrintAt(dst, b.Text, int(r.X+(r.W-w)/2), int(r.Y+(r.H-h)/2))
+}
+
+// Toggle es un interruptor: muestra Get y al activarlo llama a Set con el
+// valor contrario.
+type Toggle struct {
+	Base
+	Text string
+	Get  func() bool
+	Set  func(bool)
+}
+
+// NewToggle crea un interruptor.
+func NewToggle(text string, get func() bool, set func(bool)) *Toggle {
+	return &Toggle{Text: text, Get: get, Set: set}
+}
+
+func (t *Toggle) height() float64 { return toggleHeight }
+func (t *Toggle) focusable() bool { return true }
+
+func (t *Toggle) release(inside bool) {
+	if inside {
+		t.Set(!t.Get())
+	}
+}
+
+func (t *Toggle) key(k ebiten.Key) {
+	if k == ebiten.KeyEnter || k == ebiten.KeySpace {
+		t.Set(!t.Get())
+	}
+}
+
+func (t *Toggle) draw(dst *ebiten.Image, s state, th *Theme) {
+	r := t.bounds
+	box := utils.Rect{X: r.X, Y: r.Y + (r.H-toggleBox)/2, W: toggleBox, H: toggleBox}
+	ebitenutil.DrawRect(dst, box.X, box.Y, box.W, box.H, face(s, th))
+	if t.Get() {
+		ebitenutil.DrawRect(dst, box.X+3, box.Y+3, box.W-6, box.H-6, th.Accent)
+	}
+	frame(dst, box, s, th)
+	ebitenutil.DebugPrintAt(dst, t.Text, int(box.X+box.W+8), int(r.Y+(r.H-lineHeight)/2))
+}
+
+// Slider elige un valor entre Min y Max, en pasos de Step (0 = continuo),
+// arrastrando la perilla o con las flechas. Format muestra el valor junto al
+// texto; nil = el número.
+type Slider struct {
+	Base
+	Text           string
+	Min, Max, Step float64
+	Get            func() float64
+	Set            func(float64)
+	Format         func(float64) string
+}
+
+// NewSlider crea un slider.
+func NewSlider(text string, min, max, step float64, get func() float64, set func(float64)) *Slider {
+	return &Slider{Text: text, Min: min, Max: max, Step: step, Get: get, Set: set}
+}
+
+func (sl *Slider) height() float64 { return sliderHeight }
+func (sl *Slider) focusable() bool { return true }
+
+// track es la línea por la que corre la perilla.
+func (sl *Slider) track() utils.Rect {
+	r := sl.bounds
+	return utils.Rect{X: r.X + sliderKnob, Y: r.Y + lineHeight + (r.H-lineHeight)/2 - 2, W: r.W - 2*sliderKnob, H: 4}
+}
+
+func (sl *Slider) press(x, y float64) { sl.drag(x, y) }
+
+func (sl *Slider) drag(x, y float64) {
+	t := sl.track()
+	if t.W <= 0 {
+		return
+	}
+	sl.set(sl.Min + (x-t.X)/t.W*(sl.Max-sl.Min))
+}
+
+func (sl *Slider) key(k ebiten.Key) {
+	step := sl.Step
+	if step == 0 {
+		step = (sl.Max - sl.Min) / 20
+	}
+	switch k {
+	case ebiten.KeyArrowLeft:
+		sl.set(sl.Get() - step)
+	case ebiten.KeyArrowRight:
+		sl.set(sl.Get() + step)
+	}
+}
+
+// set ajusta v al paso y al rango y se lo pasa a Set si cambió.
+func (sl *Slider) set(v float64) {
+	if sl.Step > 0 {
+		v = sl.Min + math.Round((v-sl.Min)/sl.Step)*sl.Step
+	}
+	v = math.Max(sl.Min, math.Min(sl.Max, v))
+	if v != sl.Get() {
+		sl.Set(v)
+	}
+}
+
+func (sl *Slider) draw(dst *ebiten.Image, s state, th *Theme) {
+	v := sl.Get()
+	value := fmt.Sprintf("%g", v)
+	if sl.Format != nil {
+		value = sl.Format(v)
+	}
+	ebitenutil.DebugPrintAt(dst, sl.Text+": "+value, int(sl.bounds.X), int(sl.bounds.Y))
+
+	t := sl.track()
+	ebitenutil.DrawRect(dst, t.X, t.Y, t.W, t.H, face(s, th))
+	pos := 0.0
+	if sl.Max > sl.Min {
+		pos = (v - sl.Min) / (sl.Max - sl.Min)
+	}
+	knob := utils.Rect{X: t.X + pos*t.W - sliderKnob/2, Y: t.Y + t.H/2 - sliderKnob, W: sliderKnob, H: 2 * sliderKnob}
+	knobColor := th.Accent
+	if s.disabled {
+		knobColor = th.Disabled
+	}
+	ebitenutil.DrawRect(dst, knob.X, knob.Y, knob.W, knob.H, knobColor)
+	frame(dst, knob, s, th)
+}
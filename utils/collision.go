@@ -1 +1,115 @@
-package utils
\ No newline at end of file
+package utils
+
+import "math"
+
+// Rect es un rectángulo alineado a los ejes, en píxeles del mundo.
+type Rect struct {
+	X, Y, W, H float64
+}
+
+// RectAround devuelve el rectángulo de tamaño w x h centrado en p.
+func RectAround(p Vector2D, w, h float64) Rect {
+	return Rect{X: p.X - w/2, Y: p.Y - h/2, W: w, H: h}
+}
+
+func (r Rect) Contains(p Vector2D) bool {
+	return p.X >= r.X && p.X < r.X+r.W && p.Y >= r.Y && p.Y < r.Y+r.H
+}
+
+func (r Rect) Intersects(o Rect) bool {
+	return r.X < o.X+o.W && o.X < r.X+r.W && r.Y < o.Y+o.H && o.Y < r.Y+r.H
+}
+
+// CollisionGrid marca qué celdas del mundo están bloqueadas (paredes,
+// muebles, fuera del mapa). Todo lo que cae fuera de la rejilla cuenta como
+// bloqueado.
+type CollisionGrid struct {
+	Cols, Rows   int
+	CellW, CellH float64
+	blocked      []bool
+}
+
+// NewCollisionGrid crea una rejilla de cols x rows celdas libres.
+func NewCollisionGrid(cols, rows int, cellW, cellH float64) *CollisionGrid {
+	return &CollisionGrid{
+		Cols: cols, Rows: rows,
+		CellW: cellW, CellH: cellH,
+		blocked: make([]bool, cols*rows),
+	}
+}
+
+func (g *CollisionGrid) inside(col, row int) bool {
+	return col >= 0 && col < g.Cols && row >= 0 && row < g.Rows
+}
+
+// Set bloquea o libera una celda.
+func (g *CollisionGrid) Set(col, row int, blocked bool) {
+	if g.inside(col, row) {
+		g.blocked[row*g.Cols+col] = blocked
+	}
+}
+
+// BlockRect bloquea todas las celdas que toca r.
+func (g *CollisionGrid) BlockRect(r Rect) {
+	c0, r0, c1, r1 := g.cellSpan(r)
+	for row := r0; row <= r1; row++ {
+		for col := c0; col <= c1; col++ {
+			g.Set(col, row, true)
+		}
+	}
+}
+
+// Blocked indica si una celda está bloqueada.
+func (g *CollisionGrid) Blocked(col, row int) bool {
+	return !g.inside(col, row) || g.blocked[row*g.Cols+col]
+}
+
+// Cell devuelve la celda que contiene p.
+func (g *CollisionGrid) Cell(p Vector2D) (col, row int) {
+	return int(math.Floor(p.X / g.CellW)), int(math.Floor(p.Y / g.CellH))
+}
+
+// CellCenter devuelve el centro de una celda en píxeles del mundo.
+func (g *CollisionGrid) CellCenter(col, row int) Vector2D {
+	return Vector2D{X: (float64(col) + 0.5) * g.CellW, Y: (float64(row) + 0.5) * g.CellH}
+}
+
+// Free indica si r no toca ninguna celda bloqueada.
+func (g *CollisionGrid) Free(r Rect) bool {
+	c0, r0, c1, r1 := g.cellSpan(r)
+	for row := r0; row <= r1; row++ {
+		for col := c0; col <= c1; col++ {
+			if g.Blocked(col, row) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// SweepFree indica si un cuerpo de w x h puede ir en línea recta de a a b sin
+// tocar celdas bloqueadas.
+func (g *CollisionGrid) SweepFree(a, b Vector2D, w, h float64) bool {
+	step := math.Min(g.CellW, g.CellH) / 4
+	n := int(math.Ceil(a.Distance(b) / step))
+	for i := 0; i <= n; i++ {
+		t := 1.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		p := Vector2D{X: a.X + (b.X-a.X)*t, Y: a.Y + (b.Y-a.Y)*t}
+		if !g.Free(RectAround(p, w, h)) {
+			return false
+		}
+	}
+	return true
+}
+
+// cellSpan devuelve el rango de celdas que toca r (bordes derecho e inferior
+// exclusivos).
+func (g *CollisionGrid) cellSpan(r Rect) (c0, r0, c1, r1 int) {
+	c0, r0 = g.Cell(Vector2D{X: r.X, Y: r.Y})
+	c1 = int(math.Ceil((r.X+r.W)/g.CellW)) - 1
+	r1 = int(math.Ceil((r.Y+r.H)/g.CellH)) - 1
+	return c0, r0, c1, r1
+}
@@ -0,0 +1,89 @@
+package utils
+
+import "testing"
+
+func TestBlockRectTouchesCells(t *testing.T) {
+	g := NewCollisionGrid(5, 5, 10, 10)
+	// De (12, 12) a (30, 30): el borde derecho e inferior cae justo en la
+	// celda 3, que no se toca
+	g.BlockRect(Rect{X: 12, Y: 12, W: 18, H: 18})
+	for row := 0; row < 5; row++ {
+		for col := 0; col < 5; col++ {
+			want := col >= 1 && col <= 2 && row >= 1 && row <= 2
+			if got := g.Blocked(col, row); got != want {
+				t.Errorf("celda (%d, %d) bloqueada = %v, se esperaba %v", col, row, got, want)
+			}
+		}
+	}
+}
+
+func TestOutsideIsBlocked(t *testing.T) {
+	g := NewCollisionGrid(3, 2, 10, 10)
+	for _, c := range [][2]int{{-1, 0}, {0, -1}, {3, 0}, {0, 2}} {
+		if !g.Blocked(c[0], c[1]) {
+			t.Errorf("la celda %v fuera de la rejilla no está bloqueada", c)
+		}
+	}
+	// Set fuera de la rejilla no hace nada
+	g.Set(5, 5, false)
+	if !g.Free(Rect{X: 0, Y: 0, W: 30, H: 20}) {
+		t.Error("la rejilla entera no está libre")
+	}
+	if g.Free(Rect{X: 25, Y: 0, W: 10, H: 10}) {
+		t.Error("un rectángulo que sale por la derecha está libre")
+	}
+}
+
+func TestCellAndCenter(t *testing.T) {
+	g := NewCollisionGrid(4, 4, 20, 10)
+	cases := []struct {
+		p        Vector2D
+		col, row int
+	}{
+		{Vector2D{X: 0, Y: 0}, 0, 0},
+		{Vector2D{X: 19.9, Y: 9.9}, 0, 0},
+		{Vector2D{X: 20, Y: 10}, 1, 1},
+		{Vector2D{X: -0.1, Y: 5}, -1, 0},
+	}
+	for _, tc := range cases {
+		if col, row := g.Cell(tc.p); col != tc.col || row != tc.row {
+			t.Errorf("Cell(%v) = (%d, %d), se esperaba (%d, %d)", tc.p, col, row, tc.col, tc.row)
+		}
+	}
+	if c := g.CellCenter(1, 2); c != (Vector2D{X: 30, Y: 25}) {
+		t.Errorf("CellCenter(1, 2) = %v", c)
+	}
+}
+
+func TestSweepFree(t *testing.T) {
+	g := NewCollisionGrid(10, 10, 10, 10)
+	g.Set(5, 5, true)
+	a, b := Vector2D{X: 15, Y: 55}, Vector2D{X: 95, Y: 55}
+	if g.SweepFree(a, b, 4, 4) {
+		t.Error("el barrido atraviesa la celda bloqueada")
+	}
+	// Una fila más arriba pasa, salvo que el cuerpo sea tan alto que la roce
+	a.Y, b.Y = 45, 45
+	if !g.SweepFree(a, b, 4, 4) {
+		t.Error("el barrido por la fila libre está bloqueado")
+	}
+	if g.SweepFree(a, b, 4, 12) {
+		t.Error("un cuerpo de 12 de alto no roza la celda de abajo")
+	}
+	if !g.SweepFree(a, a, 4, 4) {
+		t.Error("un barrido sin distancia en una celda libre está bloqueado")
+	}
+}
+
+func TestRect(t *testing.T) {
+	r := RectAround(Vector2D{X: 10, Y: 10}, 4, 6)
+	if r != (Rect{X: 8, Y: 7, W: 4, H: 6}) {
+		t.Fatalf("RectAround = %+v", r)
+	}
+	if !r.Contains(Vector2D{X: 8, Y: 7}) || r.Contains(Vector2D{X: 12, Y: 10}) {
+		t.Error("Contains no incluye el borde izquierdo o incluye el derecho")
+	}
+	if !r.Intersects(Rect{X: 11, Y: 12, W: 5, H: 5}) || r.Intersects(Rect{X: 12, Y: 7, W: 5, H: 5}) {
+		t.Error("Intersects con rectángulos que se tocan o se solapan")
+	}
+}